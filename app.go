@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+	"user-api/auth"
+	"user-api/cache"
+	"user-api/config"
+	database "user-api/databases"
+	"user-api/events"
+	"user-api/logging"
+	"user-api/mail"
+	"user-api/migrations"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+	service "user-api/services"
+	"user-api/storage"
+	"user-api/webhooks"
+
+	"github.com/urfave/cli/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// app holds the repositories and services shared by the server and the
+// admin commands, built from the same configuration.
+type app struct {
+	cfg    config.Config
+	logger *slog.Logger
+	mongo  *mongo.Client
+	db     *mongo.Database
+
+	migrations *migrations.Runner
+
+	userMongo       repositories.UserRepo
+	userRepo        repositories.UserRepo
+	apiKeyRepo      repositories.ApiKeyRepo
+	sessionRepo     repositories.SessionRepo
+	addressRepo     repositories.AddressRepo
+	attributeRepo   repositories.AttributeSchemaRepo
+	emailChangeRepo repositories.EmailChangeRepo
+	webhookRepo     repositories.WebhookRepo
+	deliveryRepo    repositories.DeliveryRepo
+	outboxRepo      repositories.OutboxRepo
+	transactor      repositories.Transactor
+	blobStore       storage.BlobStore
+	userCache       cache.Cache
+	mailer          mail.Mailer
+	natsSink        *events.NATSSink
+
+	passwordPolicy auth.PasswordPolicy
+	agePolicy      models.AgePolicy
+	passwordHasher auth.PasswordHasher
+
+	userSvc        service.UserService
+	sessionSvc     service.SessionService
+	apiKeySvc      service.ApiKeyService
+	importSvc      service.UserImportService
+	exportSvc      service.UserExportService
+	addressSvc     service.AddressService
+	attributeSvc   service.AttributeService
+	avatarSvc      service.AvatarService
+	emailChangeSvc service.EmailChangeService
+	webhookSvc     service.WebhookService
+	outbox         *service.Outbox
+}
+
+func newLogger(cfg config.Config, w io.Writer) *slog.Logger {
+	logger := logging.New(w, logging.Config{Level: cfg.LogLevel, RedactFields: cfg.LogRedactFields})
+	slog.SetDefault(logger)
+	return logger
+}
+
+func newApp(ctx context.Context, cfg config.Config, logger *slog.Logger) (*app, error) {
+	a := &app{cfg: cfg, logger: logger}
+	configureJWT(cfg)
+
+	//init mongo connection
+	a.mongo = database.MongoInit(&ctx, cfg.MongoURI)
+	a.db = a.mongo.Database(cfg.MongoDatabase)
+	userDb := a.db
+	a.migrations = migrations.NewRunner(a.db, migrations.NewMongoStore(a.db), migrations.All(), instanceName(), logger)
+
+	//init repositories
+	a.userMongo = repositories.NewUserMongo(userDb.Collection("users"), logger)
+	a.userRepo = repositories.NewTracedUserRepo(repositories.NewInstrumentedUserRepo(a.userMongo))
+	a.apiKeyRepo = repositories.NewApiKeyMongo(userDb.Collection("api_keys"), logger)
+	a.sessionRepo = repositories.NewSessionMongo(userDb.Collection("sessions"), logger)
+	a.addressRepo = repositories.NewAddressMongo(userDb.Collection("users"), logger)
+
+	//init user cache, the addresses being embedded in the cached users
+	var err error
+	if a.userCache, err = newUserCache(cfg); err != nil {
+		return nil, err
+	}
+	if a.userCache != nil {
+		cached := repositories.NewCachedUserRepo(a.userRepo, a.userCache, cfg.UserCacheTTL, logger)
+		a.userRepo = cached
+		a.addressRepo = repositories.NewInvalidatingAddressRepo(a.addressRepo, cached)
+	}
+	a.attributeRepo = repositories.NewAttributeSchemaMongo(userDb.Collection("attribute_schemas"), logger)
+	a.emailChangeRepo = repositories.NewEmailChangeMongo(userDb.Collection("email_changes"), logger)
+	a.webhookRepo = repositories.NewWebhookMongo(userDb.Collection("webhooks"), logger)
+	a.deliveryRepo = repositories.NewDeliveryMongo(userDb.Collection("webhook_deliveries"), logger)
+	a.outboxRepo = repositories.NewOutboxMongo(userDb, logger)
+	a.transactor = repositories.NewMongoTransactor(a.mongo, logger)
+	if err := repositories.CheckTransactions(ctx, a.mongo); err != nil {
+		return nil, err
+	}
+
+	//init blob store
+	if a.blobStore, err = newBlobStore(cfg); err != nil {
+		return nil, err
+	}
+
+	//init mailer, logging the messages when no smtp server is set
+	if cfg.MailSMTPAddr != "" {
+		a.mailer = mail.NewSMTPMailer(mail.SMTPConfig{
+			Addr:     cfg.MailSMTPAddr,
+			Username: cfg.MailSMTPUsername,
+			Password: cfg.MailSMTPPassword,
+			From:     cfg.MailFrom,
+			Timeout:  cfg.MailSMTPTimeout,
+		})
+	} else {
+		a.mailer = mail.NewLogMailer(logger)
+	}
+
+	//init password policy
+	breached := auth.DefaultBreachedList()
+	if cfg.PasswordBreached != "" {
+		breached, err = auth.LoadHashList(cfg.PasswordBreached)
+		if err != nil {
+			return nil, fmt.Errorf("loading breached password list %s: %w", cfg.PasswordBreached, err)
+		}
+	}
+	logger.Debug("breached password list loaded", "entries", breached.Len())
+	a.passwordPolicy = auth.PasswordPolicy{
+		MinLength:      cfg.PasswordMinLength,
+		MaxBytes:       cfg.PasswordMaxBytes,
+		MinClasses:     cfg.PasswordMinClasses,
+		RejectPersonal: cfg.PasswordRejectPersonal,
+		Breached:       breached,
+	}
+	a.agePolicy = models.AgePolicy{Min: cfg.AgeMin, Max: cfg.AgeMax}
+
+	//init password hasher
+	var preferredHasher auth.PasswordHasher
+	switch cfg.PasswordHashAlgorithm {
+	case auth.AlgorithmBcrypt:
+		preferredHasher, err = auth.NewBcryptHasher(cfg.BcryptCost)
+	case auth.AlgorithmArgon2id:
+		preferredHasher, err = auth.NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism)
+	default:
+		err = fmt.Errorf("unknown password hash algorithm %q", cfg.PasswordHashAlgorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.passwordHasher = auth.NewPasswordHasher(preferredHasher)
+
+	//init services
+	a.sessionSvc = service.NewSession(a.sessionRepo, a.userRepo, logger)
+	a.attributeSvc = service.NewAttribute(a.attributeRepo, cfg.AttributeSchemaTTL, logger)
+	a.webhookSvc = service.NewWebhook(a.webhookRepo, a.deliveryRepo, webhooks.NewGuard(a.cfg.WebhookAllowPrivate), logger)
+	a.outbox = service.NewOutbox(a.transactor, a.outboxRepo)
+	a.avatarSvc = service.NewAvatar(a.userRepo, a.blobStore, a.outbox, logger)
+	a.userSvc = service.NewTracedUserService(service.NewUser(a.userRepo, a.sessionSvc, a.attributeSvc, a.avatarSvc, a.passwordPolicy, a.agePolicy, a.passwordHasher, a.outbox, logger))
+	a.apiKeySvc = service.NewApiKey(a.apiKeyRepo, logger)
+	a.importSvc = service.NewUserImport(a.userRepo, a.attributeSvc, a.passwordPolicy, a.agePolicy, a.passwordHasher, a.outbox, logger)
+	a.exportSvc = service.NewUserExport(a.userRepo, logger)
+	a.addressSvc = service.NewAddress(a.addressRepo, a.outbox, logger)
+	a.emailChangeSvc = service.NewEmailChange(a.emailChangeRepo, a.userRepo, a.sessionSvc, a.mailer, a.passwordHasher, a.outbox, service.EmailChangeOptions{
+		ConfirmURL: cfg.EmailConfirmURL,
+		RevertURL:  cfg.EmailRevertURL,
+		ConfirmTTL: cfg.EmailConfirmTTL,
+		RevertTTL:  cfg.EmailRevertTTL,
+	}, logger)
+
+	return a, nil
+}
+
+func configureJWT(cfg config.Config) {
+	auth.ConfigureJWT(auth.JWTConfig{
+		Issuer:       cfg.JWTIssuer,
+		Audience:     cfg.JWTAudience,
+		ClockSkew:    cfg.JWTClockSkew,
+		AcceptLegacy: cfg.JWTAcceptLegacy,
+	})
+}
+
+// newWebhookDispatcher returns the dispatcher sending the queued webhook
+// deliveries, run by the server only.
+func (a *app) newWebhookDispatcher() *service.WebhookDispatcher {
+	return service.NewWebhookDispatcher(a.deliveryRepo, a.webhookRepo, webhooks.NewSender(a.cfg.WebhookTimeout, webhooks.NewGuard(a.cfg.WebhookAllowPrivate)), service.WebhookOptions{
+		Workers:      a.cfg.WebhookWorkers,
+		PollInterval: a.cfg.WebhookPollInterval,
+		MaxAttempts:  a.cfg.WebhookMaxAttempts,
+		RetryBase:    a.cfg.WebhookRetryBase,
+		RetryMax:     a.cfg.WebhookRetryMax,
+		Lease:        a.cfg.WebhookTimeout + time.Minute,
+	}, a.logger)
+}
+
+// newOutboxRelay returns the relay publishing the stored events to the
+// configured sinks, run by the server only.
+func (a *app) newOutboxRelay() (*service.OutboxRelay, error) {
+	var sinks []events.Sink
+	for _, name := range a.cfg.OutboxSinks {
+		switch strings.TrimSpace(name) {
+		case "webhooks":
+			sinks = append(sinks, service.NewWebhookSink(a.webhookSvc))
+		case "nats":
+			sink, err := events.NewNATSSink(events.NATSConfig{
+				URL:           a.cfg.NATSURL,
+				Stream:        a.cfg.NATSStream,
+				SubjectPrefix: a.cfg.NATSSubjectPrefix,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("connecting to nats %s: %w", a.cfg.NATSURL, err)
+			}
+			a.natsSink = sink
+			sinks = append(sinks, sink)
+		case "log":
+			sinks = append(sinks, events.NewLogSink(a.logger))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return service.NewOutboxRelay(a.outboxRepo, sinks, instanceName(), service.OutboxOptions{
+		PollInterval: a.cfg.OutboxPollInterval,
+		BatchSize:    a.cfg.OutboxBatchSize,
+		Lease:        a.cfg.OutboxLease,
+		RetryBase:    a.cfg.OutboxRetryBase,
+		RetryMax:     a.cfg.OutboxRetryMax,
+	}, a.logger), nil
+}
+
+// newUserCache returns the cache of the users, nil when disabled.
+func newUserCache(cfg config.Config) (cache.Cache, error) {
+	switch cfg.UserCache {
+	case "none":
+		return nil, nil
+	case "memory":
+		// writes only invalidate the cache of the instance making them, the
+		// other instances serving their copy until USER_CACHE_TTL
+		return cache.NewLRU(cfg.UserCacheSize), nil
+	case "redis":
+		return cache.NewRedis(cache.RedisConfig{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+			Prefix:   cfg.RedisPrefix,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown user cache %q", cfg.UserCache)
+	}
+}
+
+// blobRoute is where the server serves the blobs of the local store.
+const blobRoute = "/blobs"
+
+func newBlobStore(cfg config.Config) (storage.BlobStore, error) {
+	switch cfg.BlobStore {
+	case "local":
+		publicURL := cfg.BlobPublicURL
+		if publicURL == "" {
+			publicURL = blobRoute
+		}
+		return storage.NewLocalStore(cfg.BlobLocalDir, publicURL)
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+			PublicURL: cfg.BlobPublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.BlobStore)
+	}
+}
+
+func (a *app) close(ctx context.Context) {
+	if err := a.mongo.Disconnect(ctx); err != nil {
+		a.logger.Error("error disconnecting from mongo", "error", err)
+	}
+	if r, ok := a.userCache.(*cache.Redis); ok {
+		if err := r.Close(); err != nil {
+			a.logger.Error("error closing redis client", "error", err)
+		}
+	}
+	if a.natsSink != nil {
+		if err := a.natsSink.Close(); err != nil {
+			a.logger.Error("error closing nats connection", "error", err)
+		}
+	}
+}
+
+// withApp runs fn with the app built from the environment, admin commands
+// log to stderr so stdout only carries their output.
+func withApp(c *cli.Context, fn func(ctx context.Context, a *app) error) error {
+	cfg := config.Load()
+	logger := newLogger(cfg, os.Stderr)
+	ctx := c.Context
+	a, err := newApp(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer a.close(ctx)
+
+	return fn(ctx, a)
+}
+
+// commandError turns the api error of a command into a message listing the
+// failed validation rules, if any.
+func commandError(action string, apiErr response.ApiError) error {
+	msg := apiErr.Error()
+	for _, f := range apiErr.Errors {
+		msg += "\n  " + f.Message
+	}
+	return fmt.Errorf("%s: %s", action, msg)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// instanceName identifies this process as the holder of locks.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
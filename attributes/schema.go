@@ -0,0 +1,232 @@
+// Package attributes compiles the JSON Schema admins define for the custom
+// attributes of users and checks attribute values against it.
+package attributes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+)
+
+// MaxSchemaBytes bounds the size of a schema.
+const MaxSchemaBytes = 64 << 10
+
+// Field is the request field the attributes are reported under, an
+// attribute being "attributes.<name>".
+const Field = "attributes"
+
+// namePattern keeps attribute names usable as Mongo field names.
+var namePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,63}$`)
+
+var errNoLoader = errors.New("schemas cannot reference external documents")
+
+// noLoader keeps $ref from reading files or fetching urls.
+type noLoader struct{}
+
+func (noLoader) Load(string) (any, error) {
+	return nil, errNoLoader
+}
+
+// Schema is a compiled attribute schema, an object schema whose properties
+// are the attributes users may have. A property marked "readOnly" is only
+// set by admins.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// Empty is the schema defining no attribute, in use until admins define one.
+var Empty = &Schema{}
+
+// Compile checks raw is a valid JSON Schema, defaulting to draft 2020-12, of
+// an object whose property names are valid attribute names.
+func Compile(raw []byte) (*Schema, error) {
+	if len(raw) > MaxSchemaBytes {
+		return nil, fmt.Errorf("schema exceeds %d bytes", MaxSchemaBytes)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok || obj["type"] != "object" {
+		return nil, errors.New(`schema must be of "type": "object"`)
+	}
+	if props, ok := obj["properties"].(map[string]any); ok {
+		for name := range props {
+			if !namePattern.MatchString(name) {
+				return nil, fmt.Errorf("attribute name %q must match %s", name, namePattern)
+			}
+		}
+	}
+
+	c := jsonschema.NewCompiler()
+	c.UseLoader(noLoader{})
+	c.AssertFormat()
+	if err := c.AddResource("attributes.json", doc); err != nil {
+		return nil, err
+	}
+	sch, err := c.Compile("attributes.json")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: sch}, nil
+}
+
+func (s *Schema) property(name string) (*jsonschema.Schema, bool) {
+	if s.schema == nil {
+		return nil, false
+	}
+	p, ok := s.schema.Properties[name]
+	return p, ok
+}
+
+// Names are the defined attributes, sorted.
+func (s *Schema) Names() []string {
+	names := make([]string, 0)
+	if s.schema != nil {
+		for name := range s.schema.Properties {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadOnly reports whether users cannot change the attribute name.
+func (s *Schema) ReadOnly(name string) bool {
+	p, ok := s.property(name)
+	return ok && p.ReadOnly
+}
+
+// Validate returns the failed rules of attrs by field: "unknown" for an
+// attribute the schema does not define, "required" for a missing one and
+// "schema:<keyword>" for a value failing a keyword of the schema.
+func (s *Schema) Validate(attrs map[string]interface{}) url.Values {
+	v := url.Values{}
+	for name := range attrs {
+		if _, ok := s.property(name); !ok {
+			v.Add(Field+"."+name, "unknown")
+		}
+	}
+	if s.schema == nil || len(v) != 0 {
+		return v
+	}
+
+	doc, err := normalize(attrs)
+	if err != nil {
+		v.Add(Field, "invalid")
+		return v
+	}
+	var verr *jsonschema.ValidationError
+	if err := s.schema.Validate(doc); errors.As(err, &verr) {
+		collect(verr, v)
+	} else if err != nil {
+		v.Add(Field, "invalid")
+	}
+	return v
+}
+
+// collect adds the leaves of the error tree to v.
+func collect(err *jsonschema.ValidationError, v url.Values) {
+	if len(err.Causes) != 0 {
+		for _, cause := range err.Causes {
+			collect(cause, v)
+		}
+		return
+	}
+
+	field := strings.Join(append([]string{Field}, err.InstanceLocation...), ".")
+	switch k := err.ErrorKind.(type) {
+	case *kind.Required:
+		for _, missing := range k.Missing {
+			v.Add(field+"."+missing, "required")
+		}
+	case *kind.FalseSchema:
+		v.Add(field, "unknown")
+	default:
+		keyword := "invalid"
+		if path := err.ErrorKind.KeywordPath(); len(path) != 0 {
+			keyword = path[len(path)-1]
+		}
+		v.Add(field, "schema:"+keyword)
+	}
+}
+
+// ChangedReadOnly returns the read only attributes whose values differ
+// between before and after, sorted.
+func (s *Schema) ChangedReadOnly(before, after map[string]interface{}) []string {
+	changed := make([]string, 0)
+	for _, name := range s.Names() {
+		if !s.ReadOnly(name) {
+			continue
+		}
+		b, inBefore := before[name]
+		a, inAfter := after[name]
+		if inBefore != inAfter || (inBefore && !equal(b, a)) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// ParseValue converts the query string value of a filter on the attribute
+// name to the type of the attribute, or returns the failed rule. Only
+// attributes of a single scalar type can be filtered on.
+func (s *Schema) ParseValue(name, raw string) (interface{}, string) {
+	p, ok := s.property(name)
+	if !ok {
+		return nil, "unknown"
+	}
+	if p.Types == nil || len(p.Types.ToStrings()) != 1 {
+		return nil, "filterable"
+	}
+
+	switch p.Types.ToStrings()[0] {
+	case "string":
+		return raw, ""
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b, ""
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return i, ""
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f, ""
+		}
+	default:
+		return nil, "filterable"
+	}
+	return nil, "schema:type"
+}
+
+// normalize turns attrs, decoded from a request or from Mongo, into the
+// json values the validator expects.
+func normalize(attrs map[string]interface{}) (any, error) {
+	if attrs == nil {
+		attrs = map[string]interface{}{}
+	}
+	b, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(b))
+}
+
+func equal(a, b interface{}) bool {
+	na, errA := normalize(map[string]interface{}{"v": a})
+	nb, errB := normalize(map[string]interface{}{"v": b})
+	return errA == nil && errB == nil && reflect.DeepEqual(na, nb)
+}
@@ -0,0 +1,86 @@
+package attributes
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"marketing_opt_in": {"type": "boolean"},
+		"plan": {"type": "string", "enum": ["free", "pro"], "readOnly": true},
+		"crm_id": {"type": "string", "pattern": "^crm-[0-9]+$"},
+		"seats": {"type": "integer", "minimum": 1},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	},
+	"required": ["marketing_opt_in"]
+}`
+
+func compile(t *testing.T) *Schema {
+	s, err := Compile([]byte(testSchema))
+	assert.Nil(t, err)
+	return s
+}
+
+func TestCompileRejectsInvalidSchemas(t *testing.T) {
+	cases := map[string]string{
+		"not json":      `{`,
+		"not an object": `{"type": "string"}`,
+		"invalid name":  `{"type": "object", "properties": {"a.b": {}}}`,
+		"bad keyword":   `{"type": "object", "properties": {"a": {"minimum": "one"}}}`,
+		"external ref":  `{"type": "object", "properties": {"a": {"$ref": "file:///etc/passwd"}}}`,
+	}
+	for name, raw := range cases {
+		_, err := Compile([]byte(raw))
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := compile(t)
+
+	assert.Empty(t, s.Validate(map[string]interface{}{"marketing_opt_in": true, "seats": float64(3), "tags": []interface{}{"a"}}))
+	assert.Equal(t, url.Values{
+		"attributes.marketing_opt_in": {"required"},
+		"attributes.seats":            {"schema:minimum"},
+		"attributes.crm_id":           {"schema:pattern"},
+	}, s.Validate(map[string]interface{}{"seats": 0, "crm_id": "42"}))
+	assert.Equal(t, url.Values{"attributes.marketing_opt_in": {"required"}}, s.Validate(nil))
+	assert.Equal(t, url.Values{"attributes.unknown": {"unknown"}}, s.Validate(map[string]interface{}{"unknown": 1}))
+}
+
+func TestEmptySchemaDefinesNoAttribute(t *testing.T) {
+	assert.Empty(t, Empty.Validate(nil))
+	assert.Equal(t, url.Values{"attributes.plan": {"unknown"}}, Empty.Validate(map[string]interface{}{"plan": "pro"}))
+}
+
+func TestChangedReadOnly(t *testing.T) {
+	s := compile(t)
+	before := map[string]interface{}{"plan": "pro", "seats": int32(2)}
+
+	assert.Empty(t, s.ChangedReadOnly(before, map[string]interface{}{"plan": "pro", "seats": float64(5)}))
+	assert.Equal(t, []string{"plan"}, s.ChangedReadOnly(before, map[string]interface{}{"plan": "free"}))
+	assert.Equal(t, []string{"plan"}, s.ChangedReadOnly(before, map[string]interface{}{}))
+	assert.Equal(t, []string{"plan"}, s.ChangedReadOnly(nil, map[string]interface{}{"plan": "free"}))
+}
+
+func TestParseValue(t *testing.T) {
+	s := compile(t)
+
+	v, rule := s.ParseValue("marketing_opt_in", "true")
+	assert.Equal(t, true, v)
+	assert.Empty(t, rule)
+
+	v, _ = s.ParseValue("seats", "4")
+	assert.Equal(t, int64(4), v)
+
+	_, rule = s.ParseValue("seats", "four")
+	assert.Equal(t, "schema:type", rule)
+	_, rule = s.ParseValue("tags", "a")
+	assert.Equal(t, "filterable", rule)
+	_, rule = s.ParseValue("nope", "a")
+	assert.Equal(t, "unknown", rule)
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	ApiKeyHeader = "X-API-Key"
+
+	apiKeyPrefix    = "uak_"
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+)
+
+// GenerateApiKey returns a new random key, the prefix shown to identify it in
+// listings and the hash to store. The key itself must only be shown once.
+func GenerateApiKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	prefix = key[:apiKeyPrefixLen]
+	hash = HashApiKey(key)
+	return
+}
+
+// HashApiKey hashes a key for storage and lookup. Keys carry 256 bits of
+// entropy so a fast hash is enough, unlike passwords.
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LooksLikeApiKey reports whether value has the shape of a key generated by
+// GenerateApiKey.
+func LooksLikeApiKey(value string) bool {
+	return strings.HasPrefix(value, apiKeyPrefix) && len(value) > apiKeyPrefixLen
+}
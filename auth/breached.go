@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed breached_passwords.txt
+var commonPasswords []byte
+
+// HashList is a BreachedCorpus of SHA-1 hashes searched in memory, no
+// network call is ever made. 20 bytes are kept per entry.
+type HashList struct {
+	hashes [][sha1.Size]byte
+}
+
+// DefaultBreachedList holds the most common passwords of public breaches,
+// bundled with the api.
+func DefaultBreachedList() *HashList {
+	l, _ := ReadHashList(bytes.NewReader(commonPasswords))
+	return l
+}
+
+// LoadHashList reads a corpus file, see ReadHashList.
+func LoadHashList(path string) (*HashList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadHashList(f)
+}
+
+// ReadHashList reads one entry per line, either a hex SHA-1 optionally
+// followed by ":count" as in the Pwned Passwords downloads, or a plain text
+// password. Empty lines and lines starting with # are skipped.
+func ReadHashList(r io.Reader) (*HashList, error) {
+	l := &HashList{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		l.hashes = append(l.hashes, lineHash(line))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(l.hashes, func(i, j int) bool {
+		return bytes.Compare(l.hashes[i][:], l.hashes[j][:]) < 0
+	})
+	return l, nil
+}
+
+func lineHash(line string) [sha1.Size]byte {
+	var h [sha1.Size]byte
+	hexHash, _, _ := strings.Cut(line, ":")
+	if len(hexHash) == 2*sha1.Size {
+		if n, err := hex.Decode(h[:], []byte(hexHash)); err == nil && n == sha1.Size {
+			return h
+		}
+	}
+	return sha1.Sum([]byte(line))
+}
+
+func (l *HashList) Len() int {
+	return len(l.hashes)
+}
+
+func (l *HashList) Contains(password string) bool {
+	h := sha1.Sum([]byte(password))
+	i := sort.Search(len(l.hashes), func(i int) bool {
+		return bytes.Compare(l.hashes[i][:], h[:]) >= 0
+	})
+	return i < len(l.hashes) && l.hashes[i] == h
+}
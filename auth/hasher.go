@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrPasswordMismatch = errors.New("password does not match")
+	ErrUnknownHash      = errors.New("unknown password hash format")
+)
+
+// PasswordHasher hashes passwords into self describing strings, bcrypt
+// modular crypt or PHC format, so the parameters a hash was produced with
+// can be told apart from the configured ones.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify returns ErrPasswordMismatch when password does not match.
+	Verify(encoded string, password string) error
+	// NeedsRehash reports whether encoded was produced by another algorithm
+	// or with other parameters than the configured ones.
+	NeedsRehash(encoded string) bool
+}
+
+// NewPasswordHasher hashes with preferred while still verifying the hashes of
+// every supported algorithm, letting stored hashes migrate on login.
+func NewPasswordHasher(preferred PasswordHasher) PasswordHasher {
+	return migratingHasher{preferred: preferred}
+}
+
+type migratingHasher struct {
+	preferred PasswordHasher
+}
+
+func (h migratingHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h migratingHasher) Verify(encoded string, password string) error {
+	switch {
+	case isBcrypt(encoded):
+		return BcryptHasher{}.Verify(encoded, password)
+	case isArgon2id(encoded):
+		return Argon2idHasher{}.Verify(encoded, password)
+	default:
+		return ErrUnknownHash
+	}
+}
+
+func (h migratingHasher) NeedsRehash(encoded string) bool {
+	return h.preferred.NeedsRehash(encoded)
+}
+
+// BcryptHasher hashes with bcrypt at Cost, bcrypt.DefaultCost when zero.
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher fails unless cost is within bcrypt.MinCost and
+// bcrypt.MaxCost: bcrypt hashes a lower cost at its default one, so every
+// login would rehash, and fails on a higher one.
+func NewBcryptHasher(cost int) (BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return BcryptHasher{}, fmt.Errorf("bcrypt cost %d is not between %d and %d", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return BcryptHasher{Cost: cost}, nil
+}
+
+func (h BcryptHasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), h.cost())
+	return string(b), err
+}
+
+func (h BcryptHasher) Verify(encoded string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h BcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcrypt(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost()
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// Argon2idHasher hashes with Argon2id, encoding the result in PHC format:
+//
+//	$argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>
+//
+// The zero value uses the OWASP recommended m=19456, t=2, p=1.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// Bounds of the parameters of the hashes Verify accepts, pre-hashed
+// passwords being imported: argon2 panics below one iteration, and the
+// memory and iterations a hash asks for are spent on every login.
+const (
+	argon2MaxMemory      = 1 << 20 // KiB
+	argon2MaxIterations  = 32
+	argon2MaxParallelism = 16
+	argon2MinSaltLen     = 8
+	argon2MaxSaltLen     = 64
+	argon2MinKeyLen      = 16
+	argon2MaxKeyLen      = 64
+)
+
+// NewArgon2idHasher fails unless the parameters are within the bounds of
+// the hashes Verify accepts, the hashes it produces being unverifiable
+// otherwise.
+func NewArgon2idHasher(memory, iterations, parallelism int) (Argon2idHasher, error) {
+	p := argon2Params{memory: uint32(memory), iterations: uint32(iterations), parallelism: uint8(parallelism)}
+	// the bounds checks first so the conversions cannot truncate
+	if memory < 0 || memory > argon2MaxMemory || iterations < 0 || iterations > argon2MaxIterations ||
+		parallelism < 0 || parallelism > argon2MaxParallelism || !p.valid() {
+		return Argon2idHasher{}, fmt.Errorf("argon2id parameters m=%d,t=%d,p=%d are not within m=%d..%d KiB, t=1..%d and p=1..%d",
+			memory, iterations, parallelism, 8*parallelism, argon2MaxMemory, argon2MaxIterations, argon2MaxParallelism)
+	}
+	return Argon2idHasher{Memory: p.memory, Iterations: p.iterations, Parallelism: p.parallelism}, nil
+}
+
+func (h Argon2idHasher) params() argon2Params {
+	p := argon2Params{memory: h.Memory, iterations: h.Iterations, parallelism: h.Parallelism}
+	if p.memory == 0 {
+		p.memory = 19456
+	}
+	if p.iterations == 0 {
+		p.iterations = 2
+	}
+	if p.parallelism == 0 {
+		p.parallelism = 1
+	}
+	return p
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	p := h.params()
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, argon2KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) Verify(encoded string, password string) error {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h Argon2idHasher) NeedsRehash(encoded string) bool {
+	p, _, key, err := decodeArgon2id(encoded)
+	return err != nil || p != h.params() || len(key) != argon2KeyLen
+}
+
+func isArgon2id(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func decodeArgon2id(encoded string) (p argon2Params, salt []byte, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownHash
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil || !p.valid() {
+		return p, nil, nil, ErrUnknownHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(salt) < argon2MinSaltLen || len(salt) > argon2MaxSaltLen {
+		return p, nil, nil, ErrUnknownHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) < argon2MinKeyLen || len(key) > argon2MaxKeyLen {
+		return p, nil, nil, ErrUnknownHash
+	}
+	return p, salt, key, nil
+}
+
+// valid reports whether p is within the bounds of the hashes Verify accepts,
+// argon2 needing at least 8 KiB per lane.
+func (p argon2Params) valid() bool {
+	return p.iterations >= 1 && p.iterations <= argon2MaxIterations &&
+		p.parallelism >= 1 && p.parallelism <= argon2MaxParallelism &&
+		p.memory >= 8*uint32(p.parallelism) && p.memory <= argon2MaxMemory
+}
+
+// IsPasswordHash reports whether encoded is a hash PasswordHasher can verify,
+// used to accept pre-hashed passwords.
+func IsPasswordHash(encoded string) bool {
+	if isBcrypt(encoded) {
+		_, err := bcrypt.Cost([]byte(encoded))
+		return err == nil
+	}
+	_, _, _, err := decodeArgon2id(encoded)
+	return err == nil
+}
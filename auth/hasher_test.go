@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var fastArgon2id = Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestHashersRoundTrip(t *testing.T) {
+	for _, h := range []PasswordHasher{BcryptHasher{Cost: bcrypt.MinCost}, fastArgon2id} {
+		encoded, err := h.Hash("secret")
+
+		assert.Nil(t, err)
+		assert.Nil(t, h.Verify(encoded, "secret"))
+		assert.ErrorIs(t, h.Verify(encoded, "Secret"), ErrPasswordMismatch)
+		assert.False(t, h.NeedsRehash(encoded))
+	}
+}
+
+func TestConfiguredHashersRoundTrip(t *testing.T) {
+	bcryptHasher, err := NewBcryptHasher(bcrypt.MinCost)
+	assert.Nil(t, err)
+	argon2idHasher, err := NewArgon2idHasher(1024, argon2MaxIterations, argon2MaxParallelism)
+	assert.Nil(t, err)
+
+	for _, preferred := range []PasswordHasher{bcryptHasher, argon2idHasher} {
+		h := NewPasswordHasher(preferred)
+		encoded, err := h.Hash("secret")
+
+		assert.Nil(t, err)
+		assert.Nil(t, h.Verify(encoded, "secret"))
+		assert.False(t, h.NeedsRehash(encoded))
+	}
+}
+
+func TestConfiguredHashersRejectOutOfBoundsParameters(t *testing.T) {
+	for _, cost := range []int{0, bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		_, err := NewBcryptHasher(cost)
+		assert.NotNil(t, err, "cost %d", cost)
+	}
+
+	cases := [][3]int{
+		{19456, 40, 1},
+		{argon2MaxMemory + 1, 2, 1},
+		{19456, 2, 256},
+		{19456, 2, 0},
+		{19456, 0, 1},
+		{64, 2, 16},
+		{-1, 2, 1},
+	}
+	for _, c := range cases {
+		_, err := NewArgon2idHasher(c[0], c[1], c[2])
+		assert.NotNil(t, err, "m=%d,t=%d,p=%d", c[0], c[1], c[2])
+	}
+}
+
+func TestArgon2idPHCFormat(t *testing.T) {
+	encoded, err := fastArgon2id.Hash("secret")
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.Len(t, strings.Split(encoded, "$"), 6)
+}
+
+func TestArgon2idRejectsMalformedHash(t *testing.T) {
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5",
+	} {
+		assert.ErrorIs(t, fastArgon2id.Verify(encoded, "secret"), ErrUnknownHash, encoded)
+		assert.True(t, fastArgon2id.NeedsRehash(encoded), encoded)
+	}
+}
+
+func TestArgon2idRejectsOutOfBoundsParameters(t *testing.T) {
+	salt, key := strings.Repeat("c2FsdHNhbHQ", 2), strings.Repeat("a2V5a2V5a2V5a2V5", 2)
+	valid := fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=1$%s$%s", salt, key)
+	assert.True(t, IsPasswordHash(valid))
+
+	for _, encoded := range []string{
+		fmt.Sprintf("$argon2id$v=19$m=1024,t=0,p=1$%s$%s", salt, key),
+		fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=0$%s$%s", salt, key),
+		fmt.Sprintf("$argon2id$v=19$m=4,t=1,p=1$%s$%s", salt, key),
+		fmt.Sprintf("$argon2id$v=19$m=4194304,t=1,p=1$%s$%s", salt, key),
+		fmt.Sprintf("$argon2id$v=19$m=1024,t=1000,p=1$%s$%s", salt, key),
+		fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=1$%s$a2V5", salt),
+		fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=1$%s$%s", salt, strings.Repeat(key, 4)),
+		fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$%s", key),
+	} {
+		assert.False(t, IsPasswordHash(encoded), encoded)
+		assert.ErrorIs(t, fastArgon2id.Verify(encoded, "secret"), ErrUnknownHash, encoded)
+	}
+}
+
+func TestNeedsRehashOnParameterChange(t *testing.T) {
+	bcryptHash, _ := BcryptHasher{Cost: bcrypt.MinCost}.Hash("secret")
+	argonHash, _ := fastArgon2id.Hash("secret")
+
+	assert.True(t, BcryptHasher{Cost: bcrypt.MinCost + 1}.NeedsRehash(bcryptHash))
+	assert.True(t, BcryptHasher{Cost: bcrypt.MinCost}.NeedsRehash(argonHash))
+	assert.True(t, Argon2idHasher{Memory: 2048, Iterations: 1, Parallelism: 1}.NeedsRehash(argonHash))
+	assert.True(t, fastArgon2id.NeedsRehash(bcryptHash))
+}
+
+func TestMigratingHasherVerifiesEveryAlgorithm(t *testing.T) {
+	h := NewPasswordHasher(fastArgon2id)
+	bcryptHash, _ := BcryptHasher{Cost: bcrypt.MinCost}.Hash("secret")
+	argonHash, _ := h.Hash("secret")
+
+	assert.Nil(t, h.Verify(bcryptHash, "secret"))
+	assert.Nil(t, h.Verify(argonHash, "secret"))
+	assert.ErrorIs(t, h.Verify("plain", "plain"), ErrUnknownHash)
+	assert.True(t, h.NeedsRehash(bcryptHash))
+	assert.False(t, h.NeedsRehash(argonHash))
+}
+
+// The benchmarks show the cost of a login per configuration, run them with
+//
+//	go test ./auth -run ^$ -bench Hasher -benchmem
+func BenchmarkBcryptHasher(b *testing.B) {
+	for _, cost := range []int{10, 12, 14} {
+		benchmarkHasher(b, fmt.Sprintf("cost=%d", cost), BcryptHasher{Cost: cost})
+	}
+}
+
+func BenchmarkArgon2idHasher(b *testing.B) {
+	for _, h := range []Argon2idHasher{
+		{Memory: 19456, Iterations: 2, Parallelism: 1},
+		{Memory: 47104, Iterations: 1, Parallelism: 1},
+		{Memory: 65536, Iterations: 3, Parallelism: 4},
+	} {
+		benchmarkHasher(b, fmt.Sprintf("m=%d,t=%d,p=%d", h.Memory, h.Iterations, h.Parallelism), h)
+	}
+}
+
+func benchmarkHasher(b *testing.B, name string, h PasswordHasher) {
+	encoded, err := h.Hash("correct horse battery staple")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run(name, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = h.Verify(encoded, "correct horse battery staple")
+		}
+	})
+}
@@ -1,8 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
-	"log"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -10,17 +11,60 @@ import (
 
 var jwtKey = []byte("secret")
 
+// TokenTTL is the lifetime of the tokens issued by GenerateJWT.
+const TokenTTL = 2 * time.Hour
+
+// JWTConfig holds the issuer and audience of the tokens and the leeway
+// ValidateToken allows on their times.
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	// ClockSkew is the difference tolerated between the clock of the issuer
+	// and the one of the verifier on exp, nbf and iat.
+	ClockSkew time.Duration
+	// AcceptLegacy keeps accepting the tokens issued before subject ids,
+	// which identify the user by email and carry no issuer nor audience.
+	AcceptLegacy bool
+}
+
+var jwtConfig = JWTConfig{Issuer: "user-api", Audience: "user-api", ClockSkew: 30 * time.Second, AcceptLegacy: true}
+
+// ConfigureJWT sets the configuration of the tokens, once at startup.
+func ConfigureJWT(cfg JWTConfig) {
+	jwtConfig = cfg
+}
+
+// JWTClaim identifies the user by id in the sub claim.
 type JWTClaim struct {
-	Email string `json:"email"`
+	// Email identifies the user of legacy tokens, which have no subject.
+	Email string `json:"email,omitempty"`
+	// SessionID is the family id of the session created by the login, tokens
+	// issued before sessions existed do not carry it.
+	SessionID string `json:"sid,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateJWT(email string) (tokenString string, err error) {
-	expirationTime := time.Now().Add(2 * time.Hour)
+// Legacy tells whether the token was issued before subject ids.
+func (c *JWTClaim) Legacy() bool {
+	return c.Subject == ""
+}
+
+func GenerateJWT(userID string, sessionID string) (tokenString string, err error) {
+	jti := make([]byte, 16)
+	if _, err = rand.Read(jti); err != nil {
+		return
+	}
+	now := time.Now()
 	claims := &JWTClaim{
-		Email: email,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Subject:   userID,
+			Issuer:    jwtConfig.Issuer,
+			Audience:  jwtConfig.Audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(TokenTTL).Unix(),
+			Id:        base64.RawURLEncoding.EncodeToString(jti),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -28,8 +72,10 @@ func GenerateJWT(email string) (tokenString string, err error) {
 	return
 }
 
-func ValidateToken(signedToken string) (email string, err error) {
-	token, err := jwt.ParseWithClaims(
+func ValidateToken(signedToken string) (claims *JWTClaim, err error) {
+	// the times are checked below with the configured leeway, jwt-go has none
+	parser := jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}, SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(
 		signedToken,
 		&JWTClaim{},
 		func(token *jwt.Token) (interface{}, error) {
@@ -37,20 +83,52 @@ func ValidateToken(signedToken string) (email string, err error) {
 		},
 	)
 	if err != nil {
-		log.Printf("[JWT AUTH] Error parse claims %s", err.Error())
 		return
 	}
 	claims, ok := token.Claims.(*JWTClaim)
 	if !ok {
-		log.Println("[JWT AUTH] Couldn't parse claims")
 		err = errors.New("couldn't parse claims")
 		return
 	}
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		log.Println("[JWT AUTH] Token expired")
-		err = errors.New("token expired")
-		return
+	err = checkClaims(claims, time.Now())
+	return
+}
+
+func checkClaims(claims *JWTClaim, now time.Time) error {
+	skew := int64(jwtConfig.ClockSkew / time.Second)
+	unix := now.Unix()
+	if claims.ExpiresAt+skew < unix {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore-skew > unix {
+		return errors.New("token not valid yet")
+	}
+	if claims.IssuedAt-skew > unix {
+		return errors.New("token issued in the future")
+	}
+
+	if claims.Legacy() {
+		if !jwtConfig.AcceptLegacy || claims.Email == "" {
+			return errors.New("token has no subject")
+		}
+		return nil
+	}
+	if !claims.VerifyIssuer(jwtConfig.Issuer, true) {
+		return errors.New("invalid token issuer")
+	}
+	if !claims.VerifyAudience(jwtConfig.Audience, true) {
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
+
+// InspectToken decodes the claims of signedToken without trusting them, err
+// tells why ValidateToken rejects the token, if it does.
+func InspectToken(signedToken string) (claims *JWTClaim, err error) {
+	claims = &JWTClaim{}
+	if _, _, err = new(jwt.Parser).ParseUnverified(signedToken, claims); err != nil {
+		return nil, err
 	}
-	email = claims.Email
+	_, err = ValidateToken(signedToken)
 	return
 }
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectTokenDecodesExpiredTokens(t *testing.T) {
+	claims := &JWTClaim{Email: "a@test.com", SessionID: "family", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Minute).Unix()}}
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKey)
+	assert.Nil(t, err)
+
+	inspected, err := InspectToken(expired)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "a@test.com", inspected.Email)
+	assert.Equal(t, "family", inspected.SessionID)
+}
+
+func TestInspectTokenRejectsGarbage(t *testing.T) {
+	claims, err := InspectToken("not-a-jwt")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, claims)
+}
+
+func signed(t *testing.T, claims *JWTClaim) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKey)
+	assert.Nil(t, err)
+	return token
+}
+
+func TestGenerateJWTCarriesSubject(t *testing.T) {
+	token, err := GenerateJWT("6530d1b2e4b0a1c2d3e4f5a6", "family")
+	assert.Nil(t, err)
+
+	claims, err := ValidateToken(token)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "6530d1b2e4b0a1c2d3e4f5a6", claims.Subject)
+	assert.Equal(t, "", claims.Email)
+	assert.Equal(t, "user-api", claims.Issuer)
+	assert.Equal(t, "user-api", claims.Audience)
+	assert.NotEqual(t, "", claims.Id)
+	assert.NotZero(t, claims.IssuedAt)
+	assert.False(t, claims.Legacy())
+}
+
+func TestValidateTokenChecksIssuerAndAudience(t *testing.T) {
+	now := time.Now()
+	base := func() *JWTClaim {
+		return &JWTClaim{StandardClaims: jwt.StandardClaims{Subject: "id", Issuer: "user-api", Audience: "user-api", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}}
+	}
+	otherIssuer := base()
+	otherIssuer.Issuer = "other"
+	otherAudience := base()
+	otherAudience.Audience = "other"
+
+	_, err := ValidateToken(signed(t, base()))
+	assert.Nil(t, err)
+	_, err = ValidateToken(signed(t, otherIssuer))
+	assert.NotNil(t, err)
+	_, err = ValidateToken(signed(t, otherAudience))
+	assert.NotNil(t, err)
+}
+
+func TestValidateTokenClockSkew(t *testing.T) {
+	now := time.Now()
+	claims := func(exp, nbf time.Time) *JWTClaim {
+		return &JWTClaim{StandardClaims: jwt.StandardClaims{Subject: "id", Issuer: "user-api", Audience: "user-api", NotBefore: nbf.Unix(), ExpiresAt: exp.Unix()}}
+	}
+
+	_, err := ValidateToken(signed(t, claims(now.Add(-10*time.Second), now.Add(-time.Hour))))
+	assert.Nil(t, err, "expired within the skew")
+	_, err = ValidateToken(signed(t, claims(now.Add(-time.Minute), now.Add(-time.Hour))))
+	assert.NotNil(t, err, "expired beyond the skew")
+	_, err = ValidateToken(signed(t, claims(now.Add(time.Hour), now.Add(10*time.Second))))
+	assert.Nil(t, err, "not before within the skew")
+	_, err = ValidateToken(signed(t, claims(now.Add(time.Hour), now.Add(time.Minute))))
+	assert.NotNil(t, err, "not before beyond the skew")
+}
+
+func TestValidateTokenLegacyWindow(t *testing.T) {
+	legacy := signed(t, &JWTClaim{Email: "a@test.com", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()}})
+	defer ConfigureJWT(jwtConfig)
+
+	claims, err := ValidateToken(legacy)
+	assert.Nil(t, err)
+	assert.True(t, claims.Legacy())
+	assert.Equal(t, "a@test.com", claims.Email)
+
+	cfg := jwtConfig
+	cfg.AcceptLegacy = false
+	ConfigureJWT(cfg)
+	_, err = ValidateToken(legacy)
+	assert.NotNil(t, err)
+}
+
+func TestValidateTokenRejectsOtherAlgorithms(t *testing.T) {
+	claims := &JWTClaim{StandardClaims: jwt.StandardClaims{Subject: "id", Issuer: "user-api", Audience: "user-api", ExpiresAt: time.Now().Add(time.Hour).Unix()}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(jwtKey)
+	assert.Nil(t, err)
+
+	_, err = ValidateToken(token)
+
+	assert.NotNil(t, err)
+}
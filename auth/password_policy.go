@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BcryptMaxBytes is the length after which bcrypt silently ignores the rest
+// of a password.
+const BcryptMaxBytes = 72
+
+// Rules reported by PasswordPolicy.Check, in the "rule:param" form of the dto
+// validation rules so they render as field errors of the password field.
+const (
+	RuleMinLength    = "min_length"
+	RuleMaxBytes     = "max_bytes"
+	RuleCharClasses  = "char_classes"
+	RulePersonalInfo = "personal_info"
+	RuleBreached     = "breached"
+)
+
+// BreachedCorpus tells whether a password appears in a breach.
+type BreachedCorpus interface {
+	Contains(password string) bool
+}
+
+// PasswordPolicy is applied to every password a user chooses. The zero value
+// accepts anything.
+type PasswordPolicy struct {
+	MinLength int
+	// MaxBytes defaults to BcryptMaxBytes when zero.
+	MaxBytes int
+	// MinClasses is the number of classes among lower case, upper case,
+	// digits and symbols the password must mix.
+	MinClasses     int
+	RejectPersonal bool
+	Breached       BreachedCorpus
+}
+
+// Check returns the failed rules, personal holding the name and email of the
+// user the password is for.
+func (p PasswordPolicy) Check(password string, personal ...string) []string {
+	failed := make([]string, 0)
+
+	if p.MinLength > 0 && utf8.RuneCountInString(password) < p.MinLength {
+		failed = append(failed, RuleMinLength+":"+strconv.Itoa(p.MinLength))
+	}
+
+	maxBytes := p.MaxBytes
+	if maxBytes <= 0 || maxBytes > BcryptMaxBytes {
+		maxBytes = BcryptMaxBytes
+	}
+	if len(password) > maxBytes {
+		failed = append(failed, RuleMaxBytes+":"+strconv.Itoa(maxBytes))
+	}
+
+	if p.MinClasses > 1 && charClasses(password) < p.MinClasses {
+		failed = append(failed, RuleCharClasses+":"+strconv.Itoa(p.MinClasses))
+	}
+
+	if p.RejectPersonal && containsPersonal(password, personal) {
+		failed = append(failed, RulePersonalInfo)
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		failed = append(failed, RuleBreached)
+	}
+
+	return failed
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// containsPersonal looks for the name, each of its words and the email local
+// part in the password, ignoring pieces too short to be meaningful.
+func containsPersonal(password string, personal []string) bool {
+	pw := strings.ToLower(password)
+	for _, v := range personal {
+		v = strings.ToLower(strings.TrimSpace(v))
+		pieces := strings.Fields(v)
+		if local, _, ok := strings.Cut(v, "@"); ok {
+			pieces = append(pieces, local)
+		}
+		for _, piece := range pieces {
+			if utf8.RuneCountInString(piece) >= 3 && strings.Contains(pw, piece) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicyZeroValueOnlyBoundsBytes(t *testing.T) {
+	p := PasswordPolicy{}
+
+	assert.Empty(t, p.Check("a"))
+	assert.Equal(t, []string{"max_bytes:72"}, p.Check(strings.Repeat("a", 73)))
+}
+
+func TestPasswordPolicyRules(t *testing.T) {
+	p := PasswordPolicy{
+		MinLength:      8,
+		MaxBytes:       64,
+		MinClasses:     3,
+		RejectPersonal: true,
+		Breached:       DefaultBreachedList(),
+	}
+	cases := []struct {
+		password string
+		expected []string
+	}{
+		{"c0rrect-Horse", []string{}},
+		{"aB1!", []string{"min_length:8"}},
+		{strings.Repeat("aB1!", 17), []string{"max_bytes:64"}},
+		{"alllowercase", []string{"char_classes:3"}},
+		{"Maria-1990!", []string{"personal_info"}},
+		{"Jsilva#2022", []string{"personal_info"}},
+		{"P@ssw0rd", []string{"breached"}},
+		{"abc123", []string{"min_length:8", "char_classes:3", "breached"}},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, p.Check(tc.password, "Maria Souza", "jsilva@test.com"), tc.password)
+	}
+}
+
+func TestPasswordPolicyCountsRunes(t *testing.T) {
+	p := PasswordPolicy{MinLength: 4}
+
+	assert.Empty(t, p.Check("ção!"))
+}
+
+func TestReadHashList(t *testing.T) {
+	corpus := strings.Join([]string{
+		"# comment",
+		"",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493",
+		"hunter2",
+	}, "\n")
+
+	l, err := ReadHashList(strings.NewReader(corpus))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, l.Len())
+	assert.True(t, l.Contains("password"))
+	assert.True(t, l.Contains("hunter2"))
+	assert.False(t, l.Contains("Password"))
+}
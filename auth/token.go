@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	AuthorizationHeader = "Authorization"
+	// LegacyTokenHeader is the non standard header the api first read the jwt from.
+	LegacyTokenHeader = "token"
+	CSRFHeader        = "X-CSRF-Token"
+)
+
+// BearerToken extracts the token of an "Authorization: Bearer <token>" header
+// value, the scheme being case insensitive as per RFC 6750.
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// GenerateCSRFToken returns the random value of the double submit cookie.
+func GenerateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ValidCSRFToken compares the header and cookie values in constant time.
+func ValidCSRFToken(header, cookie string) bool {
+	if header == "" || cookie == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie)) == 1
+}
+
+// ParseSameSite maps the strict, lax and none configuration values to the
+// cookie attribute, defaulting to lax.
+func ParseSameSite(v string) http.SameSite {
+	switch strings.ToLower(v) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// GenerateSessionID returns the random family id shared by a session record
+// and the tokens issued for it.
+func GenerateSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateLinkToken returns the random token of a link sent by email and
+// the hash to store, the token itself only being in the email.
+func GenerateLinkToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashLinkToken(token), nil
+}
+
+// HashLinkToken hashes a link token for storage and lookup.
+func HashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache keeps byte values under string keys for a limited time.
+type Cache interface {
+	// Get returns the value of key, ok being false when it is missing or
+	// expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value under key, replacing the current one.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Add stores value under key unless a value is already there, telling
+	// whether it was stored.
+	Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Delete removes keys, missing ones are not an error.
+	Delete(ctx context.Context, keys ...string) error
+}
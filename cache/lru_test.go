@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	c.Get(ctx, "a")
+	c.Set(ctx, "c", []byte("3"), time.Minute)
+
+	_, ok, _ := c.Get(ctx, "b")
+	assert.False(t, ok)
+	v, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(v))
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+
+	now = now.Add(time.Minute)
+	_, ok, _ := c.Get(ctx, "a")
+
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRUAddKeepsLiveValues(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	added, _ := c.Add(ctx, "a", []byte("1"), time.Minute)
+	assert.True(t, added)
+	added, _ = c.Add(ctx, "a", []byte("2"), time.Minute)
+	assert.False(t, added)
+
+	now = now.Add(time.Minute)
+	added, _ = c.Add(ctx, "a", []byte("3"), time.Minute)
+	assert.True(t, added)
+	v, _, _ := c.Get(ctx, "a")
+	assert.Equal(t, "3", string(v))
+}
+
+func TestLRUDelete(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+
+	assert.Nil(t, c.Delete(ctx, "a", "missing"))
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisConfig struct {
+	// Addr is the host:port of the server.
+	Addr     string
+	Password string
+	DB       int
+	// Prefix is prepended to every key, so instances of different
+	// environments can share a server.
+	Prefix string
+}
+
+// Redis is a Cache shared by every instance of the api.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedis(cfg RedisConfig) *Redis {
+	client := redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	return &Redis{client: client, prefix: cfg.Prefix}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+func (c *Redis) Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.prefix+key, value, ttl).Result()
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
+
+func (c *Redis) Name() string {
+	return "cache"
+}
+
+func (c *Redis) Check(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
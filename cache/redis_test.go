@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRedis runs against the Redis of docker-compose.yml when
+// REDIS_TEST_ADDR is set, e.g. localhost:6379.
+func TestRedis(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+	c := NewRedis(RedisConfig{Addr: addr, Prefix: "user-api-test:"})
+	defer c.Close()
+	ctx := context.Background()
+	assert.Nil(t, c.Check(ctx))
+	assert.Nil(t, c.Delete(ctx, "a"))
+
+	_, ok, err := c.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	added, err := c.Add(ctx, "a", []byte("1"), time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, added)
+	added, err = c.Add(ctx, "a", []byte("2"), time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, added)
+
+	assert.Nil(t, c.Set(ctx, "a", []byte("3"), time.Minute))
+	v, ok, err := c.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "3", string(v))
+	ttl, err := c.client.TTL(ctx, "user-api-test:a").Result()
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Minute.Seconds(), ttl.Seconds(), 5)
+
+	assert.Nil(t, c.Delete(ctx, "a"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Port                   string
+	MongoURI               string
+	MongoDatabase          string
+	HealthCheckTimeout     time.Duration
+	ShutdownDrain          time.Duration
+	ShutdownTimeout        time.Duration
+	TracingExporter        string
+	OTLPEndpoint           string
+	TracingSampleRatio     float64
+	LogLevel               string
+	LogRedactFields        []string
+	AuthLegacyHeader       bool
+	AuthCookieEnabled      bool
+	AuthCookieName         string
+	AuthCookieDomain       string
+	AuthCookieSecure       bool
+	AuthCookieSameSite     string
+	AuthCSRFCookieName     string
+	JWTIssuer              string
+	JWTAudience            string
+	JWTClockSkew           time.Duration
+	JWTAcceptLegacy        bool
+	PasswordMinLength      int
+	PasswordMaxBytes       int
+	PasswordMinClasses     int
+	PasswordRejectPersonal bool
+	PasswordBreached       string
+	PasswordHashAlgorithm  string
+	AgeMin                 int
+	AgeMax                 int
+	AttributeSchemaTTL     time.Duration
+	BlobStore              string
+	BlobLocalDir           string
+	BlobPublicURL          string
+	S3Endpoint             string
+	S3Region               string
+	S3Bucket               string
+	S3AccessKey            string
+	S3SecretKey            string
+	S3UseSSL               bool
+	AvatarMaxBytes         int
+	ImportMaxBytes         int
+	MailSMTPAddr           string
+	MailSMTPUsername       string
+	MailSMTPPassword       string
+	MailSMTPTimeout        time.Duration
+	MailFrom               string
+	EmailConfirmURL        string
+	EmailRevertURL         string
+	EmailConfirmTTL        time.Duration
+	EmailRevertTTL         time.Duration
+	UserCache              string
+	UserCacheTTL           time.Duration
+	UserCacheSize          int
+	RedisAddr              string
+	RedisPassword          string
+	RedisDB                int
+	RedisPrefix            string
+	WebhookWorkers         int
+	WebhookPollInterval    time.Duration
+	WebhookTimeout         time.Duration
+	WebhookMaxAttempts     int
+	WebhookRetryBase       time.Duration
+	WebhookRetryMax        time.Duration
+	WebhookAllowPrivate    bool
+	OutboxSinks            []string
+	OutboxPollInterval     time.Duration
+	OutboxBatchSize        int
+	OutboxLease            time.Duration
+	OutboxRetryBase        time.Duration
+	OutboxRetryMax         time.Duration
+	NATSURL                string
+	NATSStream             string
+	NATSSubjectPrefix      string
+	BcryptCost             int
+	Argon2Memory           int
+	Argon2Iterations       int
+	Argon2Parallelism      int
+	MigrateOnStart         bool
+	MigrateLockWait        time.Duration
+}
+
+// Load reads the configuration from environment variables, falling back to
+// the defaults the api always ran with.
+func Load() Config {
+	return Config{
+		Port:                   getString("PORT", "8082"),
+		MongoURI:               getString("MONGO_URI", "mongodb://localhost:27017"),
+		MongoDatabase:          getString("MONGO_DATABASE", "user-api"),
+		HealthCheckTimeout:     getDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		ShutdownDrain:          getDuration("SHUTDOWN_DRAIN", 5*time.Second),
+		ShutdownTimeout:        getDuration("SHUTDOWN_TIMEOUT", 10*time.Second),
+		TracingExporter:        getString("TRACING_EXPORTER", "none"),
+		OTLPEndpoint:           getString("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracingSampleRatio:     getFloat("TRACING_SAMPLE_RATIO", 1),
+		LogLevel:               getString("LOG_LEVEL", "info"),
+		LogRedactFields:        getList("LOG_REDACT_FIELDS", []string{"email", "address", "name"}),
+		AuthLegacyHeader:       getBool("AUTH_LEGACY_TOKEN_HEADER", true),
+		AuthCookieEnabled:      getBool("AUTH_COOKIE_ENABLED", false),
+		AuthCookieName:         getString("AUTH_COOKIE_NAME", "access_token"),
+		AuthCookieDomain:       getString("AUTH_COOKIE_DOMAIN", ""),
+		AuthCookieSecure:       getBool("AUTH_COOKIE_SECURE", true),
+		AuthCookieSameSite:     getString("AUTH_COOKIE_SAMESITE", "lax"),
+		AuthCSRFCookieName:     getString("AUTH_CSRF_COOKIE_NAME", "csrf_token"),
+		JWTIssuer:              getString("JWT_ISSUER", "user-api"),
+		JWTAudience:            getString("JWT_AUDIENCE", "user-api"),
+		JWTClockSkew:           getDuration("JWT_CLOCK_SKEW", 30*time.Second),
+		JWTAcceptLegacy:        getBool("JWT_ACCEPT_LEGACY", true),
+		PasswordMinLength:      getInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxBytes:       getInt("PASSWORD_MAX_BYTES", 72),
+		PasswordMinClasses:     getInt("PASSWORD_MIN_CLASSES", 2),
+		PasswordRejectPersonal: getBool("PASSWORD_REJECT_PERSONAL", true),
+		PasswordBreached:       getString("PASSWORD_BREACHED_FILE", ""),
+		PasswordHashAlgorithm:  getString("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+		AgeMin:                 getInt("AGE_MIN", 0),
+		AgeMax:                 getInt("AGE_MAX", 0),
+		AttributeSchemaTTL:     getDuration("ATTRIBUTE_SCHEMA_TTL", 30*time.Second),
+		BlobStore:              getString("BLOB_STORE", "local"),
+		BlobLocalDir:           getString("BLOB_LOCAL_DIR", "data/blobs"),
+		BlobPublicURL:          getString("BLOB_PUBLIC_URL", ""),
+		S3Endpoint:             getString("S3_ENDPOINT", "s3.amazonaws.com"),
+		S3Region:               getString("S3_REGION", "us-east-1"),
+		S3Bucket:               getString("S3_BUCKET", "user-api"),
+		S3AccessKey:            getString("S3_ACCESS_KEY", ""),
+		S3SecretKey:            getString("S3_SECRET_KEY", ""),
+		S3UseSSL:               getBool("S3_USE_SSL", true),
+		AvatarMaxBytes:         getInt("AVATAR_MAX_BYTES", 5<<20),
+		ImportMaxBytes:         getInt("IMPORT_MAX_BYTES", 64<<20),
+		MailSMTPAddr:           getString("MAIL_SMTP_ADDR", ""),
+		MailSMTPUsername:       getString("MAIL_SMTP_USERNAME", ""),
+		MailSMTPPassword:       getString("MAIL_SMTP_PASSWORD", ""),
+		MailSMTPTimeout:        getDuration("MAIL_SMTP_TIMEOUT", 10*time.Second),
+		MailFrom:               getString("MAIL_FROM", "no-reply@localhost"),
+		EmailConfirmURL:        getString("EMAIL_CONFIRM_URL", "http://localhost:8082/v1/auth/email/confirm"),
+		EmailRevertURL:         getString("EMAIL_REVERT_URL", "http://localhost:8082/v1/auth/email/revert"),
+		EmailConfirmTTL:        getDuration("EMAIL_CONFIRM_TTL", 24*time.Hour),
+		EmailRevertTTL:         getDuration("EMAIL_REVERT_TTL", 7*24*time.Hour),
+		UserCache:              getString("USER_CACHE", "none"),
+		UserCacheTTL:           getDuration("USER_CACHE_TTL", time.Minute),
+		UserCacheSize:          getInt("USER_CACHE_SIZE", 10000),
+		RedisAddr:              getString("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:          getString("REDIS_PASSWORD", ""),
+		RedisDB:                getInt("REDIS_DB", 0),
+		RedisPrefix:            getString("REDIS_PREFIX", "user-api:"),
+		WebhookWorkers:         getInt("WEBHOOK_WORKERS", 2),
+		WebhookPollInterval:    getDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+		WebhookTimeout:         getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:     getInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:       getDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		WebhookRetryMax:        getDuration("WEBHOOK_RETRY_MAX", time.Hour),
+		WebhookAllowPrivate:    getBool("WEBHOOK_ALLOW_PRIVATE", false),
+		OutboxSinks:            getList("OUTBOX_SINKS", []string{"webhooks"}),
+		OutboxPollInterval:     getDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond),
+		OutboxBatchSize:        getInt("OUTBOX_BATCH_SIZE", 100),
+		OutboxLease:            getDuration("OUTBOX_LEASE", 30*time.Second),
+		OutboxRetryBase:        getDuration("OUTBOX_RETRY_BASE", time.Second),
+		OutboxRetryMax:         getDuration("OUTBOX_RETRY_MAX", 5*time.Minute),
+		NATSURL:                getString("NATS_URL", "nats://localhost:4222"),
+		NATSStream:             getString("NATS_STREAM", "USER_EVENTS"),
+		NATSSubjectPrefix:      getString("NATS_SUBJECT_PREFIX", "user-api.events"),
+		BcryptCost:             getInt("BCRYPT_COST", 14),
+		Argon2Memory:           getInt("ARGON2_MEMORY_KIB", 19456),
+		Argon2Iterations:       getInt("ARGON2_ITERATIONS", 2),
+		Argon2Parallelism:      getInt("ARGON2_PARALLELISM", 1),
+		MigrateOnStart:         getBool("MIGRATE_ON_START", false),
+		MigrateLockWait:        getDuration("MIGRATE_LOCK_WAIT", 2*time.Minute),
+	}
+}
+
+func getString(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	return strings.Split(v, ",")
+}
+
+func getBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"user-api/dto"
+	"user-api/mappers"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AddressController interface {
+	List() gin.HandlerFunc
+	Get() gin.HandlerFunc
+	Create() gin.HandlerFunc
+	Update() gin.HandlerFunc
+	Delete() gin.HandlerFunc
+}
+
+type AddressControllerImpl struct {
+	svc services.AddressService
+	log *slog.Logger
+}
+
+func NewAddress(svc services.AddressService, logger *slog.Logger) AddressController {
+	return AddressControllerImpl{svc: svc, log: logger.With("component", "address_controller")}
+}
+
+// owner returns the id of the user whose addresses are requested, aborting
+// unless it is the authenticated user or an admin.
+func (a AddressControllerImpl) owner(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	user, ok := currentUser(c, a.log)
+	if !ok {
+		return "", false
+	}
+	if user.ID.Hex() != id && !user.IsAdmin() {
+		a.log.InfoContext(c.Request.Context(), "cannot access addresses of different user", "user_id", id)
+		response.Abort(c, response.DifferentUserError)
+		return "", false
+	}
+	return id, true
+}
+
+// bind reads and validates the address of the request body.
+func (a AddressControllerImpl) bind(c *gin.Context) (dto.AddressReq, bool) {
+	req := dto.AddressReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		a.log.InfoContext(c.Request.Context(), "error parsing address input", "error", err)
+		response.Abort(c, response.BadRequestError)
+		return req, false
+	}
+
+	if v := req.ValidateFields(); len(v) != 0 {
+		response.Abort(c, response.NewValidationError(v))
+		return req, false
+	}
+	return req, true
+}
+
+// List addresses example godoc
+// @SummaryUser List addresses
+// @Description List the postal addresses of a user
+// @Param id path string true "User id"
+// @Produce json
+// @Success 200 {array} dto.AddressRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/addresses [get]
+func (a AddressControllerImpl) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := a.owner(c)
+		if !ok {
+			return
+		}
+
+		addresses, apiErr := a.svc.List(c.Request.Context(), userID)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.AddressesToRes(addresses))
+	}
+}
+
+// Get address example godoc
+// @SummaryUser Get address
+// @Description Get a postal address of a user
+// @Param id path string true "User id"
+// @Param addressId path string true "Address id"
+// @Produce json
+// @Success 200 {object} dto.AddressRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/addresses/{addressId} [get]
+func (a AddressControllerImpl) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := a.owner(c)
+		if !ok {
+			return
+		}
+
+		address, apiErr := a.svc.Get(c.Request.Context(), userID, c.Param("addressId"))
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.AddressToRes(address))
+	}
+}
+
+// Create address example godoc
+// @SummaryUser Create address
+// @Description Add a postal address, labeled home, billing or shipping, to a user. The postal code is checked against the rules of the ISO 3166 country. The first address is the default one.
+// @Param id path string true "User id"
+// @Param Address body dto.AddressReq true "Address"
+// @Accept json
+// @Produce json
+// @Success 201 {object} dto.AddressRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/addresses [post]
+func (a AddressControllerImpl) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := a.owner(c)
+		if !ok {
+			return
+		}
+		req, ok := a.bind(c)
+		if !ok {
+			return
+		}
+
+		address, apiErr := a.svc.Create(c.Request.Context(), userID, mappers.AddressReqToAddress(req), req.Default)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusCreated, mappers.AddressToRes(address))
+	}
+}
+
+// Update address example godoc
+// @SummaryUser Update address
+// @Description Replace a postal address of a user, "default": true makes it the default one
+// @Param id path string true "User id"
+// @Param addressId path string true "Address id"
+// @Param Address body dto.AddressReq true "Address"
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.AddressRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/addresses/{addressId} [put]
+func (a AddressControllerImpl) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := a.owner(c)
+		if !ok {
+			return
+		}
+		req, ok := a.bind(c)
+		if !ok {
+			return
+		}
+
+		address := mappers.AddressReqToAddress(req)
+		id, err := primitive.ObjectIDFromHex(c.Param("addressId"))
+		if err != nil {
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+		address.ID = id
+
+		address, apiErr := a.svc.Update(c.Request.Context(), userID, address, req.Default)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.AddressToRes(address))
+	}
+}
+
+// Delete address example godoc
+// @SummaryUser Delete address
+// @Description Delete a postal address of a user, the first remaining one becomes the default when the default is deleted
+// @Param id path string true "User id"
+// @Param addressId path string true "Address id"
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/addresses/{addressId} [delete]
+func (a AddressControllerImpl) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := a.owner(c)
+		if !ok {
+			return
+		}
+
+		if apiErr := a.svc.Delete(c.Request.Context(), userID, c.Param("addressId")); apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
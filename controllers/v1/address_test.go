@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newAddressRouter(svc *mocks.AddressService, user models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewAddress(svc, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.Use(func(ctx *gin.Context) { ctx.Set("user", user) })
+	router.GET("/v1/users/:id/addresses", c.List())
+	router.POST("/v1/users/:id/addresses", c.Create())
+	return router
+}
+
+func TestCreateAddressValidatesPostalCode(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID()}
+	svc := new(mocks.AddressService)
+	router := newAddressRouter(svc, user)
+
+	body := `{"label":"home","line1":"1 Main St","city":"Springfield","postal_code":"ABC","country":"us"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/"+user.ID.Hex()+"/addresses", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem response.Problem
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "postal_code", problem.Errors[0].Field)
+	assert.Equal(t, "POSTAL_CODE", problem.Errors[0].Code)
+	assert.Equal(t, "US", problem.Errors[0].Param)
+	svc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateAddressNormalizes(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID()}
+	svc := new(mocks.AddressService)
+	svc.On("Create", mock.Anything, user.ID.Hex(), mock.MatchedBy(func(a models.Address) bool {
+		return a.Country == "GB" && a.PostalCode == "SW1A 2AA"
+	}), true).Return(models.Address{ID: primitive.NewObjectID(), Default: true}, response.ApiError{})
+	router := newAddressRouter(svc, user)
+
+	body := `{"label":"shipping","line1":"10 Downing St","city":"London","postal_code":"sw1a  2aa","country":"gb","default":true}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/"+user.ID.Hex()+"/addresses", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	assert.Equal(t, http.StatusCreated, do(router, req))
+	svc.AssertExpectations(t)
+}
+
+func TestAddressesOfDifferentUser(t *testing.T) {
+	svc := new(mocks.AddressService)
+	other := primitive.NewObjectID().Hex()
+	svc.On("List", mock.Anything, other).Return([]models.Address{}, response.ApiError{})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/"+other+"/addresses", nil)
+	assert.Equal(t, http.StatusUnauthorized, do(newAddressRouter(svc, models.User{ID: primitive.NewObjectID()}), req))
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/users/"+other+"/addresses", nil)
+	assert.Equal(t, http.StatusOK, do(newAddressRouter(svc, models.User{ID: primitive.NewObjectID(), Role: models.RoleAdmin}), req))
+}
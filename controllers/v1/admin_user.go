@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"user-api/response"
+	"user-api/services"
+	"user-api/userio"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminUserController interface {
+	Import() gin.HandlerFunc
+	Export() gin.HandlerFunc
+}
+
+type AdminUserControllerImpl struct {
+	importSvc      services.UserImportService
+	exportSvc      services.UserExportService
+	attrs          services.AttributeService
+	importMaxBytes int64
+	log            *slog.Logger
+}
+
+// NewAdminUser returns the admin user controller, imported files being
+// limited to importMaxBytes.
+func NewAdminUser(importSvc services.UserImportService, exportSvc services.UserExportService, attrs services.AttributeService, importMaxBytes int64, logger *slog.Logger) AdminUserController {
+	return AdminUserControllerImpl{importSvc: importSvc, exportSvc: exportSvc, attrs: attrs, importMaxBytes: importMaxBytes, log: logger.With("component", "admin_user_controller")}
+}
+
+// Import users example godoc
+// @SummaryUser Import users
+// @Description Bulk create users from a CSV file, whose header names the columns, or from NDJSON. Each row is validated like a registration and may carry a bcrypt or argon2id password_hash instead of a password. The response reports the result of every row.
+// @Param format query string false "csv or ndjson, defaults to the Content-Type"
+// @Param dry_run query boolean false "validate without creating users"
+// @Param batch_size query integer false "rows saved per round trip, at most 1000"
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 200 {object} dto.ImportReport
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 413 {object} response.Problem
+// @Failure 415 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/users/import [post]
+func (a AdminUserControllerImpl) Import() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.Query("format")
+		if format == "" {
+			format = userio.FormatFromContentType(c.ContentType())
+		}
+
+		dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+		batchSize, _ := strconv.Atoi(c.Query("batch_size"))
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, a.importMaxBytes)
+		rows, err := userio.NewRowReader(format, c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.Is(err, userio.ErrUnsupportedFormat) {
+			response.Abort(c, response.UnsupportedMediaTypeError.WithDetail("expected text/csv or application/x-ndjson"))
+			return
+		}
+		if errors.As(err, &tooLarge) {
+			response.Abort(c, response.PayloadTooLargeError)
+			return
+		}
+		if err != nil {
+			a.log.InfoContext(c.Request.Context(), "error reading import", "error", err)
+			response.Abort(c, response.BadRequestError.WithDetail(err.Error()))
+			return
+		}
+
+		report, apiErr := a.importSvc.Import(c.Request.Context(), rows, services.ImportOptions{DryRun: dryRun, BatchSize: batchSize})
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// Export users example godoc
+// @SummaryUser Export users
+// @Description Stream every user matching the filters of the list endpoint as CSV, NDJSON or Parquet. Password hashes are never exported.
+// @Param format query string false "csv, ndjson or parquet, defaults to csv"
+// @Param columns query string false "comma separated columns among id,name,email,birth_date,age,address,addresses,locale,role"
+// @Param email query string false "exact email"
+// @Param locale query string false "preferred locale"
+// @Param role query string false "user or admin"
+// @Param attributes query object false "custom attributes, e.g. attributes[plan]=pro"
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.apache.parquet
+// @Success 200 {file} file
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/users/export [get]
+func (a AdminUserControllerImpl) Export() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", userio.FormatCSV)
+		columns, err := userio.ParseColumns(c.Query("columns"))
+		if err != nil {
+			response.Abort(c, response.BadRequestError.WithDetail(err.Error()))
+			return
+		}
+
+		filter, ok := bindUserFilter(c, a.attrs)
+		if !ok {
+			return
+		}
+
+		w, err := userio.NewUserWriter(format, c.Writer, columns)
+		if errors.Is(err, userio.ErrUnsupportedFormat) {
+			response.Abort(c, response.BadRequestError.WithDetail("format must be csv, ndjson or parquet"))
+			return
+		}
+		if err != nil {
+			a.log.ErrorContext(c.Request.Context(), "error starting export", "error", err)
+			response.Abort(c, response.InternalServerError)
+			return
+		}
+
+		// headers go out with the first bytes written, an error past this
+		// point can only be logged and the truncated body dropped
+		c.Header("Content-Type", userio.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, format))
+		c.Status(http.StatusOK)
+
+		if _, apiErr := a.exportSvc.Export(c.Request.Context(), filter, w); apiErr.Status != 0 {
+			a.log.WarnContext(c.Request.Context(), "export aborted", "format", format)
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-api/dto"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newAdminRouter(svc *mocks.UserImportService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewAdminUser(svc, new(mocks.UserExportService), new(mocks.AttributeService), 1<<20, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.POST("/v1/admin/users/import", c.Import())
+	return router
+}
+
+func TestImportFormatFromContentType(t *testing.T) {
+	svc := new(mocks.UserImportService)
+	svc.On("Import", mock.Anything, mock.Anything, services.ImportOptions{DryRun: true}).Return(dto.ImportReport{DryRun: true}, response.ApiError{})
+	router := newAdminRouter(svc)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/import?dry_run=true", strings.NewReader(`{"email":"a@test.com"}`))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	assert.Equal(t, http.StatusOK, do(router, req))
+	svc.AssertExpectations(t)
+}
+
+func TestImportTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := new(mocks.UserImportService)
+	c := NewAdminUser(svc, new(mocks.UserExportService), new(mocks.AttributeService), 16, logging.Discard())
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.POST("/v1/admin/users/import", c.Import())
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/import", strings.NewReader("email,name,birth_date,address,password\n"))
+	req.Header.Set("Content-Type", "text/csv")
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(router, req))
+	svc.AssertNotCalled(t, "Import", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportUnsupportedFormat(t *testing.T) {
+	svc := new(mocks.UserImportService)
+	router := newAdminRouter(svc)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/import", strings.NewReader(`[]`))
+	req.Header.Set("Content-Type", "application/json")
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, do(router, req))
+	svc.AssertNotCalled(t, "Import", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportRejectsPasswordColumn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := new(mocks.UserExportService)
+	c := NewAdminUser(new(mocks.UserImportService), svc, new(mocks.AttributeService), 1<<20, logging.Discard())
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.GET("/v1/admin/users/export", c.Export())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/users/export?columns=email,password", nil)
+
+	assert.Equal(t, http.StatusBadRequest, do(router, req))
+	svc.AssertNotCalled(t, "Export", mock.Anything, mock.Anything, mock.Anything)
+}
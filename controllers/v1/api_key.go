@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+	"user-api/dto"
+	"user-api/mappers"
+	"user-api/models"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApiKeyController interface {
+	Create() gin.HandlerFunc
+	List() gin.HandlerFunc
+	Revoke() gin.HandlerFunc
+}
+
+type ApiKeyControllerImpl struct {
+	svc services.ApiKeyService
+	log *slog.Logger
+}
+
+func NewApiKey(svc services.ApiKeyService, logger *slog.Logger) ApiKeyController {
+	return ApiKeyControllerImpl{svc: svc, log: logger.With("component", "api_key_controller")}
+}
+
+// Create api key example godoc
+// @SummaryUser Create api key
+// @Description Create a named, scoped and expiring api key for the authenticated user, the key is only returned by this call. A caller authenticated by an api key can only grant the scopes of that key
+// @Param ApiKey body dto.CreateApiKeyReq true "Api key"
+// @Accept json
+// @Produce json
+// @Success 201 {object} dto.CreateApiKeyRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys [post]
+func (a ApiKeyControllerImpl) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := dto.CreateApiKeyReq{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			a.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+
+		if v := req.ValidateFields(); len(v) != 0 {
+			response.Abort(c, response.NewValidationError(v))
+			return
+		}
+
+		user, ok := currentUser(c, a.log)
+		if !ok {
+			return
+		}
+		// a key cannot create a key broader than itself
+		if k, ok := c.Get(apiKeyKey); ok {
+			for _, s := range req.Scopes {
+				if !k.(models.ApiKey).HasScope(s, user) {
+					a.log.InfoContext(c.Request.Context(), "api key scope escalation rejected", "scope", s)
+					response.Abort(c, response.InsufficientScopeError.WithDetail("scope "+s+" is not granted to the calling api key"))
+					return
+				}
+			}
+		}
+
+		days := req.ExpiresInDays
+		if days == 0 {
+			days = dto.DefaultApiKeyExpiresInDays
+		}
+
+		key, k, apiErr := a.svc.Create(c.Request.Context(), user, req.Name, req.Scopes, time.Duration(days)*24*time.Hour)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusCreated, dto.CreateApiKeyRes{ApiKeyRes: mappers.ApiKeyToRes(k), Key: key})
+	}
+}
+
+// List api keys example godoc
+// @SummaryUser List api keys
+// @Description List the api keys of the authenticated user, revoked and expired ones included
+// @Produce json
+// @Success 200 {array} dto.ApiKeyRes
+// @Failure 401 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys [get]
+func (a ApiKeyControllerImpl) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c, a.log)
+		if !ok {
+			return
+		}
+
+		keys, apiErr := a.svc.List(c.Request.Context(), user.ID.Hex())
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.ApiKeysToRes(keys))
+	}
+}
+
+// Revoke api key example godoc
+// @SummaryUser Revoke api key
+// @Description Revoke an api key of the authenticated user
+// @Param id path string true "Api key id"
+// @Success 204
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys/{id} [delete]
+func (a ApiKeyControllerImpl) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c, a.log)
+		if !ok {
+			return
+		}
+
+		apiErr := a.svc.Revoke(c.Request.Context(), user.ID.Hex(), c.Param("id"))
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
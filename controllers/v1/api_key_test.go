@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newApiKeyRouter authenticates the requests as user, with key when it has
+// scopes.
+func newApiKeyRouter(svc *mocks.ApiKeyService, user models.User, key models.ApiKey) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewApiKey(svc, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user", user)
+		if len(key.Scopes) != 0 {
+			ctx.Set(apiKeyKey, key)
+		}
+	})
+	router.POST("/v1/api-keys", c.Create())
+	return router
+}
+
+func TestCreateApiKeyRejectsScopeEscalation(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID()}
+	svc := new(mocks.ApiKeyService)
+	router := newApiKeyRouter(svc, user, models.ApiKey{Scopes: []string{models.ScopeApiKeysManage}})
+
+	body := `{"name":"deploy","scopes":["api_keys:manage","users:write"]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/api-keys", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	svc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateApiKeyWithinKeyScopes(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID()}
+	svc := new(mocks.ApiKeyService)
+	svc.On("Create", mock.Anything, user, "deploy", []string{models.ScopeUsersRead}, mock.AnythingOfType("time.Duration")).
+		Return("uk_test", models.ApiKey{ID: primitive.NewObjectID(), Scopes: []string{models.ScopeUsersRead}, ExpiresAt: time.Now()}, response.ApiError{})
+	router := newApiKeyRouter(svc, user, models.ApiKey{Scopes: []string{models.ScopeApiKeysManage, models.ScopeUsersRead}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/api-keys", strings.NewReader(`{"name":"deploy","scopes":["users:read"]}`)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"user-api/attributes"
+	"user-api/mappers"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttributeController interface {
+	Schema() gin.HandlerFunc
+	SetSchema() gin.HandlerFunc
+	SetUserAttributes() gin.HandlerFunc
+}
+
+type AttributeControllerImpl struct {
+	svc     services.AttributeService
+	userSvc services.UserService
+	log     *slog.Logger
+}
+
+func NewAttribute(svc services.AttributeService, userSvc services.UserService, logger *slog.Logger) AttributeController {
+	return AttributeControllerImpl{svc: svc, userSvc: userSvc, log: logger.With("component", "attribute_controller")}
+}
+
+// Get attribute schema example godoc
+// @SummaryUser Get attribute schema
+// @Description Get the JSON Schema of the custom attributes of users
+// @Produce json
+// @Success 200 {object} dto.AttributeSchemaRes
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/attributes/schema [get]
+func (a AttributeControllerImpl) Schema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, apiErr := a.svc.Schema(c.Request.Context())
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.AttributeSchemaToRes(s))
+	}
+}
+
+// Set attribute schema example godoc
+// @SummaryUser Set attribute schema
+// @Description Replace the JSON Schema of the custom attributes of users. It must describe an object whose properties are the attributes, "readOnly": true keeping an attribute for admins to set. Stored attributes are checked again on their next change only.
+// @Param Schema body object true "JSON Schema"
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.AttributeSchemaRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/attributes/schema [put]
+func (a AttributeControllerImpl) SetSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, err := io.ReadAll(io.LimitReader(c.Request.Body, attributes.MaxSchemaBytes+1))
+		if err != nil {
+			a.log.InfoContext(c.Request.Context(), "error reading attribute schema", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+
+		s, apiErr := a.svc.SetSchema(c.Request.Context(), raw)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.AttributeSchemaToRes(s))
+	}
+}
+
+// Set user attributes example godoc
+// @SummaryUser Set user attributes
+// @Description Replace the custom attributes of a user, read only ones included
+// @Param id path string true "User id"
+// @Param Attributes body object true "Attributes"
+// @Accept json
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/attributes [put]
+func (a AttributeControllerImpl) SetUserAttributes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attrs := make(map[string]interface{})
+		if err := c.ShouldBindJSON(&attrs); err != nil {
+			a.log.InfoContext(c.Request.Context(), "error parsing attributes", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+
+		if apiErr := a.userSvc.SetAttributes(c.Request.Context(), c.Param("id"), attrs); apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
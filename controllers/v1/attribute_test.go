@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newAttributeRouter(svc *mocks.AttributeService, userSvc *mocks.UserService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewAttribute(svc, userSvc, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.PUT("/v1/admin/attributes/schema", c.SetSchema())
+	router.PUT("/v1/admin/users/:id/attributes", c.SetUserAttributes())
+	return router
+}
+
+func TestSetSchemaPassesRawSchema(t *testing.T) {
+	schema := `{"type": "object", "properties": {"plan": {"type": "string"}}}`
+	svc := new(mocks.AttributeService)
+	svc.On("SetSchema", mock.Anything, []byte(schema)).Return(models.AttributeSchema{}, response.InvalidSchemaError)
+	router := newAttributeRouter(svc, new(mocks.UserService))
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/admin/attributes/schema", strings.NewReader(schema))
+
+	assert.Equal(t, http.StatusBadRequest, do(router, req))
+	svc.AssertExpectations(t)
+}
+
+func TestSetUserAttributes(t *testing.T) {
+	userSvc := new(mocks.UserService)
+	userSvc.On("SetAttributes", mock.Anything, "42", map[string]interface{}{"plan": "pro"}).Return(response.ApiError{})
+	router := newAttributeRouter(new(mocks.AttributeService), userSvc)
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/admin/users/42/attributes", strings.NewReader(`{"plan": "pro"}`))
+
+	assert.Equal(t, http.StatusNoContent, do(router, req))
+	userSvc.AssertExpectations(t)
+}
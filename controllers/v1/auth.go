@@ -1,11 +1,15 @@
 package controllers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"user-api/auth"
 	"user-api/dto"
+	"user-api/i18n"
+	"user-api/logging"
 	"user-api/mappers"
+	"user-api/metrics"
+	"user-api/models"
 	"user-api/response"
 	"user-api/services"
 
@@ -15,44 +19,235 @@ import (
 type AuthController interface {
 	Register() gin.HandlerFunc
 	Login() gin.HandlerFunc
+	Logout() gin.HandlerFunc
 	VerifyToken() gin.HandlerFunc
+	RequireScope(scope string) gin.HandlerFunc
+}
+
+const (
+	authMethodKey = "auth_method"
+	apiKeyKey     = "api_key"
+	sessionKey    = "session"
+
+	authMethodJWT    = "jwt"
+	authMethodApiKey = "api_key"
+)
+
+// AuthOptions selects where VerifyToken looks for the jwt besides the
+// Authorization header.
+type AuthOptions struct {
+	// LegacyTokenHeader keeps accepting the jwt in the non standard token header.
+	LegacyTokenHeader bool
+	Cookie            CookieOptions
+}
+
+// CookieOptions configures the HttpOnly cookie carrying the jwt of browser
+// clients, protected from CSRF by a double submit cookie.
+type CookieOptions struct {
+	Enabled  bool
+	Name     string
+	CSRFName string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
 }
 
 type AuthControllerImpl struct {
-	userSvc services.UserService
+	userSvc    services.UserService
+	apiKeySvc  services.ApiKeyService
+	sessionSvc services.SessionService
+	opts       AuthOptions
+	log        *slog.Logger
 }
 
-func NewAuth(uSvc services.UserService) AuthController {
+func NewAuth(uSvc services.UserService, kSvc services.ApiKeyService, sSvc services.SessionService, opts AuthOptions, logger *slog.Logger) AuthController {
 	return AuthControllerImpl{
-		userSvc: uSvc,
+		userSvc:    uSvc,
+		apiKeySvc:  kSvc,
+		sessionSvc: sSvc,
+		opts:       opts,
+		log:        logger.With("component", "auth_controller"),
 	}
 }
 
+// VerifyToken authenticates the request either with the X-API-Key header or
+// with a jwt, setting the resolved user in context. The jwt is read from the
+// Authorization: Bearer header, then from the legacy token header and the
+// auth cookie when enabled. Tokens whose session was revoked are rejected.
 func (a AuthControllerImpl) VerifyToken() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		token := ctx.GetHeader("token")
+		if key := ctx.GetHeader(auth.ApiKeyHeader); key != "" {
+			a.verifyApiKey(ctx, key)
+			return
+		}
+
+		token, fromCookie := a.token(ctx)
 		if token == "" {
-			log.Println("[AUTH CONTROLLER] Empty token")
-			e := response.InvalidTokenError
-			ctx.AbortWithStatusJSON(e.Status, e)
+			a.log.InfoContext(ctx.Request.Context(), "empty token")
+			metrics.TokenRejected(metrics.ReasonMissingToken)
+			response.Abort(ctx, response.InvalidTokenError)
+			return
+		}
+
+		if fromCookie && !a.validCSRF(ctx) {
+			a.log.InfoContext(ctx.Request.Context(), "invalid csrf token")
+			metrics.TokenRejected(metrics.ReasonInvalidCSRF)
+			response.Abort(ctx, response.InvalidCSRFTokenError)
 			return
 		}
 
-		email, err := auth.ValidateToken(token)
+		claims, err := auth.ValidateToken(token)
 		if err != nil {
-			log.Printf("[AUTH CONTROLLER] Invalid token %s", token)
-			e := response.InvalidTokenError
-			ctx.AbortWithStatusJSON(e.Status, e)
+			a.log.InfoContext(ctx.Request.Context(), "invalid token", "error", err)
+			metrics.TokenRejected(metrics.ReasonInvalidToken)
+			response.Abort(ctx, response.InvalidTokenError)
 			return
 		}
 
-		user, apiErr := a.userSvc.FindByEmail(email)
+		// tokens issued before sessions existed carry no sid, they are
+		// accepted until they expire or the sessions of their user are all
+		// revoked.
+		if claims.SessionID != "" {
+			session, apiErr := a.sessionSvc.Verify(ctx.Request.Context(), claims.SessionID)
+			if apiErr.Status != 0 {
+				a.log.InfoContext(ctx.Request.Context(), "token session rejected", "code", apiErr.Code)
+				metrics.TokenRejected(metrics.ReasonSessionRevoked)
+				response.Abort(ctx, apiErr)
+				return
+			}
+			ctx.Set(sessionKey, session)
+		}
 
+		user, apiErr := a.tokenUser(ctx, claims)
 		if apiErr.Status != 0 {
-			ctx.AbortWithStatusJSON(apiErr.Status, apiErr)
+			metrics.TokenRejected(metrics.ReasonTokenUserNotFound)
+			response.Abort(ctx, apiErr)
+			return
+		}
+		if claims.SessionID == "" && user.TokenRevoked(claims.IssuedAt) {
+			a.log.InfoContext(ctx.Request.Context(), "revoked token without session")
+			metrics.TokenRejected(metrics.ReasonSessionRevoked)
+			response.Abort(ctx, response.InvalidTokenError)
 			return
 		}
-		ctx.Set("user", user)
+		a.setPrincipal(ctx, user, authMethodJWT)
+
+		ctx.Next()
+	}
+}
+
+// tokenUser resolves the user of the token by id, or by email for legacy
+// tokens issued before subject ids.
+func (a AuthControllerImpl) tokenUser(ctx *gin.Context, claims *auth.JWTClaim) (models.User, response.ApiError) {
+	if !claims.Legacy() {
+		return a.userSvc.FindById(ctx.Request.Context(), claims.Subject)
+	}
+	a.log.DebugContext(ctx.Request.Context(), "legacy token")
+	metrics.LegacyTokenAccepted()
+	return a.userSvc.FindByEmail(ctx.Request.Context(), claims.Email)
+}
+
+func (a AuthControllerImpl) token(ctx *gin.Context) (token string, fromCookie bool) {
+	if t, ok := auth.BearerToken(ctx.GetHeader(auth.AuthorizationHeader)); ok {
+		return t, false
+	}
+	if a.opts.LegacyTokenHeader {
+		if t := ctx.GetHeader(auth.LegacyTokenHeader); t != "" {
+			return t, false
+		}
+	}
+	if a.opts.Cookie.Enabled {
+		if t, err := ctx.Cookie(a.opts.Cookie.Name); err == nil && t != "" {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// validCSRF checks the double submit cookie on state changing requests, the
+// browser attaches cookies to cross site requests but a foreign page cannot
+// read the csrf cookie to copy it in the header.
+func (a AuthControllerImpl) validCSRF(ctx *gin.Context) bool {
+	switch ctx.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, _ := ctx.Cookie(a.opts.Cookie.CSRFName)
+	return auth.ValidCSRFToken(ctx.GetHeader(auth.CSRFHeader), cookie)
+}
+
+func (a AuthControllerImpl) setCookie(ctx *gin.Context, name, value string, httpOnly bool, maxAge int) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   a.opts.Cookie.Domain,
+		MaxAge:   maxAge,
+		Secure:   a.opts.Cookie.Secure,
+		HttpOnly: httpOnly,
+		SameSite: a.opts.Cookie.SameSite,
+	})
+}
+
+func (a AuthControllerImpl) verifyApiKey(ctx *gin.Context, key string) {
+	k, apiErr := a.apiKeySvc.Authenticate(ctx.Request.Context(), key)
+	if apiErr.Status != 0 {
+		metrics.TokenRejected(metrics.ReasonInvalidApiKey)
+		response.Abort(ctx, apiErr)
+		return
+	}
+
+	user, apiErr := a.userSvc.FindById(ctx.Request.Context(), k.UserID.Hex())
+	if apiErr.Status != 0 {
+		metrics.TokenRejected(metrics.ReasonTokenUserNotFound)
+		response.Abort(ctx, response.InvalidApiKeyError)
+		return
+	}
+
+	ctx.Set(apiKeyKey, k)
+	ctx.Request = ctx.Request.WithContext(logging.WithAttrs(ctx.Request.Context(), slog.String("api_key_id", k.ID.Hex())))
+	a.setPrincipal(ctx, user, authMethodApiKey)
+	a.log.InfoContext(ctx.Request.Context(), "api key authenticated", "scopes", k.Scopes)
+
+	ctx.Next()
+}
+
+// setPrincipal stores the authenticated user for the handlers and attributes
+// every following log line of the request to them.
+func (a AuthControllerImpl) setPrincipal(ctx *gin.Context, user models.User, method string) {
+	ctx.Set("user", user)
+	ctx.Set(authMethodKey, method)
+	ctx.Set(i18n.PreferredLocaleKey, user.Locale)
+	ctx.Request = ctx.Request.WithContext(logging.WithAttrs(ctx.Request.Context(),
+		slog.String("user_id", user.ID.Hex()),
+		slog.String(authMethodKey, method),
+	))
+}
+
+// RequireScope rejects api key requests whose key lacks scope. JWT requests
+// act with every scope of the user, ScopeAdmin being granted to admins only,
+// for api keys too so a demoted admin's keys lose it.
+func (a AuthControllerImpl) RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if k, ok := ctx.Get(apiKeyKey); ok {
+			owner, _ := ctx.Get("user")
+			u, _ := owner.(models.User)
+			if !k.(models.ApiKey).HasScope(scope, u) {
+				a.log.InfoContext(ctx.Request.Context(), "insufficient api key scope", "scope", scope)
+				response.Abort(ctx, response.InsufficientScopeError)
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		if scope == models.ScopeAdmin {
+			user, _ := ctx.Get("user")
+			if u, ok := user.(models.User); !ok || !u.IsAdmin() {
+				response.Abort(ctx, response.InsufficientScopeError)
+				return
+			}
+		}
 
 		ctx.Next()
 	}
@@ -60,11 +255,14 @@ func (a AuthControllerImpl) VerifyToken() gin.HandlerFunc {
 
 // Login example godoc
 // @SummaryUser login
-// @Description do login
+// @Description do login starting a new session, with "cookie": true the token is set in an HttpOnly cookie and a csrf_token to send back in X-CSRF-Token is returned instead
 // @Param Login body dto.LoginReq true "User credentials"
 // @Accept json
 // @Produce json
 // @Success 200 {object} dto.LoginRes
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
 // @Router /auth/login [post]
 func (a AuthControllerImpl) Login() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -73,22 +271,44 @@ func (a AuthControllerImpl) Login() gin.HandlerFunc {
 		err := ctx.ShouldBindJSON(&req)
 
 		if err != nil {
-			log.Printf("Error parsing user input error: %s", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.BadRequestError)
+			a.log.InfoContext(ctx.Request.Context(), "error parsing user input", "error", err)
+			metrics.LoginFailed(metrics.ReasonInvalidInput)
+			response.Abort(ctx, response.BadRequestError)
 			return
 		}
 
 		v := req.ValidateFields()
 
 		if len(v) != 0 {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, v)
+			metrics.LoginFailed(metrics.ReasonInvalidInput)
+			response.Abort(ctx, response.NewValidationError(v))
 			return
 		}
 
-		jwt, apiErr := a.userSvc.Login(req.Email, req.Password)
+		client := models.SessionClient{
+			Device:    req.Device,
+			UserAgent: ctx.Request.UserAgent(),
+			IP:        ctx.ClientIP(),
+		}
+
+		jwt, apiErr := a.userSvc.Login(ctx.Request.Context(), req.Email, req.Password, client)
 
 		if apiErr.Status != 0 {
-			ctx.AbortWithStatusJSON(apiErr.Status, apiErr)
+			response.Abort(ctx, apiErr)
+			return
+		}
+
+		if req.Cookie && a.opts.Cookie.Enabled {
+			csrf, err := auth.GenerateCSRFToken()
+			if err != nil {
+				a.log.ErrorContext(ctx.Request.Context(), "error generating csrf token", "error", err)
+				response.Abort(ctx, response.InternalServerError)
+				return
+			}
+			maxAge := int(auth.TokenTTL.Seconds())
+			a.setCookie(ctx, a.opts.Cookie.Name, jwt, true, maxAge)
+			a.setCookie(ctx, a.opts.Cookie.CSRFName, csrf, false, maxAge)
+			ctx.JSON(http.StatusOK, dto.LoginRes{CSRFToken: csrf})
 			return
 		}
 
@@ -96,6 +316,21 @@ func (a AuthControllerImpl) Login() gin.HandlerFunc {
 	}
 }
 
+// Logout example godoc
+// @SummaryUser logout
+// @Description Clear the auth cookies set by a cookie login
+// @Success 204
+// @Router /auth/logout [post]
+func (a AuthControllerImpl) Logout() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if a.opts.Cookie.Enabled {
+			a.setCookie(ctx, a.opts.Cookie.Name, "", true, -1)
+			a.setCookie(ctx, a.opts.Cookie.CSRFName, "", false, -1)
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
 // Register example godoc
 // @SummaryUser Register
 // @Description Register a new user
@@ -103,6 +338,9 @@ func (a AuthControllerImpl) Login() gin.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Success 201
+// @Failure 400 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Failure 500 {object} response.Problem
 // @Router /auth/register [post]
 func (a AuthControllerImpl) Register() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -110,28 +348,25 @@ func (a AuthControllerImpl) Register() gin.HandlerFunc {
 		err := ctx.ShouldBindJSON(&req)
 
 		if err != nil {
-			log.Printf("Error parsing user input error: %s", err.Error())
-			ctx.JSON(http.StatusBadRequest, response.BadRequestError)
-			ctx.Abort()
+			a.log.InfoContext(ctx.Request.Context(), "error parsing user input", "error", err)
+			response.Abort(ctx, response.BadRequestError)
 			return
 		}
 
 		v := req.ValidateFields()
 
 		if len(v) != 0 {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, v)
+			response.Abort(ctx, response.NewValidationError(v))
 			return
 		}
 
 		user := mappers.RegisterReqToUser(req)
 
-		log.Printf("Register request mapped to user %v", user)
-
-		_, apiErr := a.userSvc.Register(user)
+		_, apiErr := a.userSvc.Register(ctx.Request.Context(), user)
 
 		if apiErr.Status != 0 {
-			log.Printf("Error register user: %v", req)
-			ctx.AbortWithStatusJSON(apiErr.Status, apiErr)
+			a.log.InfoContext(ctx.Request.Context(), "error registering user", "code", apiErr.Code)
+			response.Abort(ctx, apiErr)
 			return
 		}
 
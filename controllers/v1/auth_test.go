@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-api/auth"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testEmail  = "test@test.com"
+	testUserID = "6530d1b2e4b0a1c2d3e4f5a6"
+)
+
+func newAuthRouter(opts AuthOptions) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	userSvc := new(mocks.UserService)
+	userSvc.On("FindById", mock.Anything, testUserID).Return(models.User{Email: testEmail}, response.ApiError{})
+	a := NewAuth(userSvc, new(mocks.ApiKeyService), new(mocks.SessionService), opts, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.Use(a.VerifyToken())
+	handler := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/v1/users", handler)
+	router.PUT("/v1/users/1", handler)
+	return router
+}
+
+func do(router *gin.Engine, req *http.Request) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func validJWT(t *testing.T) string {
+	jwt, err := auth.GenerateJWT(testUserID, "")
+	assert.Nil(t, err)
+	return jwt
+}
+
+func TestVerifyTokenBearerHeader(t *testing.T) {
+	router := newAuthRouter(AuthOptions{})
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set("Authorization", "bearer "+validJWT(t))
+
+	assert.Equal(t, http.StatusNoContent, do(router, req))
+}
+
+func TestVerifyTokenLegacyHeaderSwitch(t *testing.T) {
+	jwt := validJWT(t)
+	for _, enabled := range []bool{true, false} {
+		router := newAuthRouter(AuthOptions{LegacyTokenHeader: enabled})
+		req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		req.Header.Set("token", jwt)
+
+		expected := http.StatusUnauthorized
+		if enabled {
+			expected = http.StatusNoContent
+		}
+		assert.Equal(t, expected, do(router, req), "legacy header enabled: %v", enabled)
+	}
+}
+
+func TestVerifyTokenCookieRequiresCSRFOnUnsafeMethods(t *testing.T) {
+	opts := AuthOptions{Cookie: CookieOptions{Enabled: true, Name: "access_token", CSRFName: "csrf_token"}}
+	router := newAuthRouter(opts)
+	jwt := validJWT(t)
+	withCookies := func(method, path string) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: "access_token", Value: jwt})
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "csrf"})
+		return req
+	}
+
+	assert.Equal(t, http.StatusNoContent, do(router, withCookies(http.MethodGet, "/v1/users")))
+
+	assert.Equal(t, http.StatusForbidden, do(router, withCookies(http.MethodPut, "/v1/users/1")))
+
+	forged := withCookies(http.MethodPut, "/v1/users/1")
+	forged.Header.Set(auth.CSRFHeader, "guess")
+	assert.Equal(t, http.StatusForbidden, do(router, forged))
+
+	valid := withCookies(http.MethodPut, "/v1/users/1")
+	valid.Header.Set(auth.CSRFHeader, "csrf")
+	assert.Equal(t, http.StatusNoContent, do(router, valid))
+}
+
+func TestVerifyTokenCookieIgnoredWhenDisabled(t *testing.T) {
+	router := newAuthRouter(AuthOptions{})
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: validJWT(t)})
+
+	assert.Equal(t, http.StatusUnauthorized, do(router, req))
+}
+
+func TestVerifyTokenRejectsRevokedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userSvc := new(mocks.UserService)
+	userSvc.On("FindById", mock.Anything, testUserID).Return(models.User{Email: testEmail}, response.ApiError{})
+	sessionSvc := new(mocks.SessionService)
+	sessionSvc.On("Verify", mock.Anything, "active").Return(models.Session{FamilyID: "active"}, response.ApiError{})
+	sessionSvc.On("Verify", mock.Anything, "revoked").Return(models.Session{}, response.InvalidTokenError)
+	a := NewAuth(userSvc, new(mocks.ApiKeyService), sessionSvc, AuthOptions{}, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.Use(a.VerifyToken())
+	router.GET("/v1/users", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for sid, expected := range map[string]int{"active": http.StatusNoContent, "revoked": http.StatusUnauthorized} {
+		jwt, err := auth.GenerateJWT(testUserID, sid)
+		assert.Nil(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+jwt)
+
+		assert.Equal(t, expected, do(router, req), "session %s", sid)
+	}
+}
+
+func TestVerifyTokenRejectsRevokedTokenWithoutSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwt := validJWT(t)
+	for name, revokedAt := range map[string]time.Time{"before": time.Now().Add(-time.Hour), "after": time.Now().Add(time.Minute)} {
+		userSvc := new(mocks.UserService)
+		userSvc.On("FindById", mock.Anything, testUserID).Return(models.User{Email: testEmail, TokensRevokedAt: &revokedAt}, response.ApiError{})
+		a := NewAuth(userSvc, new(mocks.ApiKeyService), new(mocks.SessionService), AuthOptions{}, logging.Discard())
+
+		router := gin.New()
+		router.Use(response.ProblemMiddleware())
+		router.Use(a.VerifyToken())
+		router.GET("/v1/users", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+jwt)
+
+		expected := http.StatusNoContent
+		if name == "after" {
+			expected = http.StatusUnauthorized
+		}
+		assert.Equal(t, expected, do(router, req), "revoked %s the token was issued", name)
+	}
+}
+
+func TestAdminScopeOfDemotedOwnerIsRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key := models.ApiKey{UserID: primitive.NewObjectID(), Scopes: []string{models.ScopeAdmin}}
+	for role, expected := range map[string]int{models.RoleAdmin: http.StatusNoContent, models.RoleUser: http.StatusForbidden} {
+		userSvc := new(mocks.UserService)
+		userSvc.On("FindById", mock.Anything, key.UserID.Hex()).Return(models.User{ID: key.UserID, Role: role}, response.ApiError{})
+		apiKeySvc := new(mocks.ApiKeyService)
+		apiKeySvc.On("Authenticate", mock.Anything, "uk_test").Return(key, response.ApiError{})
+		a := NewAuth(userSvc, apiKeySvc, new(mocks.SessionService), AuthOptions{}, logging.Discard())
+
+		router := gin.New()
+		router.Use(response.ProblemMiddleware())
+		router.Use(a.VerifyToken())
+		router.GET("/v1/admin/users", a.RequireScope(models.ScopeUsersRead), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users", nil)
+		req.Header.Set(auth.ApiKeyHeader, "uk_test")
+
+		assert.Equal(t, expected, do(router, req), "owner %s", role)
+	}
+}
+
+func newRegisterRouter(userSvc *mocks.UserService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	a := NewAuth(userSvc, new(mocks.ApiKeyService), new(mocks.SessionService), AuthOptions{}, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.POST("/v1/auth/register", a.Register())
+	return router
+}
+
+func TestRegisterParsesLegacyAddress(t *testing.T) {
+	userSvc := new(mocks.UserService)
+	userSvc.On("Register", mock.Anything, mock.MatchedBy(func(u models.User) bool {
+		return len(u.Addresses) == 1 && u.Addresses[0].Legacy && u.Addresses[0].Country == "US" && u.Address != ""
+	})).Return(models.User{}, response.ApiError{})
+	body := `{"name": "test", "email": "test@test.com", "birth_date": "2000-05-17", "password": "secret123",
+		"address": "1 Main St, Springfield, IL 62701, US"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", strings.NewReader(body))
+
+	assert.Equal(t, http.StatusCreated, do(newRegisterRouter(userSvc), req))
+	userSvc.AssertExpectations(t)
+}
+
+func TestRegisterValidatesAddresses(t *testing.T) {
+	userSvc := new(mocks.UserService)
+	body := `{"name": "test", "email": "test@test.com", "birth_date": "2000-05-17", "password": "secret123",
+		"addresses": [{"label": "home", "line1": "1 Main St", "city": "Springfield", "country": "ZZ"}]}`
+
+	w := httptest.NewRecorder()
+	newRegisterRouter(userSvc).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/auth/register", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "addresses.0.country")
+	userSvc.AssertNotCalled(t, "Register", mock.Anything, mock.Anything)
+}
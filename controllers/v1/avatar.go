@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"user-api/images"
+	"user-api/mappers"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// avatarField is the multipart form field of the uploaded image.
+const avatarField = "avatar"
+
+type AvatarController interface {
+	Put() gin.HandlerFunc
+	Delete() gin.HandlerFunc
+}
+
+type AvatarControllerImpl struct {
+	svc      services.AvatarService
+	maxBytes int64
+	log      *slog.Logger
+}
+
+// NewAvatar returns the avatar controller, uploaded images being limited
+// to maxBytes.
+func NewAvatar(svc services.AvatarService, maxBytes int64, logger *slog.Logger) AvatarController {
+	return AvatarControllerImpl{svc: svc, maxBytes: maxBytes, log: logger.With("component", "avatar_controller")}
+}
+
+// owner returns the id of the user whose avatar is changed, aborting unless
+// it is the authenticated user or an admin.
+func (a AvatarControllerImpl) owner(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	user, ok := currentUser(c, a.log)
+	if !ok {
+		return "", false
+	}
+	if user.ID.Hex() != id && !user.IsAdmin() {
+		a.log.InfoContext(c.Request.Context(), "cannot change avatar of different user", "user_id", id)
+		response.Abort(c, response.DifferentUserError)
+		return "", false
+	}
+	return id, true
+}
+
+// image reads the uploaded image, checking its declared type and size
+// before the content is decoded.
+func (a AvatarControllerImpl) image(c *gin.Context) ([]byte, bool) {
+	// room for the multipart boundaries and headers
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, a.maxBytes+64<<10)
+	file, header, err := c.Request.FormFile(avatarField)
+	if c.Request.MultipartForm != nil {
+		defer c.Request.MultipartForm.RemoveAll()
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		response.Abort(c, response.PayloadTooLargeError)
+		return nil, false
+	case errors.Is(err, http.ErrNotMultipart):
+		response.Abort(c, response.UnsupportedMediaTypeError)
+		return nil, false
+	case errors.Is(err, http.ErrMissingFile):
+		response.Abort(c, response.NewValidationError(url.Values{avatarField: []string{"required"}}))
+		return nil, false
+	case err != nil:
+		a.log.InfoContext(c.Request.Context(), "error parsing avatar upload", "error", err)
+		response.Abort(c, response.BadRequestError)
+		return nil, false
+	}
+	defer file.Close()
+
+	if ct := header.Header.Get("Content-Type"); !images.Supported(ct) {
+		a.log.InfoContext(c.Request.Context(), "unsupported avatar type", "content_type", ct)
+		response.Abort(c, response.UnsupportedMediaTypeError)
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(file, a.maxBytes+1))
+	if err != nil {
+		a.log.InfoContext(c.Request.Context(), "error reading avatar upload", "error", err)
+		response.Abort(c, response.BadRequestError)
+		return nil, false
+	}
+	if int64(len(data)) > a.maxBytes {
+		response.Abort(c, response.PayloadTooLargeError)
+		return nil, false
+	}
+	return data, true
+}
+
+// Put avatar example godoc
+// @SummaryUser Upload avatar
+// @Description Replace the profile picture of a user by a JPEG, PNG, GIF or WebP image sent as the avatar field of a multipart form. The center square of the image is stored at 512, 256, 128 and 64 pixels, without its EXIF metadata.
+// @Param id path string true "User id"
+// @Param avatar formData file true "Image"
+// @Accept multipart/form-data
+// @Produce json
+// @Success 200 {object} dto.AvatarRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 413 {object} response.Problem
+// @Failure 415 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/avatar [put]
+func (a AvatarControllerImpl) Put() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := a.owner(c)
+		if !ok {
+			return
+		}
+		data, ok := a.image(c)
+		if !ok {
+			return
+		}
+
+		avatar, apiErr := a.svc.Set(c.Request.Context(), userID, data)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.AvatarToRes(avatar))
+	}
+}
+
+// Delete avatar example godoc
+// @SummaryUser Delete avatar
+// @Description Delete the profile picture of a user
+// @Param id path string true "User id"
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/avatar [delete]
+func (a AvatarControllerImpl) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := a.owner(c)
+		if !ok {
+			return
+		}
+
+		if apiErr := a.svc.Delete(c.Request.Context(), userID); apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
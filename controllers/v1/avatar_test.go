@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"user-api/dto"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newAvatarRouter(svc *mocks.AvatarService, user models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewAvatar(svc, 1024, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.Use(func(ctx *gin.Context) { ctx.Set("user", user) })
+	router.PUT("/v1/users/:id/avatar", c.Put())
+	return router
+}
+
+// avatarUpload builds a multipart request uploading data as the avatar
+// field with contentType.
+func avatarUpload(id string, contentType string, data []byte) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="avatar"; filename="me.png"`)
+	h.Set("Content-Type", contentType)
+	part, _ := w.CreatePart(h)
+	part.Write(data)
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/users/"+id+"/avatar", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestPutAvatar(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID()}
+	svc := new(mocks.AvatarService)
+	svc.On("Set", mock.Anything, user.ID.Hex(), []byte("png")).Return(models.Avatar{URL: "/blobs/a/512.png", Thumbnails: map[string]string{"64": "/blobs/a/64.png"}}, response.ApiError{})
+
+	w := httptest.NewRecorder()
+	newAvatarRouter(svc, user).ServeHTTP(w, avatarUpload(user.ID.Hex(), "image/png", []byte("png")))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res dto.AvatarRes
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "/blobs/a/512.png", res.URL)
+	assert.Equal(t, "/blobs/a/64.png", res.Thumbnails["64"])
+}
+
+func TestPutAvatarChecksUpload(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID()}
+	svc := new(mocks.AvatarService)
+	router := newAvatarRouter(svc, user)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, do(router, avatarUpload(user.ID.Hex(), "image/svg+xml", []byte("<svg/>"))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(router, avatarUpload(user.ID.Hex(), "image/png", bytes.Repeat([]byte("x"), 1025))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(router, avatarUpload(user.ID.Hex(), "image/png", bytes.Repeat([]byte("x"), 100<<10))))
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/users/"+user.ID.Hex()+"/avatar", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, http.StatusUnsupportedMediaType, do(router, req))
+
+	assert.Equal(t, http.StatusUnauthorized, do(router, avatarUpload(primitive.NewObjectID().Hex(), "image/png", []byte("png"))))
+	svc.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"user-api/dto"
+	"user-api/i18n"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+type EmailChangeController interface {
+	Request() gin.HandlerFunc
+	Confirm() gin.HandlerFunc
+	Revert() gin.HandlerFunc
+	ConfirmPage() gin.HandlerFunc
+	RevertPage() gin.HandlerFunc
+}
+
+type EmailChangeControllerImpl struct {
+	svc services.EmailChangeService
+	log *slog.Logger
+}
+
+func NewEmailChange(svc services.EmailChangeService, logger *slog.Logger) EmailChangeController {
+	return EmailChangeControllerImpl{svc: svc, log: logger.With("component", "email_change_controller")}
+}
+
+// Request email change example godoc
+// @SummaryUser Change email
+// @Description Mail a confirmation link to the new email and a revert link to the current one, the email changes once the link is followed
+// @Param Email body dto.EmailChangeReq true "New email and current password"
+// @Accept json
+// @Success 202
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me/email [post]
+func (e EmailChangeControllerImpl) Request() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := dto.EmailChangeReq{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			e.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+
+		if v := req.ValidateFields(); len(v) != 0 {
+			response.Abort(c, response.NewValidationError(v))
+			return
+		}
+
+		user, ok := currentUser(c, e.log)
+		if !ok {
+			return
+		}
+		if apiErr := e.svc.Request(c.Request.Context(), user, req.Password, req.Email); apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.Status(http.StatusAccepted)
+	}
+}
+
+// Confirm email change example godoc
+// @SummaryUser Confirm email change
+// @Description Apply the email change of a confirmation link, every session of the user is revoked
+// @Param Link body dto.EmailLinkReq true "Link token"
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.EmailChangeRes
+// @Failure 400 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Router /auth/email/confirm [post]
+func (e EmailChangeControllerImpl) Confirm() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := e.linkToken(c)
+		if !ok {
+			return
+		}
+
+		change, apiErr := e.svc.Confirm(c.Request.Context(), token)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.EmailChangeRes{Email: change.NewEmail})
+	}
+}
+
+// Revert email change example godoc
+// @SummaryUser Revert email change
+// @Description Cancel the email change of a revert link, or restore the previous email once confirmed, every session of the user is revoked
+// @Param Link body dto.EmailLinkReq true "Link token"
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.EmailChangeRes
+// @Failure 400 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Router /auth/email/revert [post]
+func (e EmailChangeControllerImpl) Revert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := e.linkToken(c)
+		if !ok {
+			return
+		}
+
+		change, apiErr := e.svc.Revert(c.Request.Context(), token)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.EmailChangeRes{Email: change.OldEmail})
+	}
+}
+
+// linkToken reads the token posted from a link page, aborting when missing.
+func (e EmailChangeControllerImpl) linkToken(c *gin.Context) (string, bool) {
+	req := dto.EmailLinkReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		e.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+		response.Abort(c, response.BadRequestError)
+		return "", false
+	}
+
+	if v := req.ValidateFields(); len(v) != 0 {
+		response.Abort(c, response.NewValidationError(v))
+		return "", false
+	}
+	return req.Token, true
+}
+
+// linkPage is the page an emailed link opens, posting its token back only
+// once the user submits it, so link scanners and prefetchers change nothing.
+var linkPage = template.Must(template.New("link").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Button}}</button>
+</form>
+</body>
+</html>
+`))
+
+// Email change confirmation page example godoc
+// @SummaryUser Email change confirmation page
+// @Description Page of the emailed confirmation link, whose form posts the token to confirm the change
+// @Param token query string true "Link token"
+// @Produce html
+// @Success 200
+// @Router /auth/email/confirm [get]
+func (e EmailChangeControllerImpl) ConfirmPage() gin.HandlerFunc {
+	return e.page("email_change_confirm")
+}
+
+// Email change revert page example godoc
+// @SummaryUser Email change revert page
+// @Description Page of the emailed revert link, whose form posts the token to revert the change
+// @Param token query string true "Link token"
+// @Produce html
+// @Success 200
+// @Router /auth/email/revert [get]
+func (e EmailChangeControllerImpl) RevertPage() gin.HandlerFunc {
+	return e.page("email_change_revert")
+}
+
+// page renders linkPage posting to the path it is served on.
+func (e EmailChangeControllerImpl) page(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Locale(c)
+		title, button := i18n.Page(locale, name)
+
+		// the token must not leak through caches or the referer
+		c.Header("Cache-Control", "no-store")
+		c.Header("Referrer-Policy", "no-referrer")
+		c.Header("Content-Security-Policy", "default-src 'none'; form-action 'self'")
+		c.Header("Content-Language", locale)
+		c.Render(http.StatusOK, render.HTML{Template: linkPage, Data: map[string]string{
+			"Locale": locale,
+			"Title":  title,
+			"Button": button,
+			"Action": c.Request.URL.Path,
+			"Token":  c.Query("token"),
+		}})
+	}
+}
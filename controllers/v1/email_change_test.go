@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-api/dto"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newEmailChangeRouter(svc *mocks.EmailChangeService, user models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewEmailChange(svc, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.POST("/v1/users/me/email", func(ctx *gin.Context) { ctx.Set("user", user) }, c.Request())
+	router.GET("/v1/auth/email/confirm", c.ConfirmPage())
+	router.POST("/v1/auth/email/confirm", c.Confirm())
+	router.POST("/v1/auth/email/revert", c.Revert())
+	return router
+}
+
+// linkRequest posts the JSON body to the link endpoint path.
+func linkRequest(path string, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID(), Email: "ana@example.com"}
+	svc := new(mocks.EmailChangeService)
+	svc.On("Request", mock.Anything, user, "secret", "new@example.com").Return(response.ApiError{})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/me/email", strings.NewReader(`{"email":"new@example.com","password":"secret"}`))
+	newEmailChangeRouter(svc, user).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestRequestEmailChangeValidation(t *testing.T) {
+	svc := new(mocks.EmailChangeService)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/me/email", strings.NewReader(`{"email":"not-an-email"}`))
+	newEmailChangeRouter(svc, models.User{}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	svc.AssertNotCalled(t, "Request", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	svc := new(mocks.EmailChangeService)
+	svc.On("Confirm", mock.Anything, "abc").Return(models.EmailChange{OldEmail: "ana@example.com", NewEmail: "new@example.com"}, response.ApiError{})
+
+	w := httptest.NewRecorder()
+	newEmailChangeRouter(svc, models.User{}).ServeHTTP(w, linkRequest("/v1/auth/email/confirm", `{"token":"abc"}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	res := dto.EmailChangeRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "new@example.com", res.Email)
+}
+
+func TestRevertEmailChangeInvalidLink(t *testing.T) {
+	svc := new(mocks.EmailChangeService)
+	svc.On("Revert", mock.Anything, "used").Return(models.EmailChange{}, response.InvalidLinkError)
+
+	w := httptest.NewRecorder()
+	newEmailChangeRouter(svc, models.User{}).ServeHTTP(w, linkRequest("/v1/auth/email/revert", `{"token":"used"}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_LINK")
+}
+
+func TestConfirmEmailChangeWithoutToken(t *testing.T) {
+	svc := new(mocks.EmailChangeService)
+
+	w := httptest.NewRecorder()
+	newEmailChangeRouter(svc, models.User{}).ServeHTTP(w, linkRequest("/v1/auth/email/confirm", `{}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	svc.AssertNotCalled(t, "Confirm", mock.Anything, mock.Anything)
+}
+
+func TestConfirmEmailChangeFromLinkPageForm(t *testing.T) {
+	svc := new(mocks.EmailChangeService)
+	svc.On("Confirm", mock.Anything, "abc").Return(models.EmailChange{NewEmail: "new@example.com"}, response.ApiError{})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/email/confirm", strings.NewReader("token=abc"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	newEmailChangeRouter(svc, models.User{}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestConfirmLinkPageChangesNothing(t *testing.T) {
+	svc := new(mocks.EmailChangeService)
+
+	w := httptest.NewRecorder()
+	newEmailChangeRouter(svc, models.User{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/auth/email/confirm?token=a%22b", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `<form method="post" action="/v1/auth/email/confirm">`)
+	assert.Contains(t, w.Body.String(), `value="a&#34;b"`)
+	svc.AssertNotCalled(t, "Confirm", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"net/http"
+	"user-api/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController interface {
+	Liveness() gin.HandlerFunc
+	Readiness() gin.HandlerFunc
+}
+
+type HealthControllerImpl struct {
+	registry *health.Registry
+}
+
+func NewHealth(registry *health.Registry) HealthController {
+	return HealthControllerImpl{registry: registry}
+}
+
+// Liveness example godoc
+// @SummaryUser Liveness probe
+// @Description Reports whether the process is alive
+// @Produce json
+// @Success 200
+// @Router /healthz [get]
+func (h HealthControllerImpl) Liveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+	}
+}
+
+// Readiness example godoc
+// @SummaryUser Readiness probe
+// @Description Reports whether every dependency is reachable and the api is not draining
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h HealthControllerImpl) Readiness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := h.registry.Ready(c.Request.Context())
+
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, report)
+	}
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"user-api/mappers"
+	"user-api/models"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController interface {
+	List() gin.HandlerFunc
+	Revoke() gin.HandlerFunc
+}
+
+type SessionControllerImpl struct {
+	svc services.SessionService
+	log *slog.Logger
+}
+
+func NewSession(svc services.SessionService, logger *slog.Logger) SessionController {
+	return SessionControllerImpl{svc: svc, log: logger.With("component", "session_controller")}
+}
+
+// List sessions example godoc
+// @SummaryUser List sessions
+// @Description List the active sessions of the authenticated user, the one of the calling token is flagged current
+// @Produce json
+// @Success 200 {array} dto.SessionRes
+// @Failure 401 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me/sessions [get]
+func (s SessionControllerImpl) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c, s.log)
+		if !ok {
+			return
+		}
+
+		sessions, apiErr := s.svc.List(c.Request.Context(), user.ID.Hex())
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.SessionsToRes(sessions, currentFamilyID(c)))
+	}
+}
+
+// Revoke session example godoc
+// @SummaryUser Revoke session
+// @Description End a session of the authenticated user, the tokens issued for it are rejected from then on
+// @Param sid path string true "Session id"
+// @Success 204
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me/sessions/{sid} [delete]
+func (s SessionControllerImpl) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c, s.log)
+		if !ok {
+			return
+		}
+
+		apiErr := s.svc.Revoke(c.Request.Context(), user.ID.Hex(), c.Param("sid"))
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// currentFamilyID is the session of the jwt authenticating the request, empty
+// for api keys and tokens issued before sessions existed.
+func currentFamilyID(c *gin.Context) string {
+	if s, ok := c.Get(sessionKey); ok {
+		return s.(models.Session).FamilyID
+	}
+	return ""
+}
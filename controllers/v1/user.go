@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"user-api/dto"
@@ -18,14 +18,21 @@ type UserController interface {
 	Delete() gin.HandlerFunc
 	Update() gin.HandlerFunc
 	GetById() gin.HandlerFunc
+	Me() gin.HandlerFunc
+	UpdateMe() gin.HandlerFunc
+	PatchMe() gin.HandlerFunc
+	DeleteMe() gin.HandlerFunc
+	ChangePassword() gin.HandlerFunc
 }
 
 type UserControllerImpl struct {
-	svc services.UserService
+	svc   services.UserService
+	attrs services.AttributeService
+	log   *slog.Logger
 }
 
-func NewUserJson(svc services.UserService) UserController {
-	return UserControllerImpl{svc: svc}
+func NewUserJson(svc services.UserService, attrs services.AttributeService, logger *slog.Logger) UserController {
+	return UserControllerImpl{svc: svc, attrs: attrs, log: logger.With("component", "user_controller")}
 }
 
 // Get example godoc
@@ -33,10 +40,17 @@ func NewUserJson(svc services.UserService) UserController {
 // @Description Get all users paginated
 // @Param limit query integer false "limit"
 // @Param page query integer false "page"
-// @Param token header string true "Authentication token"
+// @Param email query string false "exact email"
+// @Param locale query string false "preferred locale"
+// @Param role query string false "user or admin"
+// @Param attributes query object false "custom attributes, e.g. attributes[plan]=pro"
 // @Accept json
 // @Produce json
 // @Success 200 {array} dto.UserResponse
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /users [get]
 func (u UserControllerImpl) GetAll() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -52,10 +66,15 @@ func (u UserControllerImpl) GetAll() gin.HandlerFunc {
 			page = 1
 		}
 
-		u, apiErr := u.svc.GetAll(limit, page)
+		filter, ok := bindUserFilter(c, u.attrs)
+		if !ok {
+			return
+		}
+
+		u, apiErr := u.svc.GetAll(c.Request.Context(), filter, limit, page)
 
 		if apiErr.Status != 0 {
-			c.AbortWithStatusJSON(apiErr.Status, apiErr)
+			response.Abort(c, apiErr)
 			return
 		}
 		resp := mappers.UserToPagRes(u)
@@ -69,34 +88,28 @@ func (u UserControllerImpl) GetAll() gin.HandlerFunc {
 // @Param id path string true "id"
 // @Produce json
 // @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /users/:id [delete]
 func (u UserControllerImpl) Delete() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		user, exists := c.Get("user")
-
-		if !exists {
-			log.Printf("[USER CONTROLLER] User not found in context with email")
-			e := response.InternalServerError
-			c.AbortWithStatusJSON(e.Status, e)
+		user, ok := currentUser(c, u.log)
+		if !ok {
 			return
 		}
 
-		if user.(models.User).ID.Hex() != id {
-			log.Println("[USER CONTROLLER] Cannot delete different user")
-			c.AbortWithStatus(http.StatusUnauthorized)
+		if user.ID.Hex() != id {
+			u.log.InfoContext(c.Request.Context(), "cannot delete different user", "user_id", id)
+			response.Abort(c, response.DifferentUserError)
 			return
 		}
 
-		err := u.svc.DeleteById(id)
-
-		if err.Status != 0 {
-			c.AbortWithStatusJSON(err.Status, err)
-			return
-		}
-
-		c.AbortWithStatus(http.StatusNoContent)
+		u.delete(c, id)
 	}
 }
 
@@ -106,6 +119,11 @@ func (u UserControllerImpl) Delete() gin.HandlerFunc {
 // @Param Update body dto.UserUpdateReq true "Update request"
 // @Produce json
 // @Success 200
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /users/:id [put]
 func (u UserControllerImpl) Update() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -115,58 +133,255 @@ func (u UserControllerImpl) Update() gin.HandlerFunc {
 		err := c.ShouldBindJSON(&req)
 
 		if err != nil {
-			log.Printf("Error parsing user input error: %s", err.Error())
-			c.AbortWithStatusJSON(http.StatusBadRequest, response.BadRequestError)
+			u.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+
+		user, ok := currentUser(c, u.log)
+		if !ok {
+			return
+		}
+
+		if user.ID.Hex() != id {
+			u.log.InfoContext(c.Request.Context(), "cannot update different user", "user_id", id)
+			response.Abort(c, response.DifferentUserError)
+			return
+		}
+
+		u.update(c, id, req)
+	}
+}
+
+// Me example godoc
+// @SummaryUser Get authenticated user
+// @Description Get the user of the token or api key making the request
+// @Produce json
+// @Success 200 {object} dto.UserResponse
+// @Failure 401 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me [get]
+func (u UserControllerImpl) Me() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c, u.log)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.UserToRes(user))
+	}
+}
+
+// UpdateMe example godoc
+// @SummaryUser Update authenticated user
+// @Description Replace the editable fields of the authenticated user
+// @Param Update body dto.UserUpdateReq true "Update request"
+// @Accept json
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me [put]
+func (u UserControllerImpl) UpdateMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := dto.UserUpdateReq{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			u.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+
+		user, ok := currentUser(c, u.log)
+		if !ok {
 			return
 		}
 
-		user, exists := c.Get("user")
+		u.update(c, user.ID.Hex(), req)
+	}
+}
+
+// PatchMe example godoc
+// @SummaryUser Patch authenticated user
+// @Description Update only the given fields of the authenticated user
+// @Param Patch body dto.UserPatchReq true "Patch request"
+// @Accept json
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me [patch]
+func (u UserControllerImpl) PatchMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		patch := dto.UserPatchReq{}
+		if err := c.ShouldBindJSON(&patch); err != nil {
+			u.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
 
-		if !exists {
-			log.Printf("[USER CONTROLLER] User not found in context with email")
-			e := response.InternalServerError
-			c.AbortWithStatusJSON(e.Status, e)
+		user, ok := currentUser(c, u.log)
+		if !ok {
 			return
 		}
 
-		if user.(models.User).ID.Hex() != id {
-			log.Println("[USER CONTROLLER] Cannot update different user")
-			c.AbortWithStatus(http.StatusUnauthorized)
+		u.update(c, user.ID.Hex(), patch.Apply(mappers.UserToUpdateReq(user)))
+	}
+}
+
+// DeleteMe example godoc
+// @SummaryUser Delete authenticated user
+// @Description Delete the authenticated user
+// @Success 204
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me [delete]
+func (u UserControllerImpl) DeleteMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c, u.log)
+		if !ok {
 			return
 		}
 
-		v := req.ValidateFields()
+		u.delete(c, user.ID.Hex())
+	}
+}
+
+// ChangePassword example godoc
+// @SummaryUser Change password
+// @Description Change the password of the authenticated user, every other session is revoked
+// @Param Password body dto.ChangePasswordReq true "Current and new password"
+// @Accept json
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/me/password [post]
+func (u UserControllerImpl) ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := dto.ChangePasswordReq{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			u.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+			response.Abort(c, response.BadRequestError)
+			return
+		}
 
-		if len(v) != 0 {
-			c.AbortWithStatusJSON(http.StatusBadRequest, v)
+		if v := req.ValidateFields(); len(v) != 0 {
+			response.Abort(c, response.NewValidationError(v))
 			return
 		}
 
-		apiErr := u.svc.UpdateById(id, mappers.UserUpdateReqToUser(req))
+		user, ok := currentUser(c, u.log)
+		if !ok {
+			return
+		}
 
+		apiErr := u.svc.ChangePassword(c.Request.Context(), user, req.CurrentPassword, req.NewPassword, currentFamilyID(c))
 		if apiErr.Status != 0 {
-			c.AbortWithStatusJSON(apiErr.Status, apiErr)
+			response.Abort(c, apiErr)
+			return
 		}
 
 		c.Status(http.StatusNoContent)
 	}
 }
 
+// bindUserFilter reads the filters shared by the list and export endpoints,
+// aborting when invalid.
+func bindUserFilter(c *gin.Context, attrs services.AttributeService) (models.UserFilter, bool) {
+	req := dto.UserFilterReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Abort(c, response.BadRequestError)
+		return models.UserFilter{}, false
+	}
+	req.Attributes = c.QueryMap("attributes")
+
+	if v := req.ValidateFields(); len(v) != 0 {
+		response.Abort(c, response.NewValidationError(v))
+		return models.UserFilter{}, false
+	}
+
+	filter := mappers.UserFilterReqToFilter(req)
+	values, apiErr := attrs.ParseFilter(c.Request.Context(), req.Attributes)
+	if apiErr.Status != 0 {
+		response.Abort(c, apiErr)
+		return models.UserFilter{}, false
+	}
+	filter.Attributes = values
+
+	return filter, true
+}
+
+// currentUser returns the user set by VerifyToken, aborting when missing.
+func currentUser(c *gin.Context, log *slog.Logger) (models.User, bool) {
+	user, exists := c.Get("user")
+
+	if !exists {
+		log.ErrorContext(c.Request.Context(), "user not found in context")
+		response.Abort(c, response.InternalServerError)
+		return models.User{}, false
+	}
+
+	return user.(models.User), true
+}
+
+func (u UserControllerImpl) update(c *gin.Context, id string, req dto.UserUpdateReq) {
+	v := req.ValidateFields()
+
+	if len(v) != 0 {
+		response.Abort(c, response.NewValidationError(v))
+		return
+	}
+
+	apiErr := u.svc.UpdateById(c.Request.Context(), id, mappers.UserUpdateReqToUser(req))
+
+	if apiErr.Status != 0 {
+		response.Abort(c, apiErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (u UserControllerImpl) delete(c *gin.Context, id string) {
+	err := u.svc.DeleteById(c.Request.Context(), id)
+
+	if err.Status != 0 {
+		response.Abort(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
 // Get user example godoc
 // @SummaryUser Get User by id
 // @Description Get user by id
 // @Param Id path string true "User id"
 // @Produce json
 // @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /users/:id [get]
 func (u UserControllerImpl) GetById() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 
-		u, err := u.svc.FindById(id)
+		u, err := u.svc.FindById(ctx.Request.Context(), id)
 
 		if err.Status != 0 {
-			ctx.AbortWithStatusJSON(err.Status, err)
+			response.Abort(ctx, err)
 			return
 		}
 
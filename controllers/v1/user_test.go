@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newMeRouter(svc *mocks.UserService, user models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewUserJson(svc, new(mocks.AttributeService), logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.Use(func(ctx *gin.Context) { ctx.Set("user", user) })
+	router.GET("/v1/users/me", c.Me())
+	router.PATCH("/v1/users/me", c.PatchMe())
+	return router
+}
+
+func TestPatchMeKeepsAbsentFields(t *testing.T) {
+	birthDate := time.Date(2000, time.May, 17, 0, 0, 0, 0, time.UTC)
+	user := models.User{ID: primitive.NewObjectID(), Name: "test", BirthDate: &birthDate, Address: "add", Locale: "en"}
+	patched := time.Date(1999, time.January, 2, 0, 0, 0, 0, time.UTC)
+	svc := new(mocks.UserService)
+	svc.On("UpdateById", mock.Anything, user.ID.Hex(), models.User{Name: "test", BirthDate: &patched, Address: "add", Locale: "en"}).Return(response.ApiError{})
+	router := newMeRouter(svc, user)
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/users/me", strings.NewReader(`{"birth_date": "1999-01-02"}`))
+
+	assert.Equal(t, http.StatusNoContent, do(router, req))
+	svc.AssertExpectations(t)
+}
+
+func TestPatchMeKeepsEstimatedBirthDate(t *testing.T) {
+	birthDate := time.Date(2000, time.May, 17, 0, 0, 0, 0, time.UTC)
+	user := models.User{ID: primitive.NewObjectID(), Name: "test", BirthDate: &birthDate, BirthDateEstimated: true, Address: "add"}
+	svc := new(mocks.UserService)
+	svc.On("UpdateById", mock.Anything, user.ID.Hex(), models.User{Name: "tester", BirthDate: &birthDate, BirthDateEstimated: true, Address: "add"}).Return(response.ApiError{})
+	router := newMeRouter(svc, user)
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/users/me", strings.NewReader(`{"name": "tester"}`))
+
+	assert.Equal(t, http.StatusNoContent, do(router, req))
+	svc.AssertExpectations(t)
+}
+
+func TestPatchMeValidatesMergedUser(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID(), Name: "test", Age: 20, Address: "add"}
+	svc := new(mocks.UserService)
+	router := newMeRouter(svc, user)
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/users/me", strings.NewReader(`{"name": "ab"}`))
+
+	assert.Equal(t, http.StatusBadRequest, do(router, req))
+	svc.AssertNotCalled(t, "UpdateById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMe(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID(), Name: "test", Email: testEmail}
+	router := newMeRouter(new(mocks.UserService), user)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/me", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), user.ID.Hex())
+}
+
+func TestGetAllFiltersOnAttributes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc, attrs := new(mocks.UserService), new(mocks.AttributeService)
+	attrs.On("ParseFilter", mock.Anything, map[string]string{"plan": "pro", "seats": "3"}).
+		Return(map[string]interface{}{"plan": "pro", "seats": int64(3)}, response.ApiError{})
+	filter := models.UserFilter{Role: models.RoleUser, Attributes: map[string]interface{}{"plan": "pro", "seats": int64(3)}}
+	svc.On("GetAll", mock.Anything, filter, uint64(10), uint64(1)).Return([]models.User{}, response.ApiError{})
+	router := gin.New()
+	router.GET("/v1/users", NewUserJson(svc, attrs, logging.Discard()).GetAll())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users?role=user&attributes[plan]=pro&attributes[seats]=3", nil)
+
+	assert.Equal(t, http.StatusOK, do(router, req))
+	svc.AssertExpectations(t)
+}
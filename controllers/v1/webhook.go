@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"user-api/dto"
+	"user-api/mappers"
+	"user-api/response"
+	"user-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController interface {
+	Create() gin.HandlerFunc
+	List() gin.HandlerFunc
+	Get() gin.HandlerFunc
+	Update() gin.HandlerFunc
+	Delete() gin.HandlerFunc
+	Deliveries() gin.HandlerFunc
+	Redeliver() gin.HandlerFunc
+}
+
+type WebhookControllerImpl struct {
+	svc services.WebhookService
+	log *slog.Logger
+}
+
+func NewWebhook(svc services.WebhookService, logger *slog.Logger) WebhookController {
+	return WebhookControllerImpl{svc: svc, log: logger.With("component", "webhook_controller")}
+}
+
+// Create webhook example godoc
+// @SummaryUser Create webhook
+// @Description Register an endpoint receiving the user events it subscribes to, the signing secret is only returned by this call
+// @Param Webhook body dto.WebhookReq true "Webhook"
+// @Accept json
+// @Produce json
+// @Success 201 {object} dto.WebhookRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/webhooks [post]
+func (w WebhookControllerImpl) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, ok := w.bindWebhook(c)
+		if !ok {
+			return
+		}
+
+		hook, apiErr := w.svc.Create(c.Request.Context(), mappers.WebhookReqToWebhook(req))
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		res := mappers.WebhookToRes(hook)
+		res.Secret = hook.Secret
+		c.JSON(http.StatusCreated, res)
+	}
+}
+
+// List webhooks example godoc
+// @SummaryUser List webhooks
+// @Description List the registered webhooks, inactive ones included
+// @Produce json
+// @Success 200 {array} dto.WebhookRes
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/webhooks [get]
+func (w WebhookControllerImpl) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hooks, apiErr := w.svc.List(c.Request.Context())
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.WebhooksToRes(hooks))
+	}
+}
+
+// Get webhook example godoc
+// @SummaryUser Get webhook
+// @Description Get a webhook by id
+// @Param id path string true "Webhook id"
+// @Produce json
+// @Success 200 {object} dto.WebhookRes
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id} [get]
+func (w WebhookControllerImpl) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hook, apiErr := w.svc.Get(c.Request.Context(), c.Param("id"))
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.WebhookToRes(hook))
+	}
+}
+
+// Update webhook example godoc
+// @SummaryUser Update webhook
+// @Description Replace the url, events and active flag of a webhook, its secret is kept
+// @Param id path string true "Webhook id"
+// @Param Webhook body dto.WebhookReq true "Webhook"
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.WebhookRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id} [put]
+func (w WebhookControllerImpl) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, ok := w.bindWebhook(c)
+		if !ok {
+			return
+		}
+
+		hook, apiErr := w.svc.Update(c.Request.Context(), c.Param("id"), mappers.WebhookReqToWebhook(req))
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.WebhookToRes(hook))
+	}
+}
+
+// Delete webhook example godoc
+// @SummaryUser Delete webhook
+// @Description Delete a webhook with its pending deliveries and delivery log
+// @Param id path string true "Webhook id"
+// @Success 204
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id} [delete]
+func (w WebhookControllerImpl) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiErr := w.svc.Delete(c.Request.Context(), c.Param("id")); apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// List webhook deliveries example godoc
+// @SummaryUser List webhook deliveries
+// @Description List the deliveries of a webhook newest first with their attempts, status=dead listing the dead letters
+// @Param id path string true "Webhook id"
+// @Param status query string false "pending, in_flight, delivered or dead"
+// @Param limit query integer false "limit"
+// @Param page query integer false "page"
+// @Produce json
+// @Success 200 {array} dto.DeliveryRes
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (w WebhookControllerImpl) Deliveries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := dto.DeliveryFilterReq{}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			response.Abort(c, response.BadRequestError)
+			return
+		}
+		if v := req.ValidateFields(); len(v) != 0 {
+			response.Abort(c, response.NewValidationError(v))
+			return
+		}
+
+		limit, err := strconv.ParseUint(c.Query("limit"), 0, 64)
+		if err != nil || limit == 0 || limit > 100 {
+			limit = 20
+		}
+		page, err := strconv.ParseUint(c.Query("page"), 0, 64)
+		if err != nil || page == 0 {
+			page = 1
+		}
+
+		deliveries, apiErr := w.svc.Deliveries(c.Request.Context(), c.Param("id"), req.Status, limit, page)
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusOK, mappers.DeliveriesToRes(deliveries))
+	}
+}
+
+// Redeliver webhook delivery example godoc
+// @SummaryUser Redeliver webhook delivery
+// @Description Queue the event of a delivery again, dead ones included, as a new delivery
+// @Param id path string true "Webhook id"
+// @Param delivery_id path string true "Delivery id"
+// @Produce json
+// @Success 202 {object} dto.DeliveryRes
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (w WebhookControllerImpl) Redeliver() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d, apiErr := w.svc.Redeliver(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+		if apiErr.Status != 0 {
+			response.Abort(c, apiErr)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, mappers.DeliveryToRes(d))
+	}
+}
+
+// bindWebhook reads the webhook of the create and update endpoints,
+// aborting when invalid.
+func (w WebhookControllerImpl) bindWebhook(c *gin.Context) (dto.WebhookReq, bool) {
+	req := dto.WebhookReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		w.log.InfoContext(c.Request.Context(), "error parsing user input", "error", err)
+		response.Abort(c, response.BadRequestError)
+		return req, false
+	}
+
+	if v := req.ValidateFields(); len(v) != 0 {
+		response.Abort(c, response.NewValidationError(v))
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-api/dto"
+	"user-api/logging"
+	mocks "user-api/mocks/services"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newWebhookRouter(svc *mocks.WebhookService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	c := NewWebhook(svc, logging.Discard())
+
+	router := gin.New()
+	router.Use(response.ProblemMiddleware())
+	router.POST("/v1/admin/webhooks", c.Create())
+	router.GET("/v1/admin/webhooks/:id", c.Get())
+	router.GET("/v1/admin/webhooks/:id/deliveries", c.Deliveries())
+	router.POST("/v1/admin/webhooks/:id/deliveries/:delivery_id/redeliver", c.Redeliver())
+	return router
+}
+
+func TestCreateWebhook(t *testing.T) {
+	svc := new(mocks.WebhookService)
+	svc.On("Create", mock.Anything, models.Webhook{URL: "https://example.com/hooks", Events: []string{models.EventUserCreated}, Active: true}).
+		Return(models.Webhook{ID: primitive.NewObjectID(), URL: "https://example.com/hooks", Events: []string{models.EventUserCreated}, Active: true, Secret: "whsec_test"}, response.ApiError{})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks", strings.NewReader(`{"url":"https://example.com/hooks","events":["user.created"]}`))
+	newWebhookRouter(svc).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	res := dto.WebhookRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "whsec_test", res.Secret)
+	assert.True(t, res.Active)
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	cases := map[string]string{
+		"unknown event": `{"url":"https://example.com/hooks","events":["user.renamed"]}`,
+		"no events":     `{"url":"https://example.com/hooks","events":[]}`,
+		"not http":      `{"url":"ftp://example.com/hooks","events":["user.created"]}`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			svc := new(mocks.WebhookService)
+
+			w := httptest.NewRecorder()
+			newWebhookRouter(svc).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks", strings.NewReader(body)))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			svc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGetWebhookHidesSecret(t *testing.T) {
+	id := primitive.NewObjectID()
+	svc := new(mocks.WebhookService)
+	svc.On("Get", mock.Anything, id.Hex()).Return(models.Webhook{ID: id, Secret: "whsec_test"}, response.ApiError{})
+
+	w := httptest.NewRecorder()
+	newWebhookRouter(svc).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks/"+id.Hex(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "whsec_test")
+}
+
+func TestListDeadDeliveries(t *testing.T) {
+	svc := new(mocks.WebhookService)
+	svc.On("Deliveries", mock.Anything, "hook", models.DeliveryDead, uint64(20), uint64(2)).
+		Return([]models.WebhookDelivery{{ID: primitive.NewObjectID(), Status: models.DeliveryDead}}, response.ApiError{})
+
+	w := httptest.NewRecorder()
+	newWebhookRouter(svc).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks/hook/deliveries?status=dead&page=2", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	res := []dto.DeliveryRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res, 1)
+	assert.Equal(t, models.DeliveryDead, res[0].Status)
+}
+
+func TestListDeliveriesInvalidStatus(t *testing.T) {
+	svc := new(mocks.WebhookService)
+
+	w := httptest.NewRecorder()
+	newWebhookRouter(svc).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks/hook/deliveries?status=lost", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRedeliver(t *testing.T) {
+	original := primitive.NewObjectID()
+	svc := new(mocks.WebhookService)
+	svc.On("Redeliver", mock.Anything, "hook", original.Hex()).
+		Return(models.WebhookDelivery{ID: primitive.NewObjectID(), Status: models.DeliveryPending, RedeliveryOf: &original}, response.ApiError{})
+
+	w := httptest.NewRecorder()
+	newWebhookRouter(svc).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks/hook/deliveries/"+original.Hex()+"/redeliver", nil))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	res := dto.DeliveryRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, original.Hex(), res.RedeliveryOf)
+}
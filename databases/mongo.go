@@ -7,8 +7,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func MongoInit(ctx *context.Context) (client *mongo.Client) {
-	client, err := mongo.Connect(*ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+func MongoInit(ctx *context.Context, uri string) (client *mongo.Client) {
+	client, err := mongo.Connect(*ctx, options.Client().ApplyURI(uri))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"fmt"
+	"net/url"
+	"user-api/models"
+	"user-api/postal"
+
+	"github.com/thedevsaddam/govalidator"
+)
+
+type AddressReq struct {
+	Label      string `json:"label" validate:"required"`
+	Line1      string `json:"line1" validate:"required"`
+	Line2      string `json:"line2"`
+	City       string `json:"city" validate:"required"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country" validate:"required"`
+	// Default makes this address the default one, the first address of a
+	// user always is.
+	Default bool `json:"default"`
+}
+
+// ValidateFields checks the postal code against the rules of the country
+// once the country is known to be valid.
+func (req AddressReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"label":       []string{"required", "in:home,billing,shipping"},
+		"line1":       []string{"required", "max:100"},
+		"line2":       []string{"max:100"},
+		"city":        []string{"required", "max:64"},
+		"region":      []string{"max:64"},
+		"postal_code": []string{"max:16"},
+		"country":     []string{"required"},
+	}
+
+	v := validate(&req, rules)
+	if len(v["country"]) != 0 {
+		return v
+	}
+
+	country := postal.NormalizeCountry(req.Country)
+	if !postal.ValidCountry(country) {
+		v.Add("country", "country")
+	} else if len(v["postal_code"]) == 0 && !postal.ValidPostalCode(country, req.PostalCode) {
+		v.Add("postal_code", "postal_code:"+country)
+	}
+	return v
+}
+
+// validateAddresses adds to v the failures of the addresses of a new user,
+// keyed by their index, e.g. "addresses.0.country". Older clients give the
+// legacy address instead.
+func validateAddresses(v url.Values, legacy string, addresses []AddressReq) url.Values {
+	if v == nil {
+		v = url.Values{}
+	}
+	switch {
+	case legacy == "" && len(addresses) == 0:
+		v.Add("addresses", "required")
+	case len(addresses) > models.MaxAddresses:
+		v.Add("addresses", fmt.Sprintf("max:%d", models.MaxAddresses))
+	}
+	for i, a := range addresses {
+		for field, failed := range a.ValidateFields() {
+			v[fmt.Sprintf("addresses.%d.%s", i, field)] = failed
+		}
+	}
+	return v
+}
+
+type AddressRes struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"`
+	Default    bool   `json:"default"`
+	// Legacy flags an address parsed from the deprecated free-form address,
+	// which may lack fields.
+	Legacy bool `json:"legacy,omitempty"`
+}
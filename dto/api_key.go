@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/thedevsaddam/govalidator"
+)
+
+const DefaultApiKeyExpiresInDays = 90
+
+type CreateApiKeyReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+func (req CreateApiKeyReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"name":            []string{"required", "min:3", "max:64"},
+		"scopes":          []string{"required"},
+		"expires_in_days": []string{"numeric_between:1,365"},
+	}
+
+	return validate(&req, rules)
+}
+
+type ApiKeyRes struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreateApiKeyRes is the only response carrying the plain text key.
+type CreateApiKeyRes struct {
+	ApiKeyRes
+	Key string `json:"key"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AttributeSchemaRes is the JSON Schema of the custom attributes of users,
+// version 0 being the empty schema in use until one is defined.
+type AttributeSchemaRes struct {
+	Schema    json.RawMessage `json:"schema" swaggertype:"object"`
+	Version   int             `json:"version"`
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"`
+}
@@ -7,51 +7,59 @@ import (
 )
 
 type RegisterUserReq struct {
-	Name     string `json:"name" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
-	Age      uint8  `json:"age" validate:"required"`
-	Password string `json:"password" validate:"required,min=6"`
-	Address  string `json:"address" validate:"required"`
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+	// BirthDate is a DateLayout date, e.g. "1990-05-17".
+	BirthDate string `json:"birth_date"`
+	// Deprecated: Age is accepted from older clients when no birth date is
+	// given, the birth date being estimated from it.
+	Age      uint8  `json:"age"`
+	Password string `json:"password" validate:"required"`
+	// Deprecated: Address is the free-form address of older clients, parsed
+	// into the default address when no Addresses are given.
+	Address string `json:"address"`
+	// Addresses are the structured addresses, the first one being the
+	// default unless another one is.
+	Addresses []AddressReq `json:"addresses"`
+	Locale    string       `json:"locale"`
+	// Attributes are checked against the attribute schema.
+	Attributes map[string]interface{} `json:"attributes"`
 }
 
 func (req RegisterUserReq) ValidateFields() url.Values {
 	rules := govalidator.MapData{
 		"name":     []string{"required", "min:3"},
 		"email":    []string{"required", "min:4", "email"},
-		"age":      []string{"required"},
-		"password": []string{"required", "min:6"},
-		"address":  []string{"required"},
+		"password": []string{"required"},
+		"locale":   []string{localeRule},
 	}
 
-	opts := govalidator.Options{
-		Data:  &req,
-		Rules: rules,
-	}
-	v := govalidator.New(opts)
-
-	return v.ValidateStruct()
+	v := validateBirthDate(validate(&req, rules), req.BirthDate, req.Age)
+	return validateAddresses(v, req.Address, req.Addresses)
 }
 
 type LoginReq struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// Cookie asks for the token in HttpOnly cookies instead of the body, for
+	// browser clients. Ignored unless cookies are enabled.
+	Cookie bool `json:"cookie"`
+	// Device names the session, e.g. "Work laptop", defaults to a label
+	// derived from the User-Agent.
+	Device string `json:"device"`
 }
 
 func (req LoginReq) ValidateFields() url.Values {
 	rules := govalidator.MapData{
 		"email":    []string{"required", "min:4", "email"},
 		"password": []string{"required", "min:6"},
+		"device":   []string{"max:64"},
 	}
 
-	opts := govalidator.Options{
-		Data:  &req,
-		Rules: rules,
-	}
-	v := govalidator.New(opts)
-
-	return v.ValidateStruct()
+	return validate(&req, rules)
 }
 
 type LoginRes struct {
-	Jwt string `json:"jwt"`
+	Jwt       string `json:"jwt,omitempty"`
+	CSRFToken string `json:"csrf_token,omitempty"`
 }
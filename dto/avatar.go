@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+// AvatarRes locates the square images of a profile picture, url being the
+// largest and thumbnails the others by side in pixels.
+type AvatarRes struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"net/url"
+
+	"github.com/thedevsaddam/govalidator"
+)
+
+type EmailChangeReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (req EmailChangeReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"email":    []string{"required", "min:4", "email"},
+		"password": []string{"required"},
+	}
+
+	return validate(&req, rules)
+}
+
+// EmailLinkReq carries the token of an emailed link, posted as JSON or by
+// the form of the link page.
+type EmailLinkReq struct {
+	Token string `json:"token" form:"token"`
+}
+
+func (req EmailLinkReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"token": []string{"required"},
+	}
+
+	return validate(&req, rules)
+}
+
+// EmailChangeRes tells the email of the account once a link is used.
+type EmailChangeRes struct {
+	Email string `json:"email"`
+}
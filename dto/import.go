@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"net/url"
+	"user-api/auth"
+	"user-api/response"
+)
+
+// Statuses of an imported row.
+const (
+	ImportCreated   = "created"
+	ImportValid     = "valid"
+	ImportInvalid   = "invalid"
+	ImportDuplicate = "duplicate"
+	ImportFailed    = "failed"
+)
+
+// ImportUserReq is a row of a bulk import. PasswordHash carries a bcrypt or
+// argon2id hash exported from another system, replacing Password.
+type ImportUserReq struct {
+	RegisterUserReq
+	PasswordHash string `json:"password_hash"`
+}
+
+// ValidateFields applies the RegisterUserReq rules, a pre-hashed row only
+// needs a well formed hash instead of a password.
+func (req ImportUserReq) ValidateFields() url.Values {
+	v := req.RegisterUserReq.ValidateFields()
+	if req.PasswordHash == "" {
+		return v
+	}
+
+	v.Del("password")
+	if !auth.IsPasswordHash(req.PasswordHash) {
+		v.Add("password_hash", "password_hash")
+	}
+	return v
+}
+
+type ImportRowRes struct {
+	// Row is the 1-based position of the record in the file, header excluded.
+	Row    int                   `json:"row"`
+	Email  string                `json:"email,omitempty"`
+	Status string                `json:"status"`
+	Code   string                `json:"code,omitempty"`
+	Errors []response.FieldError `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Total   int            `json:"total"`
+	Created int            `json:"created"`
+	Valid   int            `json:"valid"`
+	Failed  int            `json:"failed"`
+	Rows    []ImportRowRes `json:"rows"`
+}
+
+// Add records the result of a row updating the counters.
+func (r *ImportReport) Add(row ImportRowRes) {
+	r.Total++
+	switch row.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportValid:
+		r.Valid++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+type SessionRes struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current flags the session of the token making the request.
+	Current bool `json:"current"`
+}
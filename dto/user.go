@@ -10,27 +10,127 @@ type UserResponse struct {
 	ID    string `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
-	Age   uint8  `json:"age"`
+	// Age is computed from the birth date.
+	Age                uint8                  `json:"age"`
+	BirthDate          string                 `json:"birth_date,omitempty"`
+	BirthDateEstimated bool                   `json:"birth_date_estimated,omitempty"`
+	Locale             string                 `json:"locale,omitempty"`
+	Attributes         map[string]interface{} `json:"attributes,omitempty"`
+	Avatar             *AvatarRes             `json:"avatar,omitempty"`
+	Addresses          []AddressRes           `json:"addresses"`
 }
 
 type UserUpdateReq struct {
-	Name    string `json:"name" validate:"required"`
-	Address string `json:"address" validate:"required"`
-	Age     uint8  `json:"age" validate:"required"`
+	Name string `json:"name" validate:"required"`
+	// Deprecated: Address is the free-form address of older clients, the
+	// address parsed from it following its changes. The addresses endpoints
+	// manage the structured ones.
+	Address   string `json:"address"`
+	BirthDate string `json:"birth_date"`
+	// Deprecated: Age is accepted from older clients when no birth date is
+	// given, the birth date being estimated from it.
+	Age    uint8  `json:"age"`
+	Locale string `json:"locale"`
+	// Attributes replace the custom attributes when present, read only ones
+	// keeping their values.
+	Attributes map[string]interface{} `json:"attributes"`
+	// BirthDateEstimated keeps an estimated birth date estimated when a
+	// patch leaves it untouched.
+	BirthDateEstimated bool `json:"-"`
 }
 
 func (req UserUpdateReq) ValidateFields() url.Values {
 	rules := govalidator.MapData{
-		"name":    []string{"required", "min:3"},
-		"age":     []string{"required"},
-		"address": []string{"required"},
+		"name":   []string{"required", "min:3"},
+		"locale": []string{localeRule},
 	}
 
-	opts := govalidator.Options{
-		Data:  &req,
-		Rules: rules,
+	return validateBirthDate(validate(&req, rules), req.BirthDate, req.Age)
+}
+
+// UserPatchReq is a partial UserUpdateReq, only the present fields change.
+type UserPatchReq struct {
+	Name *string `json:"name"`
+	// Deprecated: Address is the free-form address of older clients.
+	Address   *string `json:"address"`
+	BirthDate *string `json:"birth_date"`
+	Age       *uint8  `json:"age"`
+	Locale    *string `json:"locale"`
+	// Attributes are merged into the current ones, a null value removing
+	// the attribute.
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Apply overlays the present fields on req, the result is validated as a
+// full update.
+func (p UserPatchReq) Apply(req UserUpdateReq) UserUpdateReq {
+	if p.Name != nil {
+		req.Name = *p.Name
+	}
+	if p.Address != nil {
+		req.Address = *p.Address
+	}
+	if p.BirthDate != nil {
+		req.BirthDate = *p.BirthDate
+		req.BirthDateEstimated = false
+	}
+	if p.Age != nil && p.BirthDate == nil {
+		req.BirthDate, req.BirthDateEstimated = "", false
+		req.Age = *p.Age
+	}
+	if p.Locale != nil {
+		req.Locale = *p.Locale
+	}
+	if p.Attributes == nil {
+		// untouched attributes are not checked again
+		req.Attributes = nil
+	} else {
+		merged := make(map[string]interface{}, len(req.Attributes)+len(p.Attributes))
+		for name, value := range req.Attributes {
+			merged[name] = value
+		}
+		for name, value := range p.Attributes {
+			if value == nil {
+				delete(merged, name)
+				continue
+			}
+			merged[name] = value
+		}
+		req.Attributes = merged
+	}
+	return req
+}
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (req ChangePasswordReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"current_password": []string{"required"},
+		"new_password":     []string{"required"},
+	}
+
+	return validate(&req, rules)
+}
+
+// UserFilterReq holds the filters of the list and export endpoints.
+type UserFilterReq struct {
+	Email  string `form:"email" json:"email"`
+	Locale string `form:"locale" json:"locale"`
+	Role   string `form:"role" json:"role"`
+	// Attributes are the attributes[name]=value filters, typed by the
+	// attribute service.
+	Attributes map[string]string `form:"-" json:"-"`
+}
+
+func (req UserFilterReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"email":  []string{"email"},
+		"locale": []string{localeRule},
+		"role":   []string{"in:user,admin"},
 	}
-	v := govalidator.New(opts)
 
-	return v.ValidateStruct()
+	return validate(&req, rules)
 }
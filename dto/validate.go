@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"net/url"
+	"strings"
+	"time"
+	"user-api/i18n"
+
+	"github.com/thedevsaddam/govalidator"
+)
+
+// localeRule accepts an empty locale or one of the supported ones.
+var localeRule = "in:" + strings.Join(i18n.Supported, ",")
+
+// validate runs govalidator reporting, for every invalid field, the failed
+// rules themselves (e.g. "min:6") instead of english messages, so the caller
+// can build machine readable errors out of them.
+func validate(data interface{}, rules govalidator.MapData) url.Values {
+	messages := govalidator.MapData{}
+	for field, fieldRules := range rules {
+		for _, rule := range fieldRules {
+			name, _, _ := strings.Cut(rule, ":")
+			messages[field] = append(messages[field], name+":"+rule)
+		}
+	}
+
+	opts := govalidator.Options{
+		Data:     data,
+		Rules:    rules,
+		Messages: messages,
+	}
+
+	return govalidator.New(opts).ValidateStruct()
+}
+
+// DateLayout is the layout of the dates of the API, e.g. "1990-05-17".
+const DateLayout = "2006-01-02"
+
+// validateBirthDate adds to v the failures of the birth date, which is
+// required unless an older client gives an age instead.
+func validateBirthDate(v url.Values, birthDate string, age uint8) url.Values {
+	if v == nil {
+		v = url.Values{}
+	}
+	switch {
+	case birthDate != "":
+		if _, err := time.Parse(DateLayout, birthDate); err != nil {
+			v.Add("birth_date", "date:YYYY-MM-DD")
+		}
+	case age == 0:
+		v.Add("birth_date", "required")
+	}
+	return v
+}
@@ -0,0 +1,97 @@
+package dto
+
+import (
+	"net/url"
+	"strings"
+	"time"
+	"user-api/models"
+
+	"github.com/thedevsaddam/govalidator"
+)
+
+type WebhookReq struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Active defaults to true.
+	Active *bool `json:"active"`
+}
+
+func (req WebhookReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"url":    []string{"required", "url"},
+		"events": []string{"required"},
+	}
+
+	v := validate(&req, rules)
+	if u, err := url.Parse(req.URL); req.URL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") && len(v["url"]) == 0 {
+		v.Add("url", "url")
+	}
+	for _, e := range req.Events {
+		if !validEvent(e) {
+			v.Add("events", "in:"+strings.Join(models.EventTypes, ","))
+			break
+		}
+	}
+	return v
+}
+
+func validEvent(e string) bool {
+	for _, t := range models.EventTypes {
+		if t == e {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookRes struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+	// Secret is only returned when the webhook is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DeliveryFilterReq struct {
+	Status string `form:"status" json:"status"`
+}
+
+func (req DeliveryFilterReq) ValidateFields() url.Values {
+	rules := govalidator.MapData{
+		"status": []string{"in:pending,in_flight,delivered,dead"},
+	}
+
+	return validate(&req, rules)
+}
+
+type AttemptRes struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+type DeliveryRes struct {
+	ID            string       `json:"id"`
+	EventID       string       `json:"event_id"`
+	Event         string       `json:"event"`
+	Status        string       `json:"status"`
+	Attempts      []AttemptRes `json:"attempts"`
+	NextAttemptAt *time.Time   `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	DeliveredAt   *time.Time   `json:"delivered_at,omitempty"`
+	RedeliveryOf  string       `json:"redelivery_of,omitempty"`
+	Payload       string       `json:"payload"`
+}
+
+// EventPayload is the body of the webhook deliveries.
+type EventPayload struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	UserID     string                 `json:"user_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
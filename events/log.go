@@ -0,0 +1,25 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"user-api/models"
+)
+
+// LogSink logs the events, to follow them without a broker.
+type LogSink struct {
+	log *slog.Logger
+}
+
+func NewLogSink(logger *slog.Logger) LogSink {
+	return LogSink{log: logger.With("component", "event_log")}
+}
+
+func (s LogSink) Name() string {
+	return "log"
+}
+
+func (s LogSink) Publish(ctx context.Context, e models.Event) error {
+	s.log.InfoContext(ctx, "event", "event_id", e.ID.Hex(), "type", e.Type, "user_id", e.UserID, "occurred_at", e.OccurredAt, "data", e.Data)
+	return nil
+}
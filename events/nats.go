@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"user-api/models"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type NATSConfig struct {
+	URL string
+	// Stream is the JetStream stream storing the events, created on the
+	// first publish when missing.
+	Stream string
+	// SubjectPrefix is followed by the event type in the subjects, e.g.
+	// user-api.events.user.created.
+	SubjectPrefix string
+}
+
+// NATSSink publishes the events to a JetStream stream, the event id being
+// the message id so the stream drops the copies of a relay retried within
+// its duplicate window.
+type NATSSink struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+	cfg  NATSConfig
+
+	mu      sync.Mutex
+	created bool
+}
+
+func NewNATSSink(cfg NATSConfig) (*NATSSink, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name("user-api"), nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &NATSSink{conn: conn, js: js, cfg: cfg}, nil
+}
+
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+func (s *NATSSink) Publish(ctx context.Context, e models.Event) error {
+	if err := s.ensureStream(ctx); err != nil {
+		return err
+	}
+	body, err := Encode(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.js.Publish(ctx, s.cfg.SubjectPrefix+"."+e.Type, body, jetstream.WithMsgID(e.ID.Hex()))
+	return err
+}
+
+func (s *NATSSink) ensureStream(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created {
+		return nil
+	}
+
+	_, err := s.js.Stream(ctx, s.cfg.Stream)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = s.js.CreateStream(ctx, jetstream.StreamConfig{Name: s.cfg.Stream, Subjects: []string{s.cfg.SubjectPrefix + ".>"}})
+	}
+	if err != nil {
+		return err
+	}
+	s.created = true
+	return nil
+}
+
+// Check reports whether the connection to the server is up.
+func (s *NATSSink) Check(context.Context) error {
+	if !s.conn.IsConnected() {
+		return errors.New("not connected to nats: " + s.conn.Status().String())
+	}
+	return nil
+}
+
+// Close publishes the pending messages and closes the connection.
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
@@ -0,0 +1,29 @@
+// Package events holds the sinks the outbox relay publishes the user
+// lifecycle events to.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"user-api/dto"
+	"user-api/models"
+)
+
+// Sink is a destination of the events. Publish returns once the sink
+// durably accepted e, the relay publishing it again otherwise, so sinks
+// receive every event at least once and those of a user in order.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e models.Event) error
+}
+
+// Encode returns the JSON body of e, the same for every sink.
+func Encode(e models.Event) ([]byte, error) {
+	return json.Marshal(dto.EventPayload{
+		ID:         e.ID.Hex(),
+		Type:       e.Type,
+		UserID:     e.UserID,
+		OccurredAt: e.OccurredAt,
+		Data:       e.Data,
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"user-api/models"
+	"user-api/userio"
+
+	"github.com/urfave/cli/v2"
+)
+
+func exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "export",
+		Usage:     "Stream users to a CSV, NDJSON or Parquet file",
+		ArgsUsage: "FILE (- for stdout)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Usage: "csv, ndjson or parquet, guessed from the file extension when omitted"},
+			&cli.StringFlag{Name: "columns", Usage: "comma separated columns, every column when omitted"},
+			&cli.StringFlag{Name: "email", Usage: "only export the user with this email"},
+			&cli.StringFlag{Name: "locale", Usage: "only export users with this locale"},
+			&cli.StringFlag{Name: "role", Usage: "only export users with this role"},
+		},
+		Action: exportUsers,
+	}
+}
+
+func exportUsers(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return cli.Exit("missing FILE argument", 2)
+	}
+
+	format := c.String("format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+		if format == "jsonl" {
+			format = userio.FormatNDJSON
+		}
+	}
+
+	columns, err := userio.ParseColumns(c.String("columns"))
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w, err := userio.NewUserWriter(format, out, columns)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	filter := models.UserFilter{Email: c.String("email"), Locale: c.String("locale"), Role: c.String("role")}
+	return withApp(c, func(ctx context.Context, a *app) error {
+		count, apiErr := a.exportSvc.Export(ctx, filter, w)
+		if apiErr.Status != 0 {
+			return commandError("exporting to "+path, apiErr)
+		}
+
+		fmt.Fprintf(os.Stderr, "%d users exported\n", count)
+		return nil
+	})
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// HealthChecker is implemented by every dependency the api needs to serve
+// traffic, e.g. the configured UserRepo backend.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type Registry struct {
+	timeout  time.Duration
+	checkers []HealthChecker
+	draining atomic.Bool
+	mu       sync.RWMutex
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+func (r *Registry) Register(c HealthChecker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers = append(r.checkers, c)
+}
+
+// SetDraining marks the api as shutting down so readiness reports not ready
+// while in-flight requests finish.
+func (r *Registry) SetDraining() {
+	r.draining.Store(true)
+}
+
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Ready runs every registered check concurrently, each one bounded by the
+// registry timeout, and aggregates the results.
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := append([]HealthChecker(nil), r.checkers...)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checkers))}
+	results := make([]CheckResult, len(checkers))
+
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c HealthChecker) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i, c := range checkers {
+		report.Checks[c.Name()] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	if r.Draining() {
+		report.Status = StatusDraining
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c HealthChecker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChecker struct {
+	name  string
+	err   error
+	delay time.Duration
+}
+
+func (f fakeChecker) Name() string { return f.name }
+
+func (f fakeChecker) Check(ctx context.Context) error {
+	select {
+	case <-time.After(f.delay):
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestReadyAllUp(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register(fakeChecker{name: "mongo"})
+
+	report := r.Ready(context.Background())
+
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["mongo"].Status)
+}
+
+func TestReadyCheckFails(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register(fakeChecker{name: "mongo"})
+	r.Register(fakeChecker{name: "cache", err: errors.New("connection refused")})
+
+	report := r.Ready(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["mongo"].Status)
+	assert.Equal(t, "connection refused", report.Checks["cache"].Error)
+}
+
+func TestReadyCheckTimeout(t *testing.T) {
+	r := NewRegistry(10 * time.Millisecond)
+	r.Register(fakeChecker{name: "mongo", delay: time.Second})
+
+	report := r.Ready(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["mongo"].Error)
+}
+
+func TestReadyDraining(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register(fakeChecker{name: "mongo"})
+	r.SetDraining()
+
+	report := r.Ready(context.Background())
+
+	assert.Equal(t, StatusDraining, report.Status)
+}
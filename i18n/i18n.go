@@ -0,0 +1,162 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+const (
+	English    = "en"
+	Portuguese = "pt"
+	Spanish    = "es"
+
+	DefaultLocale = English
+
+	// PreferredLocaleKey is the gin context key holding the stored locale of
+	// the authenticated user, set by VerifyToken.
+	PreferredLocaleKey = "preferred_locale"
+)
+
+// Supported lists the locales with a catalog, the first one is the fallback.
+var Supported = []string{English, Portuguese, Spanish}
+
+//go:embed locales/*.json
+var locales embed.FS
+
+type Catalog struct {
+	messages map[string]map[string]string
+	matcher  language.Matcher
+}
+
+// Default is the catalog built from the embedded locales.
+var Default = mustLoad()
+
+func mustLoad() *Catalog {
+	c, err := Load(locales)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Load reads one <locale>.json file per supported locale from fsys.
+func Load(fsys embed.FS) (*Catalog, error) {
+	c := &Catalog{messages: make(map[string]map[string]string, len(Supported))}
+	tags := make([]language.Tag, 0, len(Supported))
+
+	for _, l := range Supported {
+		b, err := fsys.ReadFile(path.Join("locales", l+".json"))
+		if err != nil {
+			return nil, err
+		}
+		m := map[string]string{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("parsing %s catalog: %w", l, err)
+		}
+		c.messages[l] = m
+		tags = append(tags, language.Make(l))
+	}
+
+	c.matcher = language.NewMatcher(tags)
+	return c, nil
+}
+
+// Message returns the message of key in locale, falling back to the default
+// locale when the key has no translation.
+func (c *Catalog) Message(locale, key string) (string, bool) {
+	if m, ok := c.messages[locale][key]; ok {
+		return m, true
+	}
+	m, ok := c.messages[DefaultLocale][key]
+	return m, ok
+}
+
+// Negotiate picks the locale of a response: the user stored preference when
+// supported, then the best Accept-Language match, then DefaultLocale.
+func (c *Catalog) Negotiate(preferred, acceptLanguage string) string {
+	if IsSupported(preferred) {
+		return preferred
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, idx, conf := c.matcher.Match(tags...)
+	if conf == language.No {
+		return DefaultLocale
+	}
+	return Supported[idx]
+}
+
+func IsSupported(locale string) bool {
+	for _, l := range Supported {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Locale negotiates the locale of the request handled by c.
+func Locale(c *gin.Context) string {
+	return Default.Negotiate(c.GetString(PreferredLocaleKey), c.GetHeader("Accept-Language"))
+}
+
+// ErrorTitle translates the title of an error code, fallback is returned for
+// codes missing from the catalog.
+func ErrorTitle(locale, code, fallback string) string {
+	if m, ok := Default.Message(locale, "error."+code+".title"); ok {
+		return m
+	}
+	return fallback
+}
+
+// ErrorDetail translates detail when it is the generic detail of the error
+// code, details specific to one occurrence are returned untouched.
+func ErrorDetail(locale, code, detail string) string {
+	key := "error." + code + ".detail"
+	if en, ok := Default.Message(DefaultLocale, key); !ok || en != detail {
+		return detail
+	}
+	m, _ := Default.Message(locale, key)
+	return m
+}
+
+// ValidationMessage translates a failed govalidator rule of field. Templates
+// get the field as %[1]s, the whole parameter list as %[2]s and each
+// parameter from %[3]s on.
+func ValidationMessage(locale, field, rule, param string) string {
+	tmpl, ok := Default.Message(locale, "validation."+rule)
+	if !ok {
+		tmpl, _ = Default.Message(locale, "validation.invalid")
+	}
+
+	args := []interface{}{field, strings.ReplaceAll(param, ",", ", ")}
+	for _, p := range strings.Split(param, ",") {
+		args = append(args, p)
+	}
+	return fmt.Sprintf(tmpl, args...)
+}
+
+// Mail translates the subject and body of the email name, the templates
+// getting args.
+func Mail(locale, name string, args ...interface{}) (subject, body string) {
+	subject, _ = Default.Message(locale, "mail."+name+".subject")
+	body, _ = Default.Message(locale, "mail."+name+".body")
+	return subject, fmt.Sprintf(body, args...)
+}
+
+// Page translates the title and the button of the page name.
+func Page(locale, name string) (title, button string) {
+	title, _ = Default.Message(locale, "page."+name+".title")
+	button, _ = Default.Message(locale, "page."+name+".button")
+	return title, button
+}
@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryLocaleTranslatesEveryKey(t *testing.T) {
+	for _, l := range Supported {
+		for key := range Default.messages[DefaultLocale] {
+			_, ok := Default.messages[l][key]
+			assert.True(t, ok, "%s catalog misses %s", l, key)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		preferred string
+		header    string
+		expected  string
+	}{
+		{"", "", English},
+		{"", "pt-BR,pt;q=0.9", Portuguese},
+		{"", "fr-FR,es;q=0.5", Spanish},
+		{"", "de-DE", English},
+		{"", "not a language;;", English},
+		{Spanish, "pt-BR", Spanish},
+		{"fr", "pt-BR", Portuguese},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, Default.Negotiate(c.preferred, c.header), "preferred %q header %q", c.preferred, c.header)
+	}
+}
+
+func TestErrorDetailKeepsSpecificDetails(t *testing.T) {
+	assert.Equal(t, "Um ou mais campos são inválidos", ErrorDetail(Portuguese, "VALIDATION_ERROR", "One or more fields are invalid"))
+	assert.Equal(t, "id 42 is malformed", ErrorDetail(Portuguese, "VALIDATION_ERROR", "id 42 is malformed"))
+}
+
+func TestValidationMessageParams(t *testing.T) {
+	assert.Equal(t, "The expires_in_days field must be between 1 and 365", ValidationMessage(English, "expires_in_days", "numeric_between", "1,365"))
+	assert.Equal(t, "O campo locale deve ser um de en, pt, es", ValidationMessage(Portuguese, "locale", "in", "en,pt,es"))
+}
+
+func TestMail(t *testing.T) {
+	subject, body := Mail(Spanish, "email_change_confirm", "Ana", "ana@example.com", "https://example.com/confirm", 24)
+
+	assert.Equal(t, "Confirma tu nueva dirección de email", subject)
+	assert.Contains(t, body, "Hola Ana,")
+	assert.Contains(t, body, "24 horas")
+	assert.Contains(t, body, "\n\nhttps://example.com/confirm\n\n")
+}
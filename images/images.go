@@ -0,0 +1,121 @@
+// Package images turns uploaded pictures into square thumbnails. Every
+// output is encoded again from the pixels, which drops EXIF and any other
+// metadata, such as the location a photo was taken at.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooManyPixels     = errors.New("image has too many pixels")
+)
+
+// MaxPixels bounds the decoded size of an image, a small file can
+// decompress to gigabytes.
+const MaxPixels = 40_000_000
+
+// ContentTypes are the formats accepted, as sniffed from the content.
+var ContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+func Supported(contentType string) bool {
+	for _, ct := range ContentTypes {
+		if ct == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+type Image struct {
+	// Size is the width and height in pixels.
+	Size        int
+	ContentType string
+	Data        []byte
+}
+
+// Squares decodes data and returns the center square of the picture,
+// upright according to its EXIF orientation, scaled to each of sizes.
+// Pictures are never enlarged, a size above the smallest side giving that
+// side instead. Opaque pictures are encoded as JPEG, the others as PNG to
+// keep the transparency.
+func Squares(data []byte, sizes []int) ([]Image, error) {
+	if !Supported(http.DetectContentType(data)) {
+		return nil, ErrUnsupportedFormat
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	orientation := 1
+	if format == "jpeg" {
+		orientation = exifOrientation(data)
+	}
+
+	// the center square is the same whatever the orientation, which is
+	// applied to the small scaled copies
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0, y0 := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+	opaque := isOpaque(src)
+
+	images := make([]Image, 0, len(sizes))
+	for _, size := range sizes {
+		n := min(size, side)
+		var dst draw.Image = image.NewNRGBA(image.Rect(0, 0, n, n))
+		if opaque {
+			dst = image.NewRGBA(image.Rect(0, 0, n, n))
+		}
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+		img, err := encode(orient(dst, orientation), opaque)
+		if err != nil {
+			return nil, err
+		}
+		img.Size = n
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+func encode(img image.Image, opaque bool) (Image, error) {
+	var buf bytes.Buffer
+	if opaque {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return Image{}, fmt.Errorf("encoding jpeg: %w", err)
+		}
+		return Image{ContentType: "image/jpeg", Data: buf.Bytes()}, nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return Image{}, fmt.Errorf("encoding png: %w", err)
+	}
+	return Image{ContentType: "image/png", Data: buf.Bytes()}, nil
+}
@@ -0,0 +1,130 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves is a 64x32 picture red above, blue below.
+func halves() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			c := red
+			if y >= 16 {
+				c = blue
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// withExif inserts an EXIF segment holding orientation after the start
+// of image marker of a JPEG.
+func withExif(t *testing.T, orientation uint16) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buf, halves(), &jpeg.Options{Quality: 95}))
+	jpg := buf.Bytes()
+
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1}
+	tiff = binary.BigEndian.AppendUint16(tiff, tagOrientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func near(t *testing.T, want color.RGBA, got color.Color) {
+	r, g, b, _ := got.RGBA()
+	wr, wg, wb, _ := want.RGBA()
+	diff := func(a, b uint32) bool { return a > b+0x2000 || b > a+0x2000 }
+	assert.False(t, diff(r, wr) || diff(g, wg) || diff(b, wb), "want %v, got %v", want, got)
+}
+
+func TestExifOrientation(t *testing.T) {
+	assert.Equal(t, 6, exifOrientation(withExif(t, 6)))
+	assert.Equal(t, 1, exifOrientation(withExif(t, 9)))
+
+	var buf bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buf, halves(), nil))
+	assert.Equal(t, 1, exifOrientation(buf.Bytes()))
+	assert.Equal(t, 1, exifOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}))
+}
+
+func TestSquaresStripsExifAndRotates(t *testing.T) {
+	out, err := Squares(withExif(t, 6), []int{128, 16})
+
+	assert.Nil(t, err)
+	assert.Len(t, out, 2)
+	assert.Equal(t, 32, out[0].Size, "never enlarged")
+	assert.Equal(t, 16, out[1].Size)
+	for _, img := range out {
+		assert.Equal(t, "image/jpeg", img.ContentType)
+		assert.False(t, bytes.Contains(img.Data, []byte("Exif")))
+		assert.Equal(t, 1, exifOrientation(img.Data))
+	}
+
+	// rotated clockwise, the top of the picture is on the right
+	decoded, err := jpeg.Decode(bytes.NewReader(out[0].Data))
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 32, 32), decoded.Bounds())
+	near(t, blue, decoded.At(4, 16))
+	near(t, red, decoded.At(27, 16))
+}
+
+func TestSquaresKeepsTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	img.Set(5, 5, red)
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, img))
+
+	out, err := Squares(buf.Bytes(), []int{8})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", out[0].ContentType)
+	decoded, err := png.Decode(bytes.NewReader(out[0].Data))
+	assert.Nil(t, err)
+	_, _, _, a := decoded.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0), a)
+}
+
+func TestSquaresRejectsOtherContent(t *testing.T) {
+	_, err := Squares([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), []int{64})
+
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestSquaresRejectsTooManyPixels(t *testing.T) {
+	// only the header is read, a 100000x100000 picture is never decoded
+	ihdr := binary.BigEndian.AppendUint32([]byte("IHDR"), 100000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 100000)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, 13)
+	data = append(data, ihdr...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ihdr))
+
+	_, err := Squares(data, []int{64})
+
+	assert.Equal(t, ErrTooManyPixels, err)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"user-api/dto"
+	"user-api/response"
+	service "user-api/services"
+	"user-api/userio"
+
+	"github.com/urfave/cli/v2"
+)
+
+func importCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "Bulk create users from a CSV or NDJSON file",
+		ArgsUsage: "FILE (- for stdin)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Usage: "csv or ndjson, guessed from the file extension when omitted"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "validate every row without creating users"},
+			&cli.IntFlag{Name: "batch-size", Value: service.DefaultImportBatchSize, Usage: "rows saved per round trip, at most 1000"},
+			&cli.StringFlag{Name: "report", Usage: "write the per-row json report to this file instead of stdout"},
+		},
+		Action: importUsers,
+	}
+}
+
+func importUsers(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return cli.Exit("missing FILE argument", 2)
+	}
+
+	format := c.String("format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+		if format == "jsonl" {
+			format = userio.FormatNDJSON
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	rows, err := userio.NewRowReader(format, in)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var report dto.ImportReport
+	err = withApp(c, func(ctx context.Context, a *app) error {
+		var apiErr response.ApiError
+		report, apiErr = a.importSvc.Import(ctx, rows, service.ImportOptions{
+			DryRun:    c.Bool("dry-run"),
+			BatchSize: c.Int("batch-size"),
+		})
+		if apiErr.Status != 0 {
+			return commandError("importing "+path, apiErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeReport(c.String("report"), report); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d rows: %d created, %d valid, %d failed\n", report.Total, report.Created, report.Valid, report.Failed)
+	if report.Failed != 0 {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+func writeReport(path string, report dto.ImportReport) error {
+	out := os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return writeJSON(out, report)
+}
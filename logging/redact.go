@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const Redacted = "[REDACTED]"
+
+// jwtPattern matches compact JWS values so a token logged under an innocent
+// key is still caught.
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+type Redactor struct {
+	keys map[string]struct{}
+}
+
+// NewRedactor redacts the given keys on top of the always redacted ones. Keys
+// are matched case insensitively, ignoring "_" and "-".
+func NewRedactor(fields []string) Redactor {
+	r := Redactor{keys: make(map[string]struct{})}
+	for _, f := range append(alwaysRedacted, fields...) {
+		if f = normalizeKey(f); f != "" {
+			r.keys[f] = struct{}{}
+		}
+	}
+	return r
+}
+
+func normalizeKey(k string) string {
+	k = strings.ToLower(strings.TrimSpace(k))
+	k = strings.ReplaceAll(k, "_", "")
+	return strings.ReplaceAll(k, "-", "")
+}
+
+// ReplaceAttr is meant to be plugged in slog.HandlerOptions, it runs for every
+// attribute including the ones nested in groups and LogValuer results.
+func (r Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if _, ok := r.keys[normalizeKey(a.Key)]; ok {
+		return slog.String(a.Key, Redacted)
+	}
+
+	if a.Value.Kind() == slog.KindString {
+		if s := a.Value.String(); jwtPattern.MatchString(s) {
+			return slog.String(a.Key, jwtPattern.ReplaceAllString(s, Redacted))
+		}
+	}
+
+	return a
+}
+
+// Fingerprint stands for a personal value, such as an email, in the logs:
+// the lines about the same value share it without the value being logged.
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(sum[:6])
+}
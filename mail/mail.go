@@ -0,0 +1,80 @@
+// Package mail sends the plain text emails of the api, such as the links
+// confirming an email change.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// format renders m as an RFC 5322 message from from, the body being
+// quoted-printable UTF-8 text.
+func format(from string, m Message, now time.Time) ([]byte, error) {
+	if _, err := netmail.ParseAddress(m.To); err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", m.To, err)
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return nil, errors.New("subject contains a line break")
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := from[strings.LastIndex(from, "@")+1:]
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LogMailer logs the recipient and subject of the messages instead of
+// sending them, for development. Bodies are not logged as their links carry
+// live tokens.
+type LogMailer struct {
+	log *slog.Logger
+}
+
+func NewLogMailer(logger *slog.Logger) LogMailer {
+	return LogMailer{log: logger.With("component", "log_mailer")}
+}
+
+func (m LogMailer) Send(ctx context.Context, msg Message) error {
+	m.log.InfoContext(ctx, "mail not sent, no smtp server configured", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
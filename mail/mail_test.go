@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	data, err := format("no-reply@example.com", Message{To: "ana@example.com", Subject: "Confirmação", Body: "Olá\nhttps://example.com/confirm?token=abc"}, now)
+
+	assert.Nil(t, err)
+	msg := string(data)
+	assert.Contains(t, msg, "To: ana@example.com\r\n")
+	assert.Contains(t, msg, "Subject: =?utf-8?q?Confirma=C3=A7=C3=A3o?=\r\n")
+	assert.Contains(t, msg, "Date: Mon, 19 Oct 2026 09:00:00 +0000\r\n")
+	assert.Contains(t, msg, "@example.com>\r\n")
+	assert.Contains(t, msg, "\r\n\r\nOl=C3=A1\r\nhttps://example.com/confirm?token=3Dabc")
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	_, err := format("no-reply@example.com", Message{To: "ana@example.com\r\nBcc: eve@example.com", Subject: "hi"}, time.Now())
+	assert.NotNil(t, err)
+
+	_, err = format("no-reply@example.com", Message{To: "ana@example.com", Subject: "hi\r\nBcc: eve@example.com"}, time.Now())
+	assert.NotNil(t, err)
+}
+
+// fakeSMTP accepts one message and sends its envelope and data on the
+// returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	received := make(chan string, 1)
+
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var got strings.Builder
+		fmt.Fprint(conn, "220 fake\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				fmt.Fprint(conn, "250 fake\r\n")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				got.WriteString(line)
+				fmt.Fprint(conn, "250 ok\r\n")
+			case cmd == "DATA":
+				fmt.Fprint(conn, "354 go\r\n")
+				for {
+					line, _ := r.ReadString('\n')
+					if line == ".\r\n" {
+						break
+					}
+					got.WriteString(line)
+				}
+				fmt.Fprint(conn, "250 queued\r\n")
+			case cmd == "QUIT":
+				fmt.Fprint(conn, "221 bye\r\n")
+				received <- got.String()
+				return
+			default:
+				fmt.Fprint(conn, "502 unsupported\r\n")
+			}
+		}
+	}()
+	return l.Addr().String(), received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	m := NewSMTPMailer(SMTPConfig{Addr: addr, From: "no-reply@example.com"})
+
+	err := m.Send(context.Background(), Message{To: "ana@example.com", Subject: "Hello", Body: "Hi"})
+
+	assert.Nil(t, err)
+	got := <-received
+	assert.Contains(t, got, "MAIL FROM:<no-reply@example.com>")
+	assert.Contains(t, got, "RCPT TO:<ana@example.com>")
+	assert.Contains(t, got, "Subject: Hello\r\n")
+}
+
+func TestSMTPMailerTimesOut(t *testing.T) {
+	// the server accepts the connection but never greets
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+	m := NewSMTPMailer(SMTPConfig{Addr: l.Addr().String(), From: "no-reply@example.com", Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	err = m.Send(context.Background(), Message{To: "ana@example.com", Subject: "Hello", Body: "Hi"})
+
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestLogMailerOmitsBody(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	assert.Nil(t, m.Send(context.Background(), Message{To: "ana@example.com", Subject: "Hello", Body: "https://example.com/confirm?token=secret"}))
+
+	assert.Contains(t, buf.String(), "Hello")
+	assert.NotContains(t, buf.String(), "secret")
+}
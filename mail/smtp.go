@@ -0,0 +1,94 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type SMTPConfig struct {
+	// Addr is the host:port of the server.
+	Addr     string
+	Username string
+	Password string
+	From     string
+	// Timeout bounds the sending of a message when the context has no
+	// earlier deadline.
+	Timeout time.Duration
+}
+
+// SMTPMailer sends messages through an SMTP server, upgrading the
+// connection with STARTTLS when the server offers it.
+type SMTPMailer struct {
+	cfg SMTPConfig
+	now func() time.Time
+}
+
+func NewSMTPMailer(cfg SMTPConfig) SMTPMailer {
+	return SMTPMailer{cfg: cfg, now: time.Now}
+}
+
+func (m SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := format(m.cfg.From, msg, m.now())
+	if err != nil {
+		return err
+	}
+	host, _, err := net.SplitHostPort(m.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address %s: %w", m.cfg.Addr, err)
+	}
+
+	if m.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.cfg.Timeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	// net/smtp refuses plain auth over unencrypted connections but to
+	// localhost
+	if m.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -1,47 +1,44 @@
 package main
 
 import (
-	"context"
-	"user-api/controllers/v1"
-	database "user-api/databases"
-	docs "user-api/docs"
-	"user-api/repositories"
-	routes "user-api/routes"
-	service "user-api/services"
+	"fmt"
+	"os"
 
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"     // swagger embed files
-	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
+	"github.com/urfave/cli/v2"
 )
 
-func main() {
-	//init mongo connection
-	ctx := context.TODO()
-	mongoClient := database.MongoInit(&ctx)
-	defer mongoClient.Disconnect(ctx)
-	userDb := mongoClient.Database("user-api")
-
-	//init repositories
-	userRepo := repositories.NewUserMongo(userDb.Collection("users"), ctx)
-
-	//init userService
-	userSvc := service.NewUser(userRepo)
+// @title User API
+// @version 1.0
+// @description This is a simple user CRUD
+// @BasePath /v1
 
-	//init controller
-	userController := controllers.NewUserJson(userSvc)
-	authController := controllers.NewAuth(userSvc)
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the jwt returned by /auth/login
 
-	//init v1 router
-	router := gin.Default()
-	docs.SwaggerInfo.BasePath = "/v1"
-	v1 := router.Group("/v1")
-
-	//set routes
-	userGroup := v1.Group("/users")
-	userGroup.Use(authController.VerifyToken())
-	routes.SetUsersRoutes(userGroup, userController)
-	routes.SetAuthRoutes(v1.Group("/auth"), authController)
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	router.Run(":8082")
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+func main() {
+	app := &cli.App{
+		Name:  "user-api",
+		Usage: "User api server and admin commands, sharing its configuration",
+		Commands: []*cli.Command{
+			serveCommand(),
+			migrateCommand(),
+			userCommand(),
+			tokenCommand(),
+			importCommand(),
+			exportCommand(),
+			seedCommand(),
+		},
+		// running the binary without a command keeps starting the server
+		Action: serve,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
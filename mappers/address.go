@@ -0,0 +1,45 @@
+package mappers
+
+import (
+	"strings"
+	"user-api/dto"
+	"user-api/models"
+	"user-api/postal"
+)
+
+// AddressReqToAddress normalizes the country and postal code, the id and
+// default flag are left to the service.
+func AddressReqToAddress(req dto.AddressReq) models.Address {
+	return models.Address{
+		Label:      req.Label,
+		Line1:      strings.TrimSpace(req.Line1),
+		Line2:      strings.TrimSpace(req.Line2),
+		City:       strings.TrimSpace(req.City),
+		Region:     strings.TrimSpace(req.Region),
+		PostalCode: postal.NormalizePostalCode(req.PostalCode),
+		Country:    postal.NormalizeCountry(req.Country),
+	}
+}
+
+func AddressToRes(a models.Address) dto.AddressRes {
+	return dto.AddressRes{
+		ID:         a.ID.Hex(),
+		Label:      a.Label,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Default:    a.Default,
+		Legacy:     a.Legacy,
+	}
+}
+
+func AddressesToRes(addresses []models.Address) []dto.AddressRes {
+	r := make([]dto.AddressRes, 0, len(addresses))
+	for _, a := range addresses {
+		r = append(r, AddressToRes(a))
+	}
+	return r
+}
@@ -0,0 +1,27 @@
+package mappers
+
+import (
+	"user-api/dto"
+	"user-api/models"
+)
+
+func ApiKeyToRes(k models.ApiKey) dto.ApiKeyRes {
+	return dto.ApiKeyRes{
+		ID:         k.ID.Hex(),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+func ApiKeysToRes(keys []models.ApiKey) []dto.ApiKeyRes {
+	r := make([]dto.ApiKeyRes, 0, len(keys))
+	for _, k := range keys {
+		r = append(r, ApiKeyToRes(k))
+	}
+	return r
+}
@@ -0,0 +1,15 @@
+package mappers
+
+import (
+	"encoding/json"
+	"user-api/dto"
+	"user-api/models"
+)
+
+func AttributeSchemaToRes(s models.AttributeSchema) dto.AttributeSchemaRes {
+	res := dto.AttributeSchemaRes{Schema: json.RawMessage(s.Schema), Version: s.Version}
+	if !s.UpdatedAt.IsZero() {
+		res.UpdatedAt = &s.UpdatedAt
+	}
+	return res
+}
@@ -0,0 +1,10 @@
+package mappers
+
+import (
+	"user-api/dto"
+	"user-api/models"
+)
+
+func AvatarToRes(a models.Avatar) dto.AvatarRes {
+	return dto.AvatarRes{URL: a.URL, Thumbnails: a.Thumbnails, UpdatedAt: a.UpdatedAt}
+}
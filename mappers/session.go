@@ -0,0 +1,27 @@
+package mappers
+
+import (
+	"user-api/dto"
+	"user-api/models"
+)
+
+func SessionToRes(s models.Session, currentFamilyID string) dto.SessionRes {
+	return dto.SessionRes{
+		ID:         s.ID.Hex(),
+		Device:     s.Device,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    currentFamilyID != "" && s.FamilyID == currentFamilyID,
+	}
+}
+
+func SessionsToRes(sessions []models.Session, currentFamilyID string) []dto.SessionRes {
+	r := make([]dto.SessionRes, 0, len(sessions))
+	for _, s := range sessions {
+		r = append(r, SessionToRes(s, currentFamilyID))
+	}
+	return r
+}
@@ -1,41 +1,109 @@
 package mappers
 
 import (
+	"math"
+	"time"
 	"user-api/dto"
 	"user-api/models"
 )
 
+// birthDate parses a validated birth date, or estimates it from the age
+// given by older clients.
+func birthDate(date string, age uint8) (birth *time.Time, estimated bool) {
+	if t, err := time.Parse(dto.DateLayout, date); err == nil {
+		return &t, false
+	}
+	if age != 0 {
+		t := models.EstimateBirthDate(int(age), time.Now())
+		return &t, true
+	}
+	return nil, false
+}
+
 func RegisterReqToUser(req dto.RegisterUserReq) models.User {
-	return *models.NewUser(req.Name, req.Age, req.Email, req.Password, req.Address)
+	birth, estimated := birthDate(req.BirthDate, req.Age)
+	u := models.NewUser(req.Name, birth, req.Email, req.Password, req.Address)
+	u.BirthDateEstimated = estimated
+	u.Locale = req.Locale
+	u.Attributes = req.Attributes
+	for _, a := range req.Addresses {
+		address := AddressReqToAddress(a)
+		address.Default = a.Default
+		u.Addresses = append(u.Addresses, address)
+	}
+	if len(u.Addresses) == 0 && req.Address != "" {
+		u.Addresses = []models.Address{models.LegacyAddress(req.Address)}
+	}
+	return *u
 }
 
 func UserToPagRes(users []models.User) []dto.UserResponse {
 	r := make([]dto.UserResponse, 0)
 	for _, u := range users {
-		r = append(r, dto.UserResponse{
-			Name:  u.Name,
-			ID:    u.ID.Hex(),
-			Age:   u.Age,
-			Email: u.Email,
-		})
+		r = append(r, UserToRes(u))
 	}
 
 	return r
 }
 
 func UserToRes(user models.User) dto.UserResponse {
-	return dto.UserResponse{
-		Name:  user.Name,
-		Email: user.Email,
-		Age:   user.Age,
-		ID:    user.ID.Hex(),
+	res := dto.UserResponse{
+		Name:               user.Name,
+		Email:              user.Email,
+		ID:                 user.ID.Hex(),
+		BirthDateEstimated: user.BirthDateEstimated,
+		Locale:             user.Locale,
+		Attributes:         user.Attributes,
+		Addresses:          AddressesToRes(user.Addresses),
+	}
+	if age, ok := user.AgeOn(time.Now()); ok && age > 0 {
+		// stored dates predate the checks, e.g. a birth date in the future
+		if age > math.MaxUint8 {
+			age = math.MaxUint8
+		}
+		res.Age = uint8(age)
+	}
+	if user.BirthDate != nil {
+		res.BirthDate = user.BirthDate.Format(dto.DateLayout)
 	}
+	if user.Avatar != nil {
+		avatar := AvatarToRes(*user.Avatar)
+		res.Avatar = &avatar
+	}
+	return res
 }
 
 func UserUpdateReqToUser(user dto.UserUpdateReq) models.User {
+	birth, estimated := birthDate(user.BirthDate, user.Age)
 	return models.User{
-		Name:    user.Name,
-		Address: user.Address,
-		Age:     user.Age,
+		Name:               user.Name,
+		Address:            user.Address,
+		BirthDate:          birth,
+		BirthDateEstimated: estimated || user.BirthDateEstimated,
+		Locale:             user.Locale,
+		Attributes:         user.Attributes,
+	}
+}
+
+func UserToUpdateReq(user models.User) dto.UserUpdateReq {
+	req := dto.UserUpdateReq{
+		Name:               user.Name,
+		Address:            user.Address,
+		Age:                user.Age,
+		BirthDateEstimated: user.BirthDateEstimated,
+		Locale:             user.Locale,
+		Attributes:         user.Attributes,
+	}
+	if user.BirthDate != nil {
+		req.BirthDate = user.BirthDate.Format(dto.DateLayout)
+	}
+	return req
+}
+
+func UserFilterReqToFilter(req dto.UserFilterReq) models.UserFilter {
+	return models.UserFilter{
+		Email:  req.Email,
+		Locale: req.Locale,
+		Role:   req.Role,
 	}
 }
@@ -0,0 +1,74 @@
+package mappers
+
+import (
+	"user-api/dto"
+	"user-api/models"
+)
+
+// WebhookReqToWebhook maps a request to a webhook, active unless told
+// otherwise.
+func WebhookReqToWebhook(req dto.WebhookReq) models.Webhook {
+	return models.Webhook{
+		URL:    req.URL,
+		Events: req.Events,
+		Active: req.Active == nil || *req.Active,
+	}
+}
+
+// WebhookToRes maps w leaving its secret out, set by the create endpoint
+// only.
+func WebhookToRes(w models.Webhook) dto.WebhookRes {
+	return dto.WebhookRes{
+		ID:        w.ID.Hex(),
+		URL:       w.URL,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func WebhooksToRes(hooks []models.Webhook) []dto.WebhookRes {
+	r := make([]dto.WebhookRes, 0, len(hooks))
+	for _, w := range hooks {
+		r = append(r, WebhookToRes(w))
+	}
+	return r
+}
+
+func DeliveryToRes(d models.WebhookDelivery) dto.DeliveryRes {
+	r := dto.DeliveryRes{
+		ID:          d.ID.Hex(),
+		EventID:     d.EventID.Hex(),
+		Event:       d.Event,
+		Status:      d.Status,
+		Attempts:    make([]dto.AttemptRes, 0, len(d.Attempts)),
+		CreatedAt:   d.CreatedAt,
+		DeliveredAt: d.DeliveredAt,
+		Payload:     d.Payload,
+	}
+	if d.Status == models.DeliveryPending {
+		next := d.NextAttemptAt
+		r.NextAttemptAt = &next
+	}
+	if d.RedeliveryOf != nil {
+		r.RedeliveryOf = d.RedeliveryOf.Hex()
+	}
+	for _, a := range d.Attempts {
+		r.Attempts = append(r.Attempts, dto.AttemptRes{
+			At:         a.At,
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMs: a.Duration.Milliseconds(),
+		})
+	}
+	return r
+}
+
+func DeliveriesToRes(deliveries []models.WebhookDelivery) []dto.DeliveryRes {
+	r := make([]dto.DeliveryRes, 0, len(deliveries))
+	for _, d := range deliveries {
+		r = append(r, DeliveryToRes(d))
+	}
+	return r
+}
@@ -0,0 +1,170 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+//
+// Metric names are part of the api contract used by dashboards and alerts,
+// do not rename them:
+//
+//	user_api_http_requests_total{method,route,status}
+//	user_api_http_request_duration_seconds{method,route,status}
+//	user_api_auth_login_total{result,reason}
+//	user_api_auth_token_validation_failures_total{reason}
+//	user_api_password_hash_duration_seconds{operation}
+//	user_api_repository_operation_duration_seconds{method,code}
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "user_api"
+
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Reasons used as label values, kept as constants so cardinality stays bounded.
+const (
+	ReasonNone              = "none"
+	ReasonInvalidInput      = "invalid_input"
+	ReasonUserNotFound      = "user_not_found"
+	ReasonInvalidPassword   = "invalid_password"
+	ReasonInternalError     = "internal_error"
+	ReasonMissingToken      = "missing_token"
+	ReasonInvalidToken      = "invalid_token"
+	ReasonTokenUserNotFound = "token_user_not_found"
+	ReasonInvalidApiKey     = "invalid_api_key"
+	ReasonInvalidCSRF       = "invalid_csrf_token"
+	ReasonSessionRevoked    = "session_revoked"
+)
+
+// Outcomes of the webhook delivery attempts.
+const (
+	WebhookDelivered = "delivered"
+	WebhookRetried   = "retried"
+	WebhookDead      = "dead"
+)
+
+// Results of the relay of an outbox event to a sink.
+const (
+	OutboxPublished = "published"
+	OutboxFailed    = "failed"
+)
+
+const (
+	OperationHash    = "hash"
+	OperationCompare = "compare"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by gin route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by gin route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "login_total",
+		Help:      "Number of login attempts by result and failure reason.",
+	}, []string{"result", "reason"})
+
+	TokenValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "token_validation_failures_total",
+		Help:      "Number of rejected authentication tokens by reason.",
+	}, []string{"reason"})
+
+	LegacyTokens = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "legacy_tokens_total",
+		Help:      "Number of accepted tokens identifying the user by email, zero once legacy tokens can be rejected.",
+	})
+
+	PasswordHashDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "password_hash_duration_seconds",
+		Help:      "Time spent hashing or comparing passwords.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2, 4},
+	}, []string{"operation"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Number of cache lookups by cache and result.",
+	}, []string{"cache", "result"})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "delivery_attempts_total",
+		Help:      "Number of webhook delivery attempts by outcome, dead ones ran out of attempts.",
+	}, []string{"result"})
+
+	OutboxEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_total",
+		Help:      "Number of events relayed from the outbox by sink and result.",
+	}, []string{"sink", "result"})
+
+	RepositoryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "operation_duration_seconds",
+		Help:      "UserRepo latency by method and resulting error code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+func LoginSucceeded() {
+	Logins.WithLabelValues(LoginSuccess, ReasonNone).Inc()
+}
+
+func LoginFailed(reason string) {
+	Logins.WithLabelValues(LoginFailure, reason).Inc()
+}
+
+func TokenRejected(reason string) {
+	TokenValidationFailures.WithLabelValues(reason).Inc()
+}
+
+func LegacyTokenAccepted() {
+	LegacyTokens.Inc()
+}
+
+func CacheHit(cache string) {
+	CacheRequests.WithLabelValues(cache, "hit").Inc()
+}
+
+func CacheMiss(cache string) {
+	CacheRequests.WithLabelValues(cache, "miss").Inc()
+}
+
+func WebhookAttempted(result string) {
+	WebhookDeliveries.WithLabelValues(result).Inc()
+}
+
+func OutboxRelayed(sink string, result string) {
+	OutboxEvents.WithLabelValues(sink, result).Inc()
+}
+
+// ObservePasswordHash records the time elapsed since start for the given operation.
+func ObservePasswordHash(operation string, start time.Time) {
+	PasswordHashDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
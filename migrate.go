@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"user-api/migrations"
+
+	"github.com/urfave/cli/v2"
+)
+
+func migrateCommand() *cli.Command {
+	runFlags := []cli.Flag{
+		&cli.BoolFlag{Name: "dry-run", Usage: "print the migrations that would run without running them"},
+		&cli.DurationFlag{Name: "wait", Usage: "wait this long for another instance to release the lock"},
+	}
+	up := &cli.Command{
+		Name:  "up",
+		Usage: "Apply the pending migrations",
+		Flags: append([]cli.Flag{
+			&cli.IntFlag{Name: "to", Usage: "stop at this version, every pending one when omitted"},
+		}, runFlags...),
+		Action: migrateUp,
+	}
+
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Apply, roll back or list the database migrations, up when no subcommand is given",
+		Subcommands: []*cli.Command{
+			up,
+			{
+				Name:  "down",
+				Usage: "Roll back the last applied migrations",
+				Flags: append([]cli.Flag{
+					&cli.IntFlag{Name: "steps", Value: 1, Usage: "migrations to roll back"},
+				}, runFlags...),
+				Action: migrateDown,
+			},
+			{
+				Name:   "status",
+				Usage:  "List the applied and pending migrations",
+				Action: migrateStatus,
+			},
+		},
+		Flags:  up.Flags,
+		Action: migrateUp,
+	}
+}
+
+func migrateUp(c *cli.Context) error {
+	opts := migrations.Options{DryRun: c.Bool("dry-run"), Wait: c.Duration("wait")}
+	return withApp(c, func(ctx context.Context, a *app) error {
+		ran, err := a.migrations.Up(ctx, c.Int("to"), opts)
+		printMigrations(ran, "up", opts.DryRun)
+		return err
+	})
+}
+
+func migrateDown(c *cli.Context) error {
+	opts := migrations.Options{DryRun: c.Bool("dry-run"), Wait: c.Duration("wait")}
+	return withApp(c, func(ctx context.Context, a *app) error {
+		ran, err := a.migrations.Down(ctx, c.Int("steps"), opts)
+		printMigrations(ran, "down", opts.DryRun)
+		return err
+	})
+}
+
+func migrateStatus(c *cli.Context) error {
+	return withApp(c, func(ctx context.Context, a *app) error {
+		status, err := a.migrations.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s  %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	})
+}
+
+func printMigrations(ran []migrations.Migration, direction string, dryRun bool) {
+	prefix := ""
+	if dryRun {
+		prefix = "would run "
+	}
+	for _, m := range ran {
+		fmt.Printf("%s%s %04d %s\n", prefix, direction, m.Version, m.Name)
+	}
+	if len(ran) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+}
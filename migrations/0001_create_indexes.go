@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexes are the indexes the repositories rely on, by collection. The
+// unique email index is what turns concurrent registrations and imports of
+// the same email into duplicate key errors.
+var indexes = map[string][]mongo.IndexModel{
+	"users": {
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetName("email_unique").SetUnique(true)},
+	},
+	"sessions": {
+		{Keys: bson.D{{Key: "family_id", Value: 1}}, Options: options.Index().SetName("family_id_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+	},
+	"api_keys": {
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetName("hash_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+	},
+}
+
+var indexedCollections = []string{"users", "sessions", "api_keys"}
+
+var createIndexes = Migration{
+	Version: 1,
+	Name:    "create_indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		for _, coll := range indexedCollections {
+			if _, err := db.Collection(coll).Indexes().CreateMany(ctx, indexes[coll]); err != nil {
+				return fmt.Errorf("creating indexes of %s: %w", coll, err)
+			}
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		for _, coll := range indexedCollections {
+			for _, idx := range indexes[coll] {
+				_, err := db.Collection(coll).Indexes().DropOne(ctx, *idx.Options.Name)
+				if err != nil && !isIndexNotFound(err) {
+					return fmt.Errorf("dropping index %s of %s: %w", *idx.Options.Name, coll, err)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// isIndexNotFound makes dropping an index idempotent.
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 27 || cmdErr.Name == "IndexNotFound"
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// backfillUserRole gives the users stored before roles existed the user
+// role, it cannot be undone as they are told apart from users created with
+// it afterwards.
+var backfillUserRole = Migration{
+	Version: 2,
+	Name:    "backfill_user_role",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		filter := bson.D{{Key: "role", Value: bson.D{{Key: "$in", Value: bson.A{nil, ""}}}}}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "role", Value: "user"}}}}
+		_, err := db.Collection("users").UpdateMany(ctx, filter, update)
+		return err
+	},
+}
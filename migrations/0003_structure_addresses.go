@@ -0,0 +1,94 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"user-api/postal"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// structureAddresses parses the legacy free-form address of the users
+// without structured ones into their default home address, on a best effort
+// basis. The legacy string is kept and the parsed address flagged legacy
+// until the user replaces it, rolling back pulls the flagged addresses.
+var structureAddresses = Migration{
+	Version: 3,
+	Name:    "structure_addresses",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		users := db.Collection("users")
+		filter := bson.D{
+			{Key: "address", Value: bson.D{{Key: "$nin", Value: bson.A{nil, ""}}}},
+			{Key: "addresses.0", Value: bson.D{{Key: "$exists", Value: false}}},
+		}
+		opts := options.Find().SetProjection(bson.D{{Key: "address", Value: 1}}).SetBatchSize(1000)
+		curr, err := users.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer curr.Close(ctx)
+
+		writes := make([]mongo.WriteModel, 0, 500)
+		flush := func() error {
+			if len(writes) == 0 {
+				return nil
+			}
+			_, err := users.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+			writes = writes[:0]
+			return err
+		}
+
+		for curr.Next(ctx) {
+			var doc struct {
+				ID      primitive.ObjectID `bson:"_id"`
+				Address string             `bson:"address"`
+			}
+			if err := curr.Decode(&doc); err != nil {
+				return fmt.Errorf("decoding user: %w", err)
+			}
+
+			f := postal.Parse(doc.Address)
+			address := bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "label", Value: "home"},
+				{Key: "line1", Value: f.Line1},
+				{Key: "line2", Value: f.Line2},
+				{Key: "city", Value: f.City},
+				{Key: "region", Value: f.Region},
+				{Key: "postal_code", Value: f.PostalCode},
+				{Key: "country", Value: f.Country},
+				{Key: "default", Value: true},
+				{Key: "legacy", Value: true},
+			}
+			// the filter keeps a user who added an address meanwhile untouched
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{{Key: "_id", Value: doc.ID}, {Key: "addresses.0", Value: bson.D{{Key: "$exists", Value: false}}}}).
+				SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "addresses", Value: bson.A{address}}}}}))
+			if len(writes) == cap(writes) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := curr.Err(); err != nil {
+			return err
+		}
+		return flush()
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		users := db.Collection("users")
+		filter := bson.D{{Key: "addresses.legacy", Value: true}}
+		update := bson.D{{Key: "$pull", Value: bson.D{{Key: "addresses", Value: bson.D{{Key: "legacy", Value: true}}}}}}
+		if _, err := users.UpdateMany(ctx, filter, update); err != nil {
+			return err
+		}
+		// users left without addresses go back to the legacy string only
+		_, err := users.UpdateMany(ctx,
+			bson.D{{Key: "addresses", Value: bson.D{{Key: "$size", Value: 0}}}},
+			bson.D{{Key: "$unset", Value: bson.D{{Key: "addresses", Value: ""}}}})
+		return err
+	},
+}
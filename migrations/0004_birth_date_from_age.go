@@ -0,0 +1,120 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"user-api/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// birthDateFromAge replaces the age stored by the users registered before
+// birth dates with a birth date estimated from it on the day they
+// registered, taken from their id. Rolling back stores again the age of
+// every user with a birth date, dropping only the estimated ones.
+var birthDateFromAge = Migration{
+	Version: 4,
+	Name:    "birth_date_from_age",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		users := db.Collection("users")
+		filter := bson.D{
+			{Key: "age", Value: bson.D{{Key: "$gt", Value: 0}}},
+			{Key: "birth_date", Value: bson.D{{Key: "$exists", Value: false}}},
+		}
+		opts := options.Find().SetProjection(bson.D{{Key: "age", Value: 1}}).SetBatchSize(1000)
+		curr, err := users.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer curr.Close(ctx)
+
+		writes := make([]mongo.WriteModel, 0, 500)
+		flush := func() error {
+			if len(writes) == 0 {
+				return nil
+			}
+			_, err := users.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+			writes = writes[:0]
+			return err
+		}
+
+		for curr.Next(ctx) {
+			var doc struct {
+				ID  primitive.ObjectID `bson:"_id"`
+				Age int                `bson:"age"`
+			}
+			if err := curr.Decode(&doc); err != nil {
+				return fmt.Errorf("decoding user: %w", err)
+			}
+
+			birthDate := models.EstimateBirthDate(doc.Age, doc.ID.Timestamp())
+			// the filter keeps a user who gave a birth date meanwhile untouched
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{{Key: "_id", Value: doc.ID}, {Key: "birth_date", Value: bson.D{{Key: "$exists", Value: false}}}}).
+				SetUpdate(bson.D{
+					{Key: "$set", Value: bson.D{{Key: "birth_date", Value: birthDate}, {Key: "birth_date_estimated", Value: true}}},
+					{Key: "$unset", Value: bson.D{{Key: "age", Value: ""}}},
+				}))
+			if len(writes) == cap(writes) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := curr.Err(); err != nil {
+			return err
+		}
+		return flush()
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		users := db.Collection("users")
+		filter := bson.D{{Key: "birth_date", Value: bson.D{{Key: "$exists", Value: true}}}}
+		opts := options.Find().SetProjection(bson.D{{Key: "birth_date", Value: 1}, {Key: "birth_date_estimated", Value: 1}}).SetBatchSize(1000)
+		curr, err := users.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer curr.Close(ctx)
+
+		writes := make([]mongo.WriteModel, 0, 500)
+		flush := func() error {
+			if len(writes) == 0 {
+				return nil
+			}
+			_, err := users.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+			writes = writes[:0]
+			return err
+		}
+
+		now := time.Now()
+		for curr.Next(ctx) {
+			var doc struct {
+				ID        primitive.ObjectID `bson:"_id"`
+				BirthDate time.Time          `bson:"birth_date"`
+				Estimated bool               `bson:"birth_date_estimated"`
+			}
+			if err := curr.Decode(&doc); err != nil {
+				return fmt.Errorf("decoding user: %w", err)
+			}
+
+			update := bson.D{{Key: "$set", Value: bson.D{{Key: "age", Value: models.AgeOn(doc.BirthDate, now)}}}}
+			if doc.Estimated {
+				update = append(update, bson.E{Key: "$unset", Value: bson.D{{Key: "birth_date", Value: ""}, {Key: "birth_date_estimated", Value: ""}}})
+			}
+			writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.D{{Key: "_id", Value: doc.ID}}).SetUpdate(update))
+			if len(writes) == cap(writes) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := curr.Err(); err != nil {
+			return err
+		}
+		return flush()
+	},
+}
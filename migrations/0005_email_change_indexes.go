@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// emailChangeIndexes look the changes up by link token and purge them once
+// their revert link expires, the last one to.
+var emailChangeIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "confirm_hash", Value: 1}}, Options: options.Index().SetName("confirm_hash_unique").SetUnique(true)},
+	{Keys: bson.D{{Key: "revert_hash", Value: 1}}, Options: options.Index().SetName("revert_hash_unique").SetUnique(true)},
+	{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+	{Keys: bson.D{{Key: "revert_by", Value: 1}}, Options: options.Index().SetName("revert_by_ttl").SetExpireAfterSeconds(0)},
+}
+
+var createEmailChangeIndexes = Migration{
+	Version: 5,
+	Name:    "email_change_indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		if _, err := db.Collection("email_changes").Indexes().CreateMany(ctx, emailChangeIndexes); err != nil {
+			return fmt.Errorf("creating indexes of email_changes: %w", err)
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		for _, idx := range emailChangeIndexes {
+			_, err := db.Collection("email_changes").Indexes().DropOne(ctx, *idx.Options.Name)
+			if err != nil && !isIndexNotFound(err) {
+				return fmt.Errorf("dropping index %s of email_changes: %w", *idx.Options.Name, err)
+			}
+		}
+		return nil
+	},
+}
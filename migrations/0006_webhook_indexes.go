@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deliveryRetention is how long the delivery log, dead letters included,
+// is kept.
+const deliveryRetention = 30 * 24 * 60 * 60
+
+// webhookIndexes find the subscribers of an event, the due deliveries of
+// the queue and the log of a webhook.
+var webhookIndexes = map[string][]mongo.IndexModel{
+	"webhooks": {
+		{Keys: bson.D{{Key: "active", Value: 1}, {Key: "events", Value: 1}}, Options: options.Index().SetName("active_events")},
+	},
+	"webhook_deliveries": {
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}, Options: options.Index().SetName("status_next_attempt_at")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "locked_until", Value: 1}}, Options: options.Index().SetName("status_locked_until")},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("webhook_id_created_at")},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(deliveryRetention)},
+	},
+}
+
+var createWebhookIndexes = Migration{
+	Version: 6,
+	Name:    "webhook_indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		for coll, indexes := range webhookIndexes {
+			if _, err := db.Collection(coll).Indexes().CreateMany(ctx, indexes); err != nil {
+				return fmt.Errorf("creating indexes of %s: %w", coll, err)
+			}
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		for coll, indexes := range webhookIndexes {
+			for _, idx := range indexes {
+				_, err := db.Collection(coll).Indexes().DropOne(ctx, *idx.Options.Name)
+				if err != nil && !isIndexNotFound(err) {
+					return fmt.Errorf("dropping index %s of %s: %w", *idx.Options.Name, coll, err)
+				}
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxRetention is how long published events are kept.
+const outboxRetention = 7 * 24 * 60 * 60
+
+// outboxIndexes keep a single event per sequence number of a user, find the
+// oldest unpublished event of every user and purge the published ones.
+var outboxIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetName("user_id_seq_unique").SetUnique(true)},
+	{
+		Keys:    bson.D{{Key: "published", Value: 1}, {Key: "user_id", Value: 1}, {Key: "seq", Value: 1}},
+		Options: options.Index().SetName("unpublished_user_id_seq").SetPartialFilterExpression(bson.D{{Key: "published", Value: false}}),
+	},
+	{Keys: bson.D{{Key: "published_at", Value: 1}}, Options: options.Index().SetName("published_at_ttl").SetExpireAfterSeconds(outboxRetention)},
+}
+
+var createOutboxIndexes = Migration{
+	Version: 7,
+	Name:    "outbox_indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		if _, err := db.Collection("outbox").Indexes().CreateMany(ctx, outboxIndexes); err != nil {
+			return fmt.Errorf("creating indexes of outbox: %w", err)
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		for _, idx := range outboxIndexes {
+			_, err := db.Collection("outbox").Indexes().DropOne(ctx, *idx.Options.Name)
+			if err != nil && !isIndexNotFound(err) {
+				return fmt.Errorf("dropping index %s of outbox: %w", *idx.Options.Name, err)
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deliveryOrderIndex finds the oldest open delivery of every user and
+// webhook, the one the dispatcher may claim.
+var deliveryOrderIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "status", Value: 1}, {Key: "webhook_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "seq", Value: 1}, {Key: "_id", Value: 1}},
+	Options: options.Index().SetName("status_webhook_id_user_id_seq"),
+}
+
+var createDeliveryOrderIndex = Migration{
+	Version: 8,
+	Name:    "webhook_delivery_order_index",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		if _, err := db.Collection("webhook_deliveries").Indexes().CreateOne(ctx, deliveryOrderIndex); err != nil {
+			return fmt.Errorf("creating index of webhook_deliveries: %w", err)
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("webhook_deliveries").Indexes().DropOne(ctx, *deliveryOrderIndex.Options.Name)
+		if err != nil && !isIndexNotFound(err) {
+			return fmt.Errorf("dropping index %s of webhook_deliveries: %w", *deliveryOrderIndex.Options.Name, err)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,16 @@
+package migrations
+
+// All is every migration of the api, a new one takes the next version and
+// released versions are never edited.
+func All() []Migration {
+	return []Migration{
+		createIndexes,
+		backfillUserRole,
+		structureAddresses,
+		birthDateFromAge,
+		createEmailChangeIndexes,
+		createWebhookIndexes,
+		createOutboxIndexes,
+		createDeliveryOrderIndex,
+	}
+}
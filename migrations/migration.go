@@ -0,0 +1,275 @@
+// Package migrations applies the versioned schema and data changes of the
+// database, recording the applied versions in the schema_migrations
+// collection.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DefaultLockTTL bounds how long a crashed instance keeps other instances
+// from migrating, the running instance renewing the lock every third of it.
+const DefaultLockTTL = 10 * time.Minute
+
+var (
+	ErrLocked       = errors.New("migrations locked by another instance")
+	ErrLockLost     = errors.New("migration lock lost to another instance")
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+)
+
+// Migration is one change of the database. Up must be idempotent so a
+// migration interrupted before being recorded can run again, Down undoes it
+// and may be nil when the change cannot be undone.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+}
+
+// Applied is a migration recorded in the store.
+type Applied struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Status is a known migration and when it was applied, nil while pending.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type Options struct {
+	// DryRun returns the migrations that would run without running them.
+	DryRun bool
+	// Wait keeps trying to take the lock for this long before returning
+	// ErrLocked.
+	Wait time.Duration
+}
+
+type Runner struct {
+	db         *mongo.Database
+	store      Store
+	migrations []Migration
+	owner      string
+	lockTTL    time.Duration
+	log        *slog.Logger
+}
+
+// NewRunner runs migrations, sorted by version, against db. owner names the
+// instance holding the lock in the store.
+func NewRunner(db *mongo.Database, store Store, migrations []Migration, owner string, logger *slog.Logger) *Runner {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Runner{
+		db:         db,
+		store:      store,
+		migrations: sorted,
+		owner:      owner,
+		lockTTL:    DefaultLockTTL,
+		log:        logger.With("component", "migrations"),
+	}
+}
+
+// Status lists every known migration in order, plus applied versions this
+// binary does not know, as when running an older release.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			at := a.AppliedAt
+			s.AppliedAt = &at
+			delete(applied, m.Version)
+		}
+		status = append(status, s)
+	}
+	for _, a := range applied {
+		at := a.AppliedAt
+		status = append(status, Status{Version: a.Version, Name: a.Name, AppliedAt: &at})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status, nil
+}
+
+// Up applies the pending migrations up to target, every one when target is
+// 0, and returns them in the order they ran.
+func (r *Runner) Up(ctx context.Context, target int, opts Options) ([]Migration, error) {
+	var ran []Migration
+	err := r.locked(ctx, opts, func(ctx context.Context) error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range r.migrations {
+			if target != 0 && m.Version > target {
+				break
+			}
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if opts.DryRun {
+				ran = append(ran, m)
+				continue
+			}
+
+			start := time.Now()
+			if err := m.Up(ctx, r.db); err != nil {
+				return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+			}
+			if err := r.renew(ctx); err != nil {
+				return fmt.Errorf("recording migration %d: %w", m.Version, err)
+			}
+			if err := r.store.Record(ctx, Applied{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}); err != nil {
+				return fmt.Errorf("recording migration %d: %w", m.Version, err)
+			}
+			r.log.InfoContext(ctx, "migration applied", "version", m.Version, "name", m.Name, "duration", time.Since(start))
+			ran = append(ran, m)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down rolls back the last steps applied migrations, newest first.
+func (r *Runner) Down(ctx context.Context, steps int, opts Options) ([]Migration, error) {
+	var ran []Migration
+	err := r.locked(ctx, opts, func(ctx context.Context) error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(r.migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == nil {
+				return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, ErrIrreversible)
+			}
+			if opts.DryRun {
+				ran = append(ran, m)
+				continue
+			}
+
+			if err := m.Down(ctx, r.db); err != nil {
+				return fmt.Errorf("rolling back migration %d %s: %w", m.Version, m.Name, err)
+			}
+			if err := r.renew(ctx); err != nil {
+				return fmt.Errorf("removing migration %d: %w", m.Version, err)
+			}
+			if err := r.store.Remove(ctx, m.Version); err != nil {
+				return fmt.Errorf("removing migration %d: %w", m.Version, err)
+			}
+			r.log.InfoContext(ctx, "migration rolled back", "version", m.Version, "name", m.Name)
+			ran = append(ran, m)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]Applied, error) {
+	list, err := r.store.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading applied migrations: %w", err)
+	}
+	applied := make(map[int]Applied, len(list))
+	for _, a := range list {
+		applied[a.Version] = a
+	}
+	return applied, nil
+}
+
+// locked runs fn holding the lock, dry runs only read and take none. The
+// lock is renewed while fn runs, its context being cancelled should another
+// instance take the lock meanwhile.
+func (r *Runner) locked(ctx context.Context, opts Options, fn func(ctx context.Context) error) error {
+	if opts.DryRun {
+		return fn(ctx)
+	}
+
+	deadline := time.Now().Add(opts.Wait)
+	for {
+		err := r.store.Lock(ctx, r.owner, r.lockTTL)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrLocked) || time.Now().After(deadline) {
+			return err
+		}
+		r.log.InfoContext(ctx, "waiting for the migration lock")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	defer func() {
+		if err := r.store.Unlock(context.WithoutCancel(ctx), r.owner); err != nil {
+			r.log.ErrorContext(ctx, "error releasing the migration lock", "error", err)
+		}
+	}()
+
+	// stopped before the unlock, which a late renewal would undo
+	runCtx, cancel := context.WithCancelCause(ctx)
+	var renewing sync.WaitGroup
+	defer func() {
+		cancel(nil)
+		renewing.Wait()
+	}()
+	renewing.Add(1)
+	go func() {
+		defer renewing.Done()
+		ticker := time.NewTicker(r.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				if err := r.renew(runCtx); err != nil {
+					if runCtx.Err() == nil {
+						r.log.ErrorContext(ctx, "error renewing the migration lock", "error", err)
+						cancel(err)
+					}
+					return
+				}
+			}
+		}
+	}()
+
+	err := fn(runCtx)
+	if cause := context.Cause(runCtx); err != nil && cause != nil && ctx.Err() == nil {
+		return fmt.Errorf("%w: %w", cause, err)
+	}
+	return err
+}
+
+// renew extends the lock, failing with ErrLockLost when another instance
+// took it after it expired.
+func (r *Runner) renew(ctx context.Context) error {
+	err := r.store.Lock(ctx, r.owner, r.lockTTL)
+	if errors.Is(err, ErrLocked) {
+		return ErrLockLost
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user-api/logging"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	applied map[int]Applied
+	owner   string
+	locks   int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{applied: make(map[int]Applied)}
+}
+
+func (s *memoryStore) Applied(ctx context.Context) ([]Applied, error) {
+	list := make([]Applied, 0, len(s.applied))
+	for _, a := range s.applied {
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+func (s *memoryStore) Record(ctx context.Context, a Applied) error {
+	s.applied[a.Version] = a
+	return nil
+}
+
+func (s *memoryStore) Remove(ctx context.Context, version int) error {
+	delete(s.applied, version)
+	return nil
+}
+
+func (s *memoryStore) Lock(ctx context.Context, owner string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locks++
+	if s.owner != "" && s.owner != owner {
+		return ErrLocked
+	}
+	s.owner = owner
+	return nil
+}
+
+func (s *memoryStore) Unlock(ctx context.Context, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owner == owner {
+		s.owner = ""
+	}
+	return nil
+}
+
+// recording returns migrations appending their version to log when they run.
+func recording(log *[]int, versions ...int) []Migration {
+	migrations := make([]Migration, 0, len(versions))
+	for _, v := range versions {
+		v := v
+		migrations = append(migrations, Migration{
+			Version: v,
+			Name:    "test",
+			Up:      func(context.Context, *mongo.Database) error { *log = append(*log, v); return nil },
+			Down:    func(context.Context, *mongo.Database) error { *log = append(*log, -v); return nil },
+		})
+	}
+	return migrations
+}
+
+func TestUpRunsPendingInOrder(t *testing.T) {
+	var ran []int
+	store := newMemoryStore()
+	store.applied[1] = Applied{Version: 1}
+	r := NewRunner(nil, store, recording(&ran, 3, 1, 2), "a", logging.Discard())
+
+	_, err := r.Up(context.Background(), 0, Options{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 3}, ran)
+	assert.Len(t, store.applied, 3)
+	assert.Empty(t, store.owner)
+}
+
+func TestUpStopsAtTarget(t *testing.T) {
+	var ran []int
+	r := NewRunner(nil, newMemoryStore(), recording(&ran, 1, 2, 3), "a", logging.Discard())
+
+	_, err := r.Up(context.Background(), 2, Options{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, ran)
+}
+
+func TestDryRunRunsNothing(t *testing.T) {
+	var ran []int
+	store := newMemoryStore()
+	r := NewRunner(nil, store, recording(&ran, 1, 2), "a", logging.Discard())
+
+	pending, err := r.Up(context.Background(), 0, Options{DryRun: true})
+
+	assert.Nil(t, err)
+	assert.Len(t, pending, 2)
+	assert.Empty(t, ran)
+	assert.Empty(t, store.applied)
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	store := newMemoryStore()
+	failing := Migration{Version: 1, Name: "failing", Up: func(context.Context, *mongo.Database) error { return errors.New("boom") }}
+	r := NewRunner(nil, store, []Migration{failing}, "a", logging.Discard())
+
+	_, err := r.Up(context.Background(), 0, Options{})
+
+	assert.NotNil(t, err)
+	assert.Empty(t, store.applied)
+	assert.Empty(t, store.owner)
+}
+
+func TestLockedByAnotherInstance(t *testing.T) {
+	var ran []int
+	store := newMemoryStore()
+	store.owner = "b"
+	r := NewRunner(nil, store, recording(&ran, 1), "a", logging.Discard())
+
+	_, err := r.Up(context.Background(), 0, Options{})
+
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.Empty(t, ran)
+}
+
+func TestLockRenewedWhileMigrating(t *testing.T) {
+	store := newMemoryStore()
+	slow := Migration{Version: 1, Name: "slow", Up: func(context.Context, *mongo.Database) error { time.Sleep(50 * time.Millisecond); return nil }}
+	r := NewRunner(nil, store, []Migration{slow}, "a", logging.Discard())
+	r.lockTTL = 15 * time.Millisecond
+
+	_, err := r.Up(context.Background(), 0, Options{})
+
+	assert.Nil(t, err)
+	assert.Len(t, store.applied, 1)
+	// taken, renewed at least once while running and before recording
+	assert.GreaterOrEqual(t, store.locks, 3)
+}
+
+func TestLostLockIsNotRecorded(t *testing.T) {
+	store := newMemoryStore()
+	// the lock expired and another instance took it meanwhile
+	stolen := Migration{Version: 1, Name: "stolen", Up: func(context.Context, *mongo.Database) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.owner = "b"
+		return nil
+	}}
+	r := NewRunner(nil, store, []Migration{stolen}, "a", logging.Discard())
+
+	_, err := r.Up(context.Background(), 0, Options{})
+
+	assert.ErrorIs(t, err, ErrLockLost)
+	assert.Empty(t, store.applied)
+	assert.Equal(t, "b", store.owner)
+}
+
+func TestDownRollsBackNewestFirst(t *testing.T) {
+	var ran []int
+	store := newMemoryStore()
+	r := NewRunner(nil, store, recording(&ran, 1, 2, 3), "a", logging.Discard())
+	_, err := r.Up(context.Background(), 0, Options{})
+	assert.Nil(t, err)
+	ran = nil
+
+	_, err = r.Down(context.Background(), 2, Options{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{-3, -2}, ran)
+	assert.Len(t, store.applied, 1)
+}
+
+func TestDownStopsAtIrreversible(t *testing.T) {
+	store := newMemoryStore()
+	store.applied[1] = Applied{Version: 1}
+	r := NewRunner(nil, store, []Migration{{Version: 1, Name: "backfill"}}, "a", logging.Discard())
+
+	_, err := r.Down(context.Background(), 1, Options{})
+
+	assert.ErrorIs(t, err, ErrIrreversible)
+	assert.Len(t, store.applied, 1)
+}
+
+func TestStatusListsUnknownApplied(t *testing.T) {
+	var ran []int
+	store := newMemoryStore()
+	store.applied[1] = Applied{Version: 1, Name: "test"}
+	store.applied[9] = Applied{Version: 9, Name: "newer"}
+	r := NewRunner(nil, store, recording(&ran, 1, 2), "a", logging.Discard())
+
+	status, err := r.Status(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 9}, []int{status[0].Version, status[1].Version, status[2].Version})
+	assert.NotNil(t, status[0].AppliedAt)
+	assert.Nil(t, status[1].AppliedAt)
+	assert.Equal(t, "newer", status[2].Name)
+}
+
+func TestAllVersionsAreUnique(t *testing.T) {
+	seen := make(map[int]bool)
+	for _, m := range All() {
+		assert.False(t, seen[m.Version], "version %d", m.Version)
+		assert.NotNil(t, m.Up, "version %d", m.Version)
+		seen[m.Version] = true
+	}
+}
@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store records the applied migrations and holds the lock making sure a
+// single instance migrates at a time.
+type Store interface {
+	Applied(ctx context.Context) ([]Applied, error)
+	Record(ctx context.Context, a Applied) error
+	Remove(ctx context.Context, version int) error
+	// Lock takes the lock for owner until ttl elapses, returning ErrLocked
+	// while another owner holds it.
+	Lock(ctx context.Context, owner string, ttl time.Duration) error
+	Unlock(ctx context.Context, owner string) error
+}
+
+const lockID = "lock"
+
+type mongoStore struct {
+	applied *mongo.Collection
+	lock    *mongo.Collection
+}
+
+// NewMongoStore keeps the applied versions in schema_migrations and the
+// lock in schema_migrations_lock of db.
+func NewMongoStore(db *mongo.Database) Store {
+	return mongoStore{
+		applied: db.Collection("schema_migrations"),
+		lock:    db.Collection("schema_migrations_lock"),
+	}
+}
+
+func (s mongoStore) Applied(ctx context.Context) ([]Applied, error) {
+	curr, err := s.applied.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	applied := make([]Applied, 0)
+	if err := curr.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (s mongoStore) Record(ctx context.Context, a Applied) error {
+	_, err := s.applied.ReplaceOne(ctx, bson.D{{Key: "_id", Value: a.Version}}, a, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s mongoStore) Remove(ctx context.Context, version int) error {
+	_, err := s.applied.DeleteOne(ctx, bson.D{{Key: "_id", Value: version}})
+	return err
+}
+
+// Lock upserts the lock document when it is missing, expired or already
+// ours. When another owner holds it the filter misses and the upsert fails
+// on the _id unique index.
+func (s mongoStore) Lock(ctx context.Context, owner string, ttl time.Duration) error {
+	now := time.Now().UTC()
+	filter := bson.D{
+		{Key: "_id", Value: lockID},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lt", Value: now}}}},
+			bson.D{{Key: "owner", Value: owner}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "owner", Value: owner},
+		{Key: "locked_at", Value: now},
+		{Key: "expires_at", Value: now.Add(ttl)},
+	}}}
+
+	_, err := s.lock.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	return err
+}
+
+func (s mongoStore) Unlock(ctx context.Context, owner string) error {
+	_, err := s.lock.DeleteOne(ctx, bson.D{{Key: "_id", Value: lockID}, {Key: "owner", Value: owner}})
+	return err
+}
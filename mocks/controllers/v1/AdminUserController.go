@@ -0,0 +1,60 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AdminUserController is an autogenerated mock type for the AdminUserController type
+type AdminUserController struct {
+	mock.Mock
+}
+
+// Export provides a mock function with given fields:
+func (_m *AdminUserController) Export() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Import provides a mock function with given fields:
+func (_m *AdminUserController) Import() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAdminUserController interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAdminUserController creates a new instance of AdminUserController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAdminUserController(t mockConstructorTestingTNewAdminUserController) *AdminUserController {
+	mock := &AdminUserController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
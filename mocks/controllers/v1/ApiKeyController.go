@@ -0,0 +1,76 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ApiKeyController is an autogenerated mock type for the ApiKeyController type
+type ApiKeyController struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields:
+func (_m *ApiKeyController) Create() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields:
+func (_m *ApiKeyController) List() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Revoke provides a mock function with given fields:
+func (_m *ApiKeyController) Revoke() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewApiKeyController interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewApiKeyController creates a new instance of ApiKeyController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewApiKeyController(t mockConstructorTestingTNewApiKeyController) *ApiKeyController {
+	mock := &ApiKeyController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,76 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AttributeController is an autogenerated mock type for the AttributeController type
+type AttributeController struct {
+	mock.Mock
+}
+
+// Schema provides a mock function with given fields:
+func (_m *AttributeController) Schema() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// SetSchema provides a mock function with given fields:
+func (_m *AttributeController) SetSchema() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// SetUserAttributes provides a mock function with given fields:
+func (_m *AttributeController) SetUserAttributes() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAttributeController interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAttributeController creates a new instance of AttributeController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAttributeController(t mockConstructorTestingTNewAttributeController) *AttributeController {
+	mock := &AttributeController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -28,6 +28,22 @@ func (_m *AuthController) Login() gin.HandlerFunc {
 	return r0
 }
 
+// Logout provides a mock function with given fields:
+func (_m *AuthController) Logout() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // Register provides a mock function with given fields:
 func (_m *AuthController) Register() gin.HandlerFunc {
 	ret := _m.Called()
@@ -44,6 +60,22 @@ func (_m *AuthController) Register() gin.HandlerFunc {
 	return r0
 }
 
+// RequireScope provides a mock function with given fields: scope
+func (_m *AuthController) RequireScope(scope string) gin.HandlerFunc {
+	ret := _m.Called(scope)
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func(string) gin.HandlerFunc); ok {
+		r0 = rf(scope)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // VerifyToken provides a mock function with given fields:
 func (_m *AuthController) VerifyToken() gin.HandlerFunc {
 	ret := _m.Called()
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AvatarController is an autogenerated mock type for the AvatarController type
+type AvatarController struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields:
+func (_m *AvatarController) Delete() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Put provides a mock function with given fields:
+func (_m *AvatarController) Put() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAvatarController interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAvatarController creates a new instance of AvatarController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAvatarController(t mockConstructorTestingTNewAvatarController) *AvatarController {
+	mock := &AvatarController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SessionController is an autogenerated mock type for the SessionController type
+type SessionController struct {
+	mock.Mock
+}
+
+// List provides a mock function with given fields:
+func (_m *SessionController) List() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Revoke provides a mock function with given fields:
+func (_m *SessionController) Revoke() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewSessionController interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewSessionController creates a new instance of SessionController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewSessionController(t mockConstructorTestingTNewSessionController) *SessionController {
+	mock := &SessionController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -12,6 +12,22 @@ type UserController struct {
 	mock.Mock
 }
 
+// ChangePassword provides a mock function with given fields:
+func (_m *UserController) ChangePassword() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields:
 func (_m *UserController) Delete() gin.HandlerFunc {
 	ret := _m.Called()
@@ -28,6 +44,22 @@ func (_m *UserController) Delete() gin.HandlerFunc {
 	return r0
 }
 
+// DeleteMe provides a mock function with given fields:
+func (_m *UserController) DeleteMe() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // GetAll provides a mock function with given fields:
 func (_m *UserController) GetAll() gin.HandlerFunc {
 	ret := _m.Called()
@@ -60,6 +92,38 @@ func (_m *UserController) GetById() gin.HandlerFunc {
 	return r0
 }
 
+// Me provides a mock function with given fields:
+func (_m *UserController) Me() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// PatchMe provides a mock function with given fields:
+func (_m *UserController) PatchMe() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields:
 func (_m *UserController) Update() gin.HandlerFunc {
 	ret := _m.Called()
@@ -76,6 +140,22 @@ func (_m *UserController) Update() gin.HandlerFunc {
 	return r0
 }
 
+// UpdateMe provides a mock function with given fields:
+func (_m *UserController) UpdateMe() gin.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 gin.HandlerFunc
+	if rf, ok := ret.Get(0).(func() gin.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gin.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewUserController interface {
 	mock.TestingT
 	Cleanup(func())
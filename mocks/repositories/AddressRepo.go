@@ -0,0 +1,104 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// AddressRepo is an autogenerated mock type for the AddressRepo type
+type AddressRepo struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, userID, a
+func (_m *AddressRepo) Add(ctx context.Context, userID string, a models.Address) (models.Address, response.ApiError) {
+	ret := _m.Called(ctx, userID, a)
+
+	var r0 models.Address
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Address) models.Address); ok {
+		r0 = rf(ctx, userID, a)
+	} else {
+		r0 = ret.Get(0).(models.Address)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Address) response.ApiError); ok {
+		r1 = rf(ctx, userID, a)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, userID, id
+func (_m *AddressRepo) Delete(ctx context.Context, userID string, id string) response.ApiError {
+	ret := _m.Called(ctx, userID, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields: ctx, userID
+func (_m *AddressRepo) List(ctx context.Context, userID string) ([]models.Address, response.ApiError) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.Address
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Address); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Address)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Replace provides a mock function with given fields: ctx, userID, a
+func (_m *AddressRepo) Replace(ctx context.Context, userID string, a models.Address) response.ApiError {
+	ret := _m.Called(ctx, userID, a)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Address) response.ApiError); ok {
+		r0 = rf(ctx, userID, a)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAddressRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAddressRepo creates a new instance of AddressRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAddressRepo(t mockConstructorTestingTNewAddressRepo) *AddressRepo {
+	mock := &AddressRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,129 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	response "user-api/response"
+
+	time "time"
+)
+
+// ApiKeyRepo is an autogenerated mock type for the ApiKeyRepo type
+type ApiKeyRepo struct {
+	mock.Mock
+}
+
+// FindByHash provides a mock function with given fields: ctx, hash
+func (_m *ApiKeyRepo) FindByHash(ctx context.Context, hash string) (models.ApiKey, response.ApiError) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 models.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.ApiKey); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		r0 = ret.Get(0).(models.ApiKey)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// ListByUser provides a mock function with given fields: ctx, userID
+func (_m *ApiKeyRepo) ListByUser(ctx context.Context, userID string) ([]models.ApiKey, response.ApiError) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.ApiKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ApiKey)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, userID, id, at
+func (_m *ApiKeyRepo) Revoke(ctx context.Context, userID string, id string, at time.Time) response.ApiError {
+	ret := _m.Called(ctx, userID, id, at)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, userID, id, at)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: ctx, k
+func (_m *ApiKeyRepo) Save(ctx context.Context, k models.ApiKey) (models.ApiKey, response.ApiError) {
+	ret := _m.Called(ctx, k)
+
+	var r0 models.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context, models.ApiKey) models.ApiKey); ok {
+		r0 = rf(ctx, k)
+	} else {
+		r0 = ret.Get(0).(models.ApiKey)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.ApiKey) response.ApiError); ok {
+		r1 = rf(ctx, k)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// TouchLastUsed provides a mock function with given fields: ctx, id, at
+func (_m *ApiKeyRepo) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewApiKeyRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewApiKeyRepo creates a new instance of ApiKeyRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewApiKeyRepo(t mockConstructorTestingTNewApiKeyRepo) *ApiKeyRepo {
+	mock := &ApiKeyRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
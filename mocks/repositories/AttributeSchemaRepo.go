@@ -0,0 +1,74 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// AttributeSchemaRepo is an autogenerated mock type for the AttributeSchemaRepo type
+type AttributeSchemaRepo struct {
+	mock.Mock
+}
+
+// Get provides a mock function with given fields: ctx
+func (_m *AttributeSchemaRepo) Get(ctx context.Context) (models.AttributeSchema, response.ApiError) {
+	ret := _m.Called(ctx)
+
+	var r0 models.AttributeSchema
+	if rf, ok := ret.Get(0).(func(context.Context) models.AttributeSchema); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(models.AttributeSchema)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context) response.ApiError); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, s
+func (_m *AttributeSchemaRepo) Save(ctx context.Context, s models.AttributeSchema) (models.AttributeSchema, response.ApiError) {
+	ret := _m.Called(ctx, s)
+
+	var r0 models.AttributeSchema
+	if rf, ok := ret.Get(0).(func(context.Context, models.AttributeSchema) models.AttributeSchema); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Get(0).(models.AttributeSchema)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.AttributeSchema) response.ApiError); ok {
+		r1 = rf(ctx, s)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewAttributeSchemaRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAttributeSchemaRepo creates a new instance of AttributeSchemaRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAttributeSchemaRepo(t mockConstructorTestingTNewAttributeSchemaRepo) *AttributeSchemaRepo {
+	mock := &AttributeSchemaRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
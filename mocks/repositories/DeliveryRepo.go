@@ -0,0 +1,150 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	response "user-api/response"
+
+	time "time"
+)
+
+// DeliveryRepo is an autogenerated mock type for the DeliveryRepo type
+type DeliveryRepo struct {
+	mock.Mock
+}
+
+// Claim provides a mock function with given fields: ctx, now, lease
+func (_m *DeliveryRepo) Claim(ctx context.Context, now time.Time, lease time.Duration) (models.WebhookDelivery, bool, response.ApiError) {
+	ret := _m.Called(ctx, now, lease)
+
+	var r0 models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration) models.WebhookDelivery); ok {
+		r0 = rf(ctx, now, lease)
+	} else {
+		r0 = ret.Get(0).(models.WebhookDelivery)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Duration) bool); ok {
+		r1 = rf(ctx, now, lease)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 response.ApiError
+	if rf, ok := ret.Get(2).(func(context.Context, time.Time, time.Duration) response.ApiError); ok {
+		r2 = rf(ctx, now, lease)
+	} else {
+		r2 = ret.Get(2).(response.ApiError)
+	}
+
+	return r0, r1, r2
+}
+
+// DeleteByWebhook provides a mock function with given fields: ctx, webhookID
+func (_m *DeliveryRepo) DeleteByWebhook(ctx context.Context, webhookID primitive.ObjectID) response.ApiError {
+	ret := _m.Called(ctx, webhookID)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) response.ApiError); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// FindById provides a mock function with given fields: ctx, webhookID, id
+func (_m *DeliveryRepo) FindById(ctx context.Context, webhookID primitive.ObjectID, id string) (models.WebhookDelivery, response.ApiError) {
+	ret := _m.Called(ctx, webhookID, id)
+
+	var r0 models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, string) models.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, id)
+	} else {
+		r0 = ret.Get(0).(models.WebhookDelivery)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, primitive.ObjectID, string) response.ApiError); ok {
+		r1 = rf(ctx, webhookID, id)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, webhookID, status, limit, page
+func (_m *DeliveryRepo) List(ctx context.Context, webhookID primitive.ObjectID, status string, limit uint64, page uint64) ([]models.WebhookDelivery, response.ApiError) {
+	ret := _m.Called(ctx, webhookID, status, limit, page)
+
+	var r0 []models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, string, uint64, uint64) []models.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, status, limit, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.WebhookDelivery)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, primitive.ObjectID, string, uint64, uint64) response.ApiError); ok {
+		r1 = rf(ctx, webhookID, status, limit, page)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Record provides a mock function with given fields: ctx, id, attempt, status, nextAttemptAt
+func (_m *DeliveryRepo) Record(ctx context.Context, id primitive.ObjectID, attempt models.Attempt, status string, nextAttemptAt time.Time) response.ApiError {
+	ret := _m.Called(ctx, id, attempt, status, nextAttemptAt)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, models.Attempt, string, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, id, attempt, status, nextAttemptAt)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// SaveMany provides a mock function with given fields: ctx, deliveries
+func (_m *DeliveryRepo) SaveMany(ctx context.Context, deliveries []models.WebhookDelivery) response.ApiError {
+	ret := _m.Called(ctx, deliveries)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, []models.WebhookDelivery) response.ApiError); ok {
+		r0 = rf(ctx, deliveries)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewDeliveryRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewDeliveryRepo creates a new instance of DeliveryRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewDeliveryRepo(t mockConstructorTestingTNewDeliveryRepo) *DeliveryRepo {
+	mock := &DeliveryRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
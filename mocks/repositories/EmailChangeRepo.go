@@ -0,0 +1,155 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	response "user-api/response"
+
+	time "time"
+)
+
+// EmailChangeRepo is an autogenerated mock type for the EmailChangeRepo type
+type EmailChangeRepo struct {
+	mock.Mock
+}
+
+// Confirm provides a mock function with given fields: ctx, confirmHash, at
+func (_m *EmailChangeRepo) Confirm(ctx context.Context, confirmHash string, at time.Time) (models.EmailChange, response.ApiError) {
+	ret := _m.Called(ctx, confirmHash, at)
+
+	var r0 models.EmailChange
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) models.EmailChange); ok {
+		r0 = rf(ctx, confirmHash, at)
+	} else {
+		r0 = ret.Get(0).(models.EmailChange)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) response.ApiError); ok {
+		r1 = rf(ctx, confirmHash, at)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *EmailChangeRepo) Delete(ctx context.Context, id primitive.ObjectID) response.ApiError {
+	ret := _m.Called(ctx, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) response.ApiError); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// DeletePending provides a mock function with given fields: ctx, userID
+func (_m *EmailChangeRepo) DeletePending(ctx context.Context, userID primitive.ObjectID) response.ApiError {
+	ret := _m.Called(ctx, userID)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) response.ApiError); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Revert provides a mock function with given fields: ctx, revertHash, at
+func (_m *EmailChangeRepo) Revert(ctx context.Context, revertHash string, at time.Time) (models.EmailChange, response.ApiError) {
+	ret := _m.Called(ctx, revertHash, at)
+
+	var r0 models.EmailChange
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) models.EmailChange); ok {
+		r0 = rf(ctx, revertHash, at)
+	} else {
+		r0 = ret.Get(0).(models.EmailChange)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) response.ApiError); ok {
+		r1 = rf(ctx, revertHash, at)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, c
+func (_m *EmailChangeRepo) Save(ctx context.Context, c models.EmailChange) (models.EmailChange, response.ApiError) {
+	ret := _m.Called(ctx, c)
+
+	var r0 models.EmailChange
+	if rf, ok := ret.Get(0).(func(context.Context, models.EmailChange) models.EmailChange); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Get(0).(models.EmailChange)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.EmailChange) response.ApiError); ok {
+		r1 = rf(ctx, c)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Unconfirm provides a mock function with given fields: ctx, id
+func (_m *EmailChangeRepo) Unconfirm(ctx context.Context, id primitive.ObjectID) response.ApiError {
+	ret := _m.Called(ctx, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) response.ApiError); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Unrevert provides a mock function with given fields: ctx, id
+func (_m *EmailChangeRepo) Unrevert(ctx context.Context, id primitive.ObjectID) response.ApiError {
+	ret := _m.Called(ctx, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) response.ApiError); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewEmailChangeRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewEmailChangeRepo creates a new instance of EmailChangeRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewEmailChangeRepo(t mockConstructorTestingTNewEmailChangeRepo) *EmailChangeRepo {
+	mock := &EmailChangeRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
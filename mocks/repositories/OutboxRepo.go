@@ -0,0 +1,157 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	response "user-api/response"
+
+	time "time"
+)
+
+// OutboxRepo is an autogenerated mock type for the OutboxRepo type
+type OutboxRepo struct {
+	mock.Mock
+}
+
+// Append provides a mock function with given fields: ctx, events
+func (_m *OutboxRepo) Append(ctx context.Context, events ...models.Event) response.ApiError {
+	_va := make([]interface{}, len(events))
+	for _i := range events {
+		_va[_i] = events[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, ...models.Event) response.ApiError); ok {
+		r0 = rf(ctx, events...)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Heads provides a mock function with given fields: ctx, now, limit
+func (_m *OutboxRepo) Heads(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, response.ApiError) {
+	ret := _m.Called(ctx, now, limit)
+
+	var r0 []models.OutboxEvent
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []models.OutboxEvent); ok {
+		r0 = rf(ctx, now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.OutboxEvent)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) response.ApiError); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Lease provides a mock function with given fields: ctx, owner, ttl
+func (_m *OutboxRepo) Lease(ctx context.Context, owner string, ttl time.Duration) (bool, response.ApiError) {
+	ret := _m.Called(ctx, owner, ttl)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) bool); ok {
+		r0 = rf(ctx, owner, ttl)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) response.ApiError); ok {
+		r1 = rf(ctx, owner, ttl)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// MarkFailed provides a mock function with given fields: ctx, id, lastError, nextAttemptAt
+func (_m *OutboxRepo) MarkFailed(ctx context.Context, id primitive.ObjectID, lastError string, nextAttemptAt time.Time) response.ApiError {
+	ret := _m.Called(ctx, id, lastError, nextAttemptAt)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, string, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, id, lastError, nextAttemptAt)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// MarkPublished provides a mock function with given fields: ctx, id, at
+func (_m *OutboxRepo) MarkPublished(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// MarkSent provides a mock function with given fields: ctx, id, sink
+func (_m *OutboxRepo) MarkSent(ctx context.Context, id primitive.ObjectID, sink string) response.ApiError {
+	ret := _m.Called(ctx, id, sink)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, string) response.ApiError); ok {
+		r0 = rf(ctx, id, sink)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Release provides a mock function with given fields: ctx, owner
+func (_m *OutboxRepo) Release(ctx context.Context, owner string) response.ApiError {
+	ret := _m.Called(ctx, owner)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.ApiError); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewOutboxRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewOutboxRepo creates a new instance of OutboxRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewOutboxRepo(t mockConstructorTestingTNewOutboxRepo) *OutboxRepo {
+	mock := &OutboxRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
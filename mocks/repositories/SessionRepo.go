@@ -0,0 +1,143 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	response "user-api/response"
+
+	time "time"
+)
+
+// SessionRepo is an autogenerated mock type for the SessionRepo type
+type SessionRepo struct {
+	mock.Mock
+}
+
+// FindByFamily provides a mock function with given fields: ctx, familyID
+func (_m *SessionRepo) FindByFamily(ctx context.Context, familyID string) (models.Session, response.ApiError) {
+	ret := _m.Called(ctx, familyID)
+
+	var r0 models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Session); ok {
+		r0 = rf(ctx, familyID)
+	} else {
+		r0 = ret.Get(0).(models.Session)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, familyID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// ListActive provides a mock function with given fields: ctx, userID, now
+func (_m *SessionRepo) ListActive(ctx context.Context, userID string, now time.Time) ([]models.Session, response.ApiError) {
+	ret := _m.Called(ctx, userID, now)
+
+	var r0 []models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) []models.Session); ok {
+		r0 = rf(ctx, userID, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Session)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) response.ApiError); ok {
+		r1 = rf(ctx, userID, now)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, userID, id, at
+func (_m *SessionRepo) Revoke(ctx context.Context, userID string, id string, at time.Time) response.ApiError {
+	ret := _m.Called(ctx, userID, id, at)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, userID, id, at)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// RevokeAllExcept provides a mock function with given fields: ctx, userID, keepFamilyID, at
+func (_m *SessionRepo) RevokeAllExcept(ctx context.Context, userID string, keepFamilyID string, at time.Time) response.ApiError {
+	ret := _m.Called(ctx, userID, keepFamilyID, at)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, userID, keepFamilyID, at)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: ctx, s
+func (_m *SessionRepo) Save(ctx context.Context, s models.Session) (models.Session, response.ApiError) {
+	ret := _m.Called(ctx, s)
+
+	var r0 models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, models.Session) models.Session); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Get(0).(models.Session)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.Session) response.ApiError); ok {
+		r1 = rf(ctx, s)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// TouchLastSeen provides a mock function with given fields: ctx, id, at
+func (_m *SessionRepo) TouchLastSeen(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewSessionRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewSessionRepo creates a new instance of SessionRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewSessionRepo(t mockConstructorTestingTNewSessionRepo) *SessionRepo {
+	mock := &SessionRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
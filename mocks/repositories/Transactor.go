@@ -0,0 +1,45 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// Transactor is an autogenerated mock type for the Transactor type
+type Transactor struct {
+	mock.Mock
+}
+
+// WithTransaction provides a mock function with given fields: ctx, fn
+func (_m *Transactor) WithTransaction(ctx context.Context, fn func(context.Context) response.ApiError) response.ApiError {
+	ret := _m.Called(ctx, fn)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) response.ApiError) response.ApiError); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewTransactor interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewTransactor creates a new instance of Transactor. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewTransactor(t mockConstructorTestingTNewTransactor) *Transactor {
+	mock := &Transactor{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,11 +3,14 @@
 package mocks
 
 import (
+	context "context"
 	models "user-api/models"
 
 	mock "github.com/stretchr/testify/mock"
 
 	response "user-api/response"
+
+	time "time"
 )
 
 // UserRepo is an autogenerated mock type for the UserRepo type
@@ -15,13 +18,13 @@ type UserRepo struct {
 	mock.Mock
 }
 
-// DeleteById provides a mock function with given fields: id
-func (_m *UserRepo) DeleteById(id string) response.ApiError {
-	ret := _m.Called(id)
+// DeleteById provides a mock function with given fields: ctx, id
+func (_m *UserRepo) DeleteById(ctx context.Context, id string) response.ApiError {
+	ret := _m.Called(ctx, id)
 
 	var r0 response.ApiError
-	if rf, ok := ret.Get(0).(func(string) response.ApiError); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.ApiError); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(response.ApiError)
 	}
@@ -29,20 +32,57 @@ func (_m *UserRepo) DeleteById(id string) response.ApiError {
 	return r0
 }
 
-// FindByField provides a mock function with given fields: value, key
-func (_m *UserRepo) FindByField(value interface{}, key string) (models.User, response.ApiError) {
-	ret := _m.Called(value, key)
+// Each provides a mock function with given fields: ctx, filter, fn
+func (_m *UserRepo) Each(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	ret := _m.Called(ctx, filter, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserFilter, func(models.User) error) error); ok {
+		r0 = rf(ctx, filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExistingEmails provides a mock function with given fields: ctx, emails
+func (_m *UserRepo) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, response.ApiError) {
+	ret := _m.Called(ctx, emails)
+
+	var r0 map[string]bool
+	if rf, ok := ret.Get(0).(func(context.Context, []string) map[string]bool); ok {
+		r0 = rf(ctx, emails)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]bool)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, []string) response.ApiError); ok {
+		r1 = rf(ctx, emails)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// FindByField provides a mock function with given fields: ctx, value, key
+func (_m *UserRepo) FindByField(ctx context.Context, value interface{}, key string) (models.User, response.ApiError) {
+	ret := _m.Called(ctx, value, key)
 
 	var r0 models.User
-	if rf, ok := ret.Get(0).(func(interface{}, string) models.User); ok {
-		r0 = rf(value, key)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, string) models.User); ok {
+		r0 = rf(ctx, value, key)
 	} else {
 		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 response.ApiError
-	if rf, ok := ret.Get(1).(func(interface{}, string) response.ApiError); ok {
-		r1 = rf(value, key)
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, string) response.ApiError); ok {
+		r1 = rf(ctx, value, key)
 	} else {
 		r1 = ret.Get(1).(response.ApiError)
 	}
@@ -50,20 +90,20 @@ func (_m *UserRepo) FindByField(value interface{}, key string) (models.User, res
 	return r0, r1
 }
 
-// FindById provides a mock function with given fields: id
-func (_m *UserRepo) FindById(id string) (models.User, response.ApiError) {
-	ret := _m.Called(id)
+// FindById provides a mock function with given fields: ctx, id
+func (_m *UserRepo) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
+	ret := _m.Called(ctx, id)
 
 	var r0 models.User
-	if rf, ok := ret.Get(0).(func(string) models.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.User); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 response.ApiError
-	if rf, ok := ret.Get(1).(func(string) response.ApiError); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Get(1).(response.ApiError)
 	}
@@ -71,13 +111,13 @@ func (_m *UserRepo) FindById(id string) (models.User, response.ApiError) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields: limit, page
-func (_m *UserRepo) GetAll(limit uint64, page uint64) ([]models.User, error) {
-	ret := _m.Called(limit, page)
+// GetAll provides a mock function with given fields: ctx, filter, limit, page
+func (_m *UserRepo) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, error) {
+	ret := _m.Called(ctx, filter, limit, page)
 
 	var r0 []models.User
-	if rf, ok := ret.Get(0).(func(uint64, uint64) []models.User); ok {
-		r0 = rf(limit, page)
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserFilter, uint64, uint64) []models.User); ok {
+		r0 = rf(ctx, filter, limit, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.User)
@@ -85,8 +125,8 @@ func (_m *UserRepo) GetAll(limit uint64, page uint64) ([]models.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint64, uint64) error); ok {
-		r1 = rf(limit, page)
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserFilter, uint64, uint64) error); ok {
+		r1 = rf(ctx, filter, limit, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -94,13 +134,143 @@ func (_m *UserRepo) GetAll(limit uint64, page uint64) ([]models.User, error) {
 	return r0, r1
 }
 
-// Save provides a mock function with given fields: u
-func (_m *UserRepo) Save(u models.User) response.ApiError {
-	ret := _m.Called(u)
+// PasswordHash provides a mock function with given fields: ctx, id
+func (_m *UserRepo) PasswordHash(ctx context.Context, id string) (string, response.ApiError) {
+	ret := _m.Called(ctx, id)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// RevokeTokens provides a mock function with given fields: ctx, id, at
+func (_m *UserRepo) RevokeTokens(ctx context.Context, id string, at time.Time) response.ApiError {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) response.ApiError); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: ctx, u
+func (_m *UserRepo) Save(ctx context.Context, u models.User) response.ApiError {
+	ret := _m.Called(ctx, u)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, models.User) response.ApiError); ok {
+		r0 = rf(ctx, u)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// SaveMany provides a mock function with given fields: ctx, users
+func (_m *UserRepo) SaveMany(ctx context.Context, users []models.User) []response.ApiError {
+	ret := _m.Called(ctx, users)
+
+	var r0 []response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, []models.User) []response.ApiError); ok {
+		r0 = rf(ctx, users)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]response.ApiError)
+		}
+	}
+
+	return r0
+}
+
+// UpdateAttributes provides a mock function with given fields: ctx, id, attrs
+func (_m *UserRepo) UpdateAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	ret := _m.Called(ctx, id, attrs)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) response.ApiError); ok {
+		r0 = rf(ctx, id, attrs)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// UpdateAvatar provides a mock function with given fields: ctx, id, avatar
+func (_m *UserRepo) UpdateAvatar(ctx context.Context, id string, avatar *models.Avatar) (*models.Avatar, response.ApiError) {
+	ret := _m.Called(ctx, id, avatar)
+
+	var r0 *models.Avatar
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.Avatar) *models.Avatar); ok {
+		r0 = rf(ctx, id, avatar)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Avatar)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, *models.Avatar) response.ApiError); ok {
+		r1 = rf(ctx, id, avatar)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// UpdateByID provides a mock function with given fields: ctx, id, u
+func (_m *UserRepo) UpdateByID(ctx context.Context, id string, u models.User) response.ApiError {
+	ret := _m.Called(ctx, id, u)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.User) response.ApiError); ok {
+		r0 = rf(ctx, id, u)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// UpdateEmail provides a mock function with given fields: ctx, id, from, to
+func (_m *UserRepo) UpdateEmail(ctx context.Context, id string, from string, to string) response.ApiError {
+	ret := _m.Called(ctx, id, from, to)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) response.ApiError); ok {
+		r0 = rf(ctx, id, from, to)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, id, hash
+func (_m *UserRepo) UpdatePassword(ctx context.Context, id string, hash string) response.ApiError {
+	ret := _m.Called(ctx, id, hash)
 
 	var r0 response.ApiError
-	if rf, ok := ret.Get(0).(func(models.User) response.ApiError); ok {
-		r0 = rf(u)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, id, hash)
 	} else {
 		r0 = ret.Get(0).(response.ApiError)
 	}
@@ -108,13 +278,13 @@ func (_m *UserRepo) Save(u models.User) response.ApiError {
 	return r0
 }
 
-// UpdateByID provides a mock function with given fields: id, u
-func (_m *UserRepo) UpdateByID(id string, u models.User) response.ApiError {
-	ret := _m.Called(id, u)
+// UpdateRole provides a mock function with given fields: ctx, id, role
+func (_m *UserRepo) UpdateRole(ctx context.Context, id string, role string) response.ApiError {
+	ret := _m.Called(ctx, id, role)
 
 	var r0 response.ApiError
-	if rf, ok := ret.Get(0).(func(string, models.User) response.ApiError); ok {
-		r0 = rf(id, u)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, id, role)
 	} else {
 		r0 = ret.Get(0).(response.ApiError)
 	}
@@ -0,0 +1,148 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// WebhookRepo is an autogenerated mock type for the WebhookRepo type
+type WebhookRepo struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *WebhookRepo) Delete(ctx context.Context, id string) response.ApiError {
+	ret := _m.Called(ctx, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.ApiError); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// FindById provides a mock function with given fields: ctx, id
+func (_m *WebhookRepo) FindById(ctx context.Context, id string) (models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx, id)
+
+	var r0 models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Webhook); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *WebhookRepo) List(ctx context.Context) ([]models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Webhook)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context) response.ApiError); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, w
+func (_m *WebhookRepo) Save(ctx context.Context, w models.Webhook) (models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx, w)
+
+	var r0 models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context, models.Webhook) models.Webhook); ok {
+		r0 = rf(ctx, w)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.Webhook) response.ApiError); ok {
+		r1 = rf(ctx, w)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Subscribed provides a mock function with given fields: ctx, eventType
+func (_m *WebhookRepo) Subscribed(ctx context.Context, eventType string) ([]models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx, eventType)
+
+	var r0 []models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Webhook); ok {
+		r0 = rf(ctx, eventType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Webhook)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, eventType)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, w
+func (_m *WebhookRepo) Update(ctx context.Context, w models.Webhook) response.ApiError {
+	ret := _m.Called(ctx, w)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, models.Webhook) response.ApiError); ok {
+		r0 = rf(ctx, w)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewWebhookRepo interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewWebhookRepo creates a new instance of WebhookRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewWebhookRepo(t mockConstructorTestingTNewWebhookRepo) *WebhookRepo {
+	mock := &WebhookRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,132 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// AddressService is an autogenerated mock type for the AddressService type
+type AddressService struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, userID, a, makeDefault
+func (_m *AddressService) Create(ctx context.Context, userID string, a models.Address, makeDefault bool) (models.Address, response.ApiError) {
+	ret := _m.Called(ctx, userID, a, makeDefault)
+
+	var r0 models.Address
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Address, bool) models.Address); ok {
+		r0 = rf(ctx, userID, a, makeDefault)
+	} else {
+		r0 = ret.Get(0).(models.Address)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Address, bool) response.ApiError); ok {
+		r1 = rf(ctx, userID, a, makeDefault)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, userID, id
+func (_m *AddressService) Delete(ctx context.Context, userID string, id string) response.ApiError {
+	ret := _m.Called(ctx, userID, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: ctx, userID, id
+func (_m *AddressService) Get(ctx context.Context, userID string, id string) (models.Address, response.ApiError) {
+	ret := _m.Called(ctx, userID, id)
+
+	var r0 models.Address
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.Address); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Get(0).(models.Address)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) response.ApiError); ok {
+		r1 = rf(ctx, userID, id)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, userID
+func (_m *AddressService) List(ctx context.Context, userID string) ([]models.Address, response.ApiError) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.Address
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Address); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Address)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, userID, a, makeDefault
+func (_m *AddressService) Update(ctx context.Context, userID string, a models.Address, makeDefault bool) (models.Address, response.ApiError) {
+	ret := _m.Called(ctx, userID, a, makeDefault)
+
+	var r0 models.Address
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Address, bool) models.Address); ok {
+		r0 = rf(ctx, userID, a, makeDefault)
+	} else {
+		r0 = ret.Get(0).(models.Address)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Address, bool) response.ApiError); ok {
+		r1 = rf(ctx, userID, a, makeDefault)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewAddressService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAddressService creates a new instance of AddressService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAddressService(t mockConstructorTestingTNewAddressService) *AddressService {
+	mock := &AddressService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,120 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+
+	time "time"
+)
+
+// ApiKeyService is an autogenerated mock type for the ApiKeyService type
+type ApiKeyService struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: ctx, key
+func (_m *ApiKeyService) Authenticate(ctx context.Context, key string) (models.ApiKey, response.ApiError) {
+	ret := _m.Called(ctx, key)
+
+	var r0 models.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.ApiKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(models.ApiKey)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, owner, name, scopes, ttl
+func (_m *ApiKeyService) Create(ctx context.Context, owner models.User, name string, scopes []string, ttl time.Duration) (string, models.ApiKey, response.ApiError) {
+	ret := _m.Called(ctx, owner, name, scopes, ttl)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, models.User, string, []string, time.Duration) string); ok {
+		r0 = rf(ctx, owner, name, scopes, ttl)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 models.ApiKey
+	if rf, ok := ret.Get(1).(func(context.Context, models.User, string, []string, time.Duration) models.ApiKey); ok {
+		r1 = rf(ctx, owner, name, scopes, ttl)
+	} else {
+		r1 = ret.Get(1).(models.ApiKey)
+	}
+
+	var r2 response.ApiError
+	if rf, ok := ret.Get(2).(func(context.Context, models.User, string, []string, time.Duration) response.ApiError); ok {
+		r2 = rf(ctx, owner, name, scopes, ttl)
+	} else {
+		r2 = ret.Get(2).(response.ApiError)
+	}
+
+	return r0, r1, r2
+}
+
+// List provides a mock function with given fields: ctx, userID
+func (_m *ApiKeyService) List(ctx context.Context, userID string) ([]models.ApiKey, response.ApiError) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.ApiKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ApiKey)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, userID, id
+func (_m *ApiKeyService) Revoke(ctx context.Context, userID string, id string) response.ApiError {
+	ret := _m.Called(ctx, userID, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewApiKeyService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewApiKeyService creates a new instance of ApiKeyService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewApiKeyService(t mockConstructorTestingTNewApiKeyService) *ApiKeyService {
+	mock := &ApiKeyService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,125 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// AttributeService is an autogenerated mock type for the AttributeService type
+type AttributeService struct {
+	mock.Mock
+}
+
+// ParseFilter provides a mock function with given fields: ctx, query
+func (_m *AttributeService) ParseFilter(ctx context.Context, query map[string]string) (map[string]interface{}, response.ApiError) {
+	ret := _m.Called(ctx, query)
+
+	var r0 map[string]interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, map[string]string) map[string]interface{}); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]interface{})
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, map[string]string) response.ApiError); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Schema provides a mock function with given fields: ctx
+func (_m *AttributeService) Schema(ctx context.Context) (models.AttributeSchema, response.ApiError) {
+	ret := _m.Called(ctx)
+
+	var r0 models.AttributeSchema
+	if rf, ok := ret.Get(0).(func(context.Context) models.AttributeSchema); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(models.AttributeSchema)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context) response.ApiError); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// SetSchema provides a mock function with given fields: ctx, raw
+func (_m *AttributeService) SetSchema(ctx context.Context, raw []byte) (models.AttributeSchema, response.ApiError) {
+	ret := _m.Called(ctx, raw)
+
+	var r0 models.AttributeSchema
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) models.AttributeSchema); ok {
+		r0 = rf(ctx, raw)
+	} else {
+		r0 = ret.Get(0).(models.AttributeSchema)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) response.ApiError); ok {
+		r1 = rf(ctx, raw)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Validate provides a mock function with given fields: ctx, attrs
+func (_m *AttributeService) Validate(ctx context.Context, attrs map[string]interface{}) response.ApiError {
+	ret := _m.Called(ctx, attrs)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, map[string]interface{}) response.ApiError); ok {
+		r0 = rf(ctx, attrs)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// ValidateChange provides a mock function with given fields: ctx, before, after
+func (_m *AttributeService) ValidateChange(ctx context.Context, before map[string]interface{}, after map[string]interface{}) response.ApiError {
+	ret := _m.Called(ctx, before, after)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, map[string]interface{}, map[string]interface{}) response.ApiError); ok {
+		r0 = rf(ctx, before, after)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAttributeService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAttributeService creates a new instance of AttributeService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAttributeService(t mockConstructorTestingTNewAttributeService) *AttributeService {
+	mock := &AttributeService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,72 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// AvatarService is an autogenerated mock type for the AvatarService type
+type AvatarService struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *AvatarService) Delete(ctx context.Context, id string) response.ApiError {
+	ret := _m.Called(ctx, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.ApiError); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Remove provides a mock function with given fields: ctx, avatar
+func (_m *AvatarService) Remove(ctx context.Context, avatar models.Avatar) {
+	_m.Called(ctx, avatar)
+}
+
+// Set provides a mock function with given fields: ctx, id, data
+func (_m *AvatarService) Set(ctx context.Context, id string, data []byte) (models.Avatar, response.ApiError) {
+	ret := _m.Called(ctx, id, data)
+
+	var r0 models.Avatar
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) models.Avatar); ok {
+		r0 = rf(ctx, id, data)
+	} else {
+		r0 = ret.Get(0).(models.Avatar)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) response.ApiError); ok {
+		r1 = rf(ctx, id, data)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewAvatarService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAvatarService creates a new instance of AvatarService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAvatarService(t mockConstructorTestingTNewAvatarService) *AvatarService {
+	mock := &AvatarService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,88 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// EmailChangeService is an autogenerated mock type for the EmailChangeService type
+type EmailChangeService struct {
+	mock.Mock
+}
+
+// Confirm provides a mock function with given fields: ctx, token
+func (_m *EmailChangeService) Confirm(ctx context.Context, token string) (models.EmailChange, response.ApiError) {
+	ret := _m.Called(ctx, token)
+
+	var r0 models.EmailChange
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.EmailChange); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(models.EmailChange)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Request provides a mock function with given fields: ctx, u, password, email
+func (_m *EmailChangeService) Request(ctx context.Context, u models.User, password string, email string) response.ApiError {
+	ret := _m.Called(ctx, u, password, email)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, models.User, string, string) response.ApiError); ok {
+		r0 = rf(ctx, u, password, email)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Revert provides a mock function with given fields: ctx, token
+func (_m *EmailChangeService) Revert(ctx context.Context, token string) (models.EmailChange, response.ApiError) {
+	ret := _m.Called(ctx, token)
+
+	var r0 models.EmailChange
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.EmailChange); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(models.EmailChange)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewEmailChangeService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewEmailChangeService creates a new instance of EmailChangeService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewEmailChangeService(t mockConstructorTestingTNewEmailChangeService) *EmailChangeService {
+	mock := &EmailChangeService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,125 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// SessionService is an autogenerated mock type for the SessionService type
+type SessionService struct {
+	mock.Mock
+}
+
+// List provides a mock function with given fields: ctx, userID
+func (_m *SessionService) List(ctx context.Context, userID string) ([]models.Session, response.ApiError) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Session); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Session)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, userID, id
+func (_m *SessionService) Revoke(ctx context.Context, userID string, id string) response.ApiError {
+	ret := _m.Called(ctx, userID, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// RevokeOthers provides a mock function with given fields: ctx, userID, keepFamilyID
+func (_m *SessionService) RevokeOthers(ctx context.Context, userID string, keepFamilyID string) response.ApiError {
+	ret := _m.Called(ctx, userID, keepFamilyID)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, userID, keepFamilyID)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Start provides a mock function with given fields: ctx, u, client
+func (_m *SessionService) Start(ctx context.Context, u models.User, client models.SessionClient) (models.Session, response.ApiError) {
+	ret := _m.Called(ctx, u, client)
+
+	var r0 models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, models.User, models.SessionClient) models.Session); ok {
+		r0 = rf(ctx, u, client)
+	} else {
+		r0 = ret.Get(0).(models.Session)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.User, models.SessionClient) response.ApiError); ok {
+		r1 = rf(ctx, u, client)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Verify provides a mock function with given fields: ctx, familyID
+func (_m *SessionService) Verify(ctx context.Context, familyID string) (models.Session, response.ApiError) {
+	ret := _m.Called(ctx, familyID)
+
+	var r0 models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Session); ok {
+		r0 = rf(ctx, familyID)
+	} else {
+		r0 = ret.Get(0).(models.Session)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, familyID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewSessionService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewSessionService creates a new instance of SessionService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewSessionService(t mockConstructorTestingTNewSessionService) *SessionService {
+	mock := &SessionService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
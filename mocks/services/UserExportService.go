@@ -0,0 +1,55 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+
+	userio "user-api/userio"
+)
+
+// UserExportService is an autogenerated mock type for the UserExportService type
+type UserExportService struct {
+	mock.Mock
+}
+
+// Export provides a mock function with given fields: ctx, filter, w
+func (_m *UserExportService) Export(ctx context.Context, filter models.UserFilter, w userio.UserWriter) (int, response.ApiError) {
+	ret := _m.Called(ctx, filter, w)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserFilter, userio.UserWriter) int); ok {
+		r0 = rf(ctx, filter, w)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserFilter, userio.UserWriter) response.ApiError); ok {
+		r1 = rf(ctx, filter, w)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewUserExportService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewUserExportService creates a new instance of UserExportService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewUserExportService(t mockConstructorTestingTNewUserExportService) *UserExportService {
+	mock := &UserExportService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,57 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	dto "user-api/dto"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+
+	services "user-api/services"
+
+	userio "user-api/userio"
+)
+
+// UserImportService is an autogenerated mock type for the UserImportService type
+type UserImportService struct {
+	mock.Mock
+}
+
+// Import provides a mock function with given fields: ctx, rows, opts
+func (_m *UserImportService) Import(ctx context.Context, rows userio.RowReader, opts services.ImportOptions) (dto.ImportReport, response.ApiError) {
+	ret := _m.Called(ctx, rows, opts)
+
+	var r0 dto.ImportReport
+	if rf, ok := ret.Get(0).(func(context.Context, userio.RowReader, services.ImportOptions) dto.ImportReport); ok {
+		r0 = rf(ctx, rows, opts)
+	} else {
+		r0 = ret.Get(0).(dto.ImportReport)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, userio.RowReader, services.ImportOptions) response.ApiError); ok {
+		r1 = rf(ctx, rows, opts)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewUserImportService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewUserImportService creates a new instance of UserImportService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewUserImportService(t mockConstructorTestingTNewUserImportService) *UserImportService {
+	mock := &UserImportService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	models "user-api/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -15,13 +16,13 @@ type UserService struct {
 	mock.Mock
 }
 
-// DeleteById provides a mock function with given fields: id
-func (_m *UserService) DeleteById(id string) response.ApiError {
-	ret := _m.Called(id)
+// ChangePassword provides a mock function with given fields: ctx, u, current, password, keepSessionID
+func (_m *UserService) ChangePassword(ctx context.Context, u models.User, current string, password string, keepSessionID string) response.ApiError {
+	ret := _m.Called(ctx, u, current, password, keepSessionID)
 
 	var r0 response.ApiError
-	if rf, ok := ret.Get(0).(func(string) response.ApiError); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, models.User, string, string, string) response.ApiError); ok {
+		r0 = rf(ctx, u, current, password, keepSessionID)
 	} else {
 		r0 = ret.Get(0).(response.ApiError)
 	}
@@ -29,20 +30,34 @@ func (_m *UserService) DeleteById(id string) response.ApiError {
 	return r0
 }
 
-// FindByEmail provides a mock function with given fields: email
-func (_m *UserService) FindByEmail(email string) (models.User, response.ApiError) {
-	ret := _m.Called(email)
+// DeleteById provides a mock function with given fields: ctx, id
+func (_m *UserService) DeleteById(ctx context.Context, id string) response.ApiError {
+	ret := _m.Called(ctx, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.ApiError); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// FindByEmail provides a mock function with given fields: ctx, email
+func (_m *UserService) FindByEmail(ctx context.Context, email string) (models.User, response.ApiError) {
+	ret := _m.Called(ctx, email)
 
 	var r0 models.User
-	if rf, ok := ret.Get(0).(func(string) models.User); ok {
-		r0 = rf(email)
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.User); ok {
+		r0 = rf(ctx, email)
 	} else {
 		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 response.ApiError
-	if rf, ok := ret.Get(1).(func(string) response.ApiError); ok {
-		r1 = rf(email)
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, email)
 	} else {
 		r1 = ret.Get(1).(response.ApiError)
 	}
@@ -50,20 +65,20 @@ func (_m *UserService) FindByEmail(email string) (models.User, response.ApiError
 	return r0, r1
 }
 
-// FindById provides a mock function with given fields: id
-func (_m *UserService) FindById(id string) (models.User, response.ApiError) {
-	ret := _m.Called(id)
+// FindById provides a mock function with given fields: ctx, id
+func (_m *UserService) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
+	ret := _m.Called(ctx, id)
 
 	var r0 models.User
-	if rf, ok := ret.Get(0).(func(string) models.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.User); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 response.ApiError
-	if rf, ok := ret.Get(1).(func(string) response.ApiError); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Get(1).(response.ApiError)
 	}
@@ -71,13 +86,13 @@ func (_m *UserService) FindById(id string) (models.User, response.ApiError) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields: _a0, _a1
-func (_m *UserService) GetAll(_a0 uint64, _a1 uint64) ([]models.User, response.ApiError) {
-	ret := _m.Called(_a0, _a1)
+// GetAll provides a mock function with given fields: ctx, filter, limit, page
+func (_m *UserService) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, response.ApiError) {
+	ret := _m.Called(ctx, filter, limit, page)
 
 	var r0 []models.User
-	if rf, ok := ret.Get(0).(func(uint64, uint64) []models.User); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserFilter, uint64, uint64) []models.User); ok {
+		r0 = rf(ctx, filter, limit, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.User)
@@ -85,8 +100,29 @@ func (_m *UserService) GetAll(_a0 uint64, _a1 uint64) ([]models.User, response.A
 	}
 
 	var r1 response.ApiError
-	if rf, ok := ret.Get(1).(func(uint64, uint64) response.ApiError); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserFilter, uint64, uint64) response.ApiError); ok {
+		r1 = rf(ctx, filter, limit, page)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// IssueToken provides a mock function with given fields: ctx, u, client
+func (_m *UserService) IssueToken(ctx context.Context, u models.User, client models.SessionClient) (string, response.ApiError) {
+	ret := _m.Called(ctx, u, client)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, models.User, models.SessionClient) string); ok {
+		r0 = rf(ctx, u, client)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.User, models.SessionClient) response.ApiError); ok {
+		r1 = rf(ctx, u, client)
 	} else {
 		r1 = ret.Get(1).(response.ApiError)
 	}
@@ -94,20 +130,20 @@ func (_m *UserService) GetAll(_a0 uint64, _a1 uint64) ([]models.User, response.A
 	return r0, r1
 }
 
-// Login provides a mock function with given fields: email, password
-func (_m *UserService) Login(email string, password string) (string, response.ApiError) {
-	ret := _m.Called(email, password)
+// Login provides a mock function with given fields: ctx, email, password, client
+func (_m *UserService) Login(ctx context.Context, email string, password string, client models.SessionClient) (string, response.ApiError) {
+	ret := _m.Called(ctx, email, password, client)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(string, string) string); ok {
-		r0 = rf(email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, models.SessionClient) string); ok {
+		r0 = rf(ctx, email, password, client)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 response.ApiError
-	if rf, ok := ret.Get(1).(func(string, string) response.ApiError); ok {
-		r1 = rf(email, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, models.SessionClient) response.ApiError); ok {
+		r1 = rf(ctx, email, password, client)
 	} else {
 		r1 = ret.Get(1).(response.ApiError)
 	}
@@ -115,13 +151,62 @@ func (_m *UserService) Login(email string, password string) (string, response.Ap
 	return r0, r1
 }
 
-// Register provides a mock function with given fields: _a0
-func (_m *UserService) Register(_a0 models.User) response.ApiError {
-	ret := _m.Called(_a0)
+// Register provides a mock function with given fields: ctx, u
+func (_m *UserService) Register(ctx context.Context, u models.User) (models.User, response.ApiError) {
+	ret := _m.Called(ctx, u)
+
+	var r0 models.User
+	if rf, ok := ret.Get(0).(func(context.Context, models.User) models.User); ok {
+		r0 = rf(ctx, u)
+	} else {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.User) response.ApiError); ok {
+		r1 = rf(ctx, u)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// ResetPassword provides a mock function with given fields: ctx, id, password
+func (_m *UserService) ResetPassword(ctx context.Context, id string, password string) response.ApiError {
+	ret := _m.Called(ctx, id, password)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, id, password)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// SetAttributes provides a mock function with given fields: ctx, id, attrs
+func (_m *UserService) SetAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	ret := _m.Called(ctx, id, attrs)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) response.ApiError); ok {
+		r0 = rf(ctx, id, attrs)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// SetRole provides a mock function with given fields: ctx, id, role
+func (_m *UserService) SetRole(ctx context.Context, id string, role string) response.ApiError {
+	ret := _m.Called(ctx, id, role)
 
 	var r0 response.ApiError
-	if rf, ok := ret.Get(0).(func(models.User) response.ApiError); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.ApiError); ok {
+		r0 = rf(ctx, id, role)
 	} else {
 		r0 = ret.Get(0).(response.ApiError)
 	}
@@ -129,13 +214,13 @@ func (_m *UserService) Register(_a0 models.User) response.ApiError {
 	return r0
 }
 
-// UpdateById provides a mock function with given fields: id, u
-func (_m *UserService) UpdateById(id string, u models.User) response.ApiError {
-	ret := _m.Called(id, u)
+// UpdateById provides a mock function with given fields: ctx, id, u
+func (_m *UserService) UpdateById(ctx context.Context, id string, u models.User) response.ApiError {
+	ret := _m.Called(ctx, id, u)
 
 	var r0 response.ApiError
-	if rf, ok := ret.Get(0).(func(string, models.User) response.ApiError); ok {
-		r0 = rf(id, u)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.User) response.ApiError); ok {
+		r0 = rf(ctx, id, u)
 	} else {
 		r0 = ret.Get(0).(response.ApiError)
 	}
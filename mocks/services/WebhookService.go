@@ -0,0 +1,190 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "user-api/models"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "user-api/response"
+)
+
+// WebhookService is an autogenerated mock type for the WebhookService type
+type WebhookService struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, w
+func (_m *WebhookService) Create(ctx context.Context, w models.Webhook) (models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx, w)
+
+	var r0 models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context, models.Webhook) models.Webhook); ok {
+		r0 = rf(ctx, w)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, models.Webhook) response.ApiError); ok {
+		r1 = rf(ctx, w)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *WebhookService) Delete(ctx context.Context, id string) response.ApiError {
+	ret := _m.Called(ctx, id)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.ApiError); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Deliveries provides a mock function with given fields: ctx, webhookID, status, limit, page
+func (_m *WebhookService) Deliveries(ctx context.Context, webhookID string, status string, limit uint64, page uint64) ([]models.WebhookDelivery, response.ApiError) {
+	ret := _m.Called(ctx, webhookID, status, limit, page)
+
+	var r0 []models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64) []models.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, status, limit, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.WebhookDelivery)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, uint64, uint64) response.ApiError); ok {
+		r1 = rf(ctx, webhookID, status, limit, page)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: ctx, id
+func (_m *WebhookService) Get(ctx context.Context, id string) (models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx, id)
+
+	var r0 models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Webhook); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string) response.ApiError); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *WebhookService) List(ctx context.Context) ([]models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Webhook)
+		}
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context) response.ApiError); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Publish provides a mock function with given fields: ctx, e
+func (_m *WebhookService) Publish(ctx context.Context, e models.Event) response.ApiError {
+	ret := _m.Called(ctx, e)
+
+	var r0 response.ApiError
+	if rf, ok := ret.Get(0).(func(context.Context, models.Event) response.ApiError); ok {
+		r0 = rf(ctx, e)
+	} else {
+		r0 = ret.Get(0).(response.ApiError)
+	}
+
+	return r0
+}
+
+// Redeliver provides a mock function with given fields: ctx, webhookID, deliveryID
+func (_m *WebhookService) Redeliver(ctx context.Context, webhookID string, deliveryID string) (models.WebhookDelivery, response.ApiError) {
+	ret := _m.Called(ctx, webhookID, deliveryID)
+
+	var r0 models.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, deliveryID)
+	} else {
+		r0 = ret.Get(0).(models.WebhookDelivery)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) response.ApiError); ok {
+		r1 = rf(ctx, webhookID, deliveryID)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, id, w
+func (_m *WebhookService) Update(ctx context.Context, id string, w models.Webhook) (models.Webhook, response.ApiError) {
+	ret := _m.Called(ctx, id, w)
+
+	var r0 models.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Webhook) models.Webhook); ok {
+		r0 = rf(ctx, id, w)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	var r1 response.ApiError
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Webhook) response.ApiError); ok {
+		r1 = rf(ctx, id, w)
+	} else {
+		r1 = ret.Get(1).(response.ApiError)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewWebhookService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewWebhookService creates a new instance of WebhookService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewWebhookService(t mockConstructorTestingTNewWebhookService) *WebhookService {
+	mock := &WebhookService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"user-api/postal"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AddressHome     = "home"
+	AddressBilling  = "billing"
+	AddressShipping = "shipping"
+)
+
+// MaxAddresses bounds the addresses embedded in a user document.
+const MaxAddresses = 10
+
+// Address is a postal address of a user, Country being an ISO 3166-1 alpha-2
+// code. A user with addresses has exactly one Default.
+type Address struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Label      string             `bson:"label"`
+	Line1      string             `bson:"line1"`
+	Line2      string             `bson:"line2,omitempty"`
+	City       string             `bson:"city"`
+	Region     string             `bson:"region,omitempty"`
+	PostalCode string             `bson:"postal_code,omitempty"`
+	Country    string             `bson:"country"`
+	Default    bool               `bson:"default"`
+	// Legacy flags an address parsed from the legacy free-form address,
+	// which is parsed again when that changes.
+	Legacy bool `bson:"legacy,omitempty"`
+}
+
+// LegacyAddress parses the legacy free-form address s into a default home
+// address on a best effort basis.
+func LegacyAddress(s string) Address {
+	f := postal.Parse(s)
+	return Address{
+		ID:         primitive.NewObjectID(),
+		Label:      AddressHome,
+		Line1:      f.Line1,
+		Line2:      f.Line2,
+		City:       f.City,
+		Region:     f.Region,
+		PostalCode: f.PostalCode,
+		Country:    f.Country,
+		Default:    true,
+		Legacy:     true,
+	}
+}
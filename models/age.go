@@ -0,0 +1,66 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// AgeOn is the age in whole years on now of someone born on birthDate.
+func AgeOn(birthDate, now time.Time) int {
+	age := now.Year() - birthDate.Year()
+	if now.Month() < birthDate.Month() || (now.Month() == birthDate.Month() && now.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// EstimateBirthDate is the latest birth date of someone aged age on on, for
+// clients and documents still giving an age.
+func EstimateBirthDate(age int, on time.Time) time.Time {
+	y, m, d := on.AddDate(-age, 0, 0).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// AgeOn returns the age of u on now from the birth date, or from the legacy
+// age of users stored before birth dates, ok being false when unknown.
+func (u User) AgeOn(now time.Time) (age int, ok bool) {
+	if u.BirthDate != nil {
+		return AgeOn(*u.BirthDate, now), true
+	}
+	if u.Age != 0 {
+		return int(u.Age), true
+	}
+	return 0, false
+}
+
+// AgePolicy bounds the age of registering users, a zero bound being
+// disabled.
+type AgePolicy struct {
+	Min int
+	Max int
+}
+
+// Check returns the failed rules of the birth date of u, as validation rules
+// of the birth_date field: "before_today", "min_age:N" and "max_age:N".
+func (p AgePolicy) Check(u User, now time.Time) []string {
+	failed := make([]string, 0)
+	if u.BirthDate != nil && u.BirthDate.After(now) {
+		return append(failed, "before_today")
+	}
+
+	age, ok := u.AgeOn(now)
+	if !ok {
+		if p.Min != 0 || p.Max != 0 {
+			failed = append(failed, "required")
+		}
+		return failed
+	}
+
+	if p.Min != 0 && age < p.Min {
+		failed = append(failed, "min_age:"+strconv.Itoa(p.Min))
+	}
+	if p.Max != 0 && age > p.Max {
+		failed = append(failed, "max_age:"+strconv.Itoa(p.Max))
+	}
+	return failed
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAgeOn(t *testing.T) {
+	birth := date(2000, time.May, 17)
+
+	assert.Equal(t, 25, AgeOn(birth, date(2026, time.May, 16)))
+	assert.Equal(t, 26, AgeOn(birth, date(2026, time.May, 17)))
+	assert.Equal(t, 26, AgeOn(birth, date(2026, time.October, 1)))
+	assert.Equal(t, 0, AgeOn(birth, birth))
+	// born on a leap day, a year older on the 1st of March
+	assert.Equal(t, 0, AgeOn(date(2004, time.February, 29), date(2005, time.February, 28)))
+	assert.Equal(t, 1, AgeOn(date(2004, time.February, 29), date(2005, time.March, 1)))
+}
+
+func TestEstimateBirthDateKeepsAge(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 15, 4, 5, 0, time.UTC)
+	birth := EstimateBirthDate(30, now)
+
+	assert.Equal(t, date(1996, time.October, 19), birth)
+	assert.Equal(t, 30, AgeOn(birth, now))
+}
+
+func TestUserAgeOnFallsBackToLegacyAge(t *testing.T) {
+	now := date(2026, time.October, 19)
+	birth := date(2000, time.May, 17)
+
+	age, ok := User{BirthDate: &birth, Age: 99}.AgeOn(now)
+	assert.True(t, ok)
+	assert.Equal(t, 26, age)
+
+	age, ok = User{Age: 40}.AgeOn(now)
+	assert.True(t, ok)
+	assert.Equal(t, 40, age)
+
+	_, ok = User{}.AgeOn(now)
+	assert.False(t, ok)
+}
+
+func TestAgePolicyCheck(t *testing.T) {
+	now := date(2026, time.October, 19)
+	born := func(y int) User {
+		b := date(y, time.January, 1)
+		return User{BirthDate: &b}
+	}
+	future := date(2026, time.October, 20)
+	policy := AgePolicy{Min: 18, Max: 100}
+
+	assert.Empty(t, policy.Check(born(1990), now))
+	assert.Equal(t, []string{"min_age:18"}, policy.Check(born(2010), now))
+	assert.Equal(t, []string{"max_age:100"}, policy.Check(born(1900), now))
+	assert.Equal(t, []string{"before_today"}, policy.Check(User{BirthDate: &future}, now))
+	assert.Equal(t, []string{"required"}, policy.Check(User{}, now))
+	assert.Empty(t, AgePolicy{}.Check(User{}, now))
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeApiKeysManage = "api_keys:manage"
+	ScopeAdmin         = "admin"
+)
+
+// UserScopes are the scopes any user can grant to their own keys, ScopeAdmin
+// is reserved to admins.
+var UserScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeApiKeysManage}
+
+type ApiKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	Name       string             `bson:"name"`
+	Prefix     string             `bson:"prefix"`
+	Hash       string             `bson:"hash"`
+	Scopes     []string           `bson:"scopes"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty"`
+}
+
+func (k ApiKey) Expired(now time.Time) bool {
+	return !now.Before(k.ExpiresAt)
+}
+
+func (k ApiKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// HasScope reports whether the key grants scope to its owner, ScopeAdmin
+// granting every scope only while the owner is still an admin.
+func (k ApiKey) HasScope(scope string, owner User) bool {
+	for _, s := range k.Scopes {
+		if s == ScopeAdmin && owner.IsAdmin() {
+			return true
+		}
+		if s == scope && s != ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
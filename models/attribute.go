@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// AttributeSchema is the JSON Schema admins define for the custom
+// attributes of users. It is kept as text since keywords such as "$ref"
+// are not valid Mongo field names.
+type AttributeSchema struct {
+	Schema    string    `bson:"schema"`
+	Version   int       `bson:"version"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
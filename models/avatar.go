@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Avatar locates the images of the profile picture of a user. Each upload
+// gets new keys, so the images can be cached forever.
+type Avatar struct {
+	// URL is the largest image, Thumbnails the others by size in pixels.
+	URL        string            `bson:"url"`
+	Thumbnails map[string]string `bson:"thumbnails,omitempty"`
+	// Keys are the blob keys of every image, deleted with the avatar.
+	Keys      []string  `bson:"keys"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
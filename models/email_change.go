@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailChange is a request to change the email of a user. The change
+// applies once the link sent to NewEmail is opened before ConfirmBy, the
+// link sent to OldEmail cancelling or undoing it until RevertBy. Only the
+// hashes of the link tokens are stored.
+type EmailChange struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id"`
+	OldEmail    string             `bson:"old_email"`
+	NewEmail    string             `bson:"new_email"`
+	ConfirmHash string             `bson:"confirm_hash"`
+	RevertHash  string             `bson:"revert_hash"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	ConfirmBy   time.Time          `bson:"confirm_by"`
+	RevertBy    time.Time          `bson:"revert_by"`
+	ConfirmedAt *time.Time         `bson:"confirmed_at,omitempty"`
+	RevertedAt  *time.Time         `bson:"reverted_at,omitempty"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Types of the user lifecycle events.
+const (
+	EventUserCreated         = "user.created"
+	EventUserUpdated         = "user.updated"
+	EventUserDeleted         = "user.deleted"
+	EventUserLogin           = "user.login"
+	EventUserPasswordChanged = "user.password_changed"
+)
+
+// EventTypes are the events webhooks can subscribe to.
+var EventTypes = []string{EventUserCreated, EventUserUpdated, EventUserDeleted, EventUserLogin, EventUserPasswordChanged}
+
+// Event records a change of a user for the downstream systems. Data only
+// carries ids and the changed values, never personal data.
+type Event struct {
+	ID         primitive.ObjectID     `bson:"_id"`
+	Type       string                 `bson:"type"`
+	UserID     string                 `bson:"user_id"`
+	OccurredAt time.Time              `bson:"occurred_at"`
+	Data       map[string]interface{} `bson:"data,omitempty"`
+	// Seq orders the events of a user, from 1 and without gaps as the
+	// outbox assigns it in the transaction of the change.
+	Seq int64 `bson:"seq,omitempty"`
+}
+
+func NewEvent(eventType string, userID string, data map[string]interface{}) Event {
+	return Event{ID: primitive.NewObjectID(), Type: eventType, UserID: userID, OccurredAt: time.Now().UTC(), Data: data}
+}
+
+// OutboxEvent is an event stored in the outbox in the transaction of its
+// change, until the relay published it to every sink.
+type OutboxEvent struct {
+	Event     `bson:",inline"`
+	Published bool `bson:"published"`
+	// Sinks are the sinks the event was published to, skipped when a
+	// failed relay is retried.
+	Sinks         []string   `bson:"sinks,omitempty"`
+	Attempts      int        `bson:"attempts,omitempty"`
+	LastError     string     `bson:"last_error,omitempty"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at"`
+	PublishedAt   *time.Time `bson:"published_at,omitempty"`
+}
+
+func (e OutboxEvent) SentTo(sink string) bool {
+	for _, s := range e.Sinks {
+		if s == sink {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is created by every successful login. The jwt issued by the login
+// carries FamilyID so revoking the session invalidates it.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	FamilyID   string             `bson:"family_id"`
+	Device     string             `bson:"device"`
+	UserAgent  string             `bson:"user_agent"`
+	IP         string             `bson:"ip"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	LastSeenAt time.Time          `bson:"last_seen_at"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty"`
+}
+
+// SessionClient describes the client performing a login.
+type SessionClient struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
+
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -1,42 +1,94 @@
 package models
 
 import (
+	"log/slog"
+	"time"
+	"user-api/auth"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty"`
 	Name     string             `bson:"name,omitempty"`
-	Age      uint8              `bson:"age,omitempty"`
 	Email    string             `bson:"email,omitempty"`
 	Password string             `bson:"password,omitempty"`
-	Address  string             `bson:"address,omitempty"`
+	// BirthDate is a UTC midnight. It is estimated when derived from an
+	// age, which proves nothing for age verification.
+	BirthDate          *time.Time `bson:"birth_date,omitempty"`
+	BirthDateEstimated bool       `bson:"birth_date_estimated,omitempty"`
+	// Age is the age given before birth dates existed, read until the
+	// documents are migrated.
+	Age uint8 `bson:"age,omitempty"`
+	// Deprecated: Address is the legacy free-form address, parsed into a
+	// Legacy address of Addresses.
+	Address   string    `bson:"address,omitempty"`
+	Addresses []Address `bson:"addresses,omitempty"`
+	Locale    string    `bson:"locale,omitempty"`
+	Role      string    `bson:"role,omitempty"`
+	// Attributes are the custom attributes defined by the AttributeSchema.
+	Attributes map[string]interface{} `bson:"attributes,omitempty"`
+	Avatar     *Avatar                `bson:"avatar,omitempty"`
+	// TokensRevokedAt rejects the jwts issued until then without a session,
+	// which revoking the sessions cannot reach.
+	TokensRevokedAt *time.Time `bson:"tokens_revoked_at,omitempty"`
 }
 
-func NewUser(name string, age uint8, email string, password string, address string) *User {
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+func NewUser(name string, birthDate *time.Time, email string, password string, address string) *User {
 	return &User{
-		Name:     name,
-		Email:    email,
-		Password: password,
-		Address:  address,
-		Age:      age,
+		Name:      name,
+		Email:     email,
+		Password:  password,
+		Address:   address,
+		BirthDate: birthDate,
+		Role:      RoleUser,
 	}
 }
 
-func (u *User) HashPassword() (err error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(u.Password), 14)
-	if err != nil {
-		return
-	}
-	u.Password = string(bytes)
-	return
+// IsAdmin reports whether u has the admin role, users stored before roles
+// existed have none and are regular users.
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
 
-func (u *User) CheckPassword(providedPassword string) (err error) {
-	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(providedPassword))
+// TokenRevoked reports whether a jwt without a session issued at issuedAt,
+// in Unix seconds, was revoked.
+func (u User) TokenRevoked(issuedAt int64) bool {
+	return u.TokensRevokedAt != nil && issuedAt <= u.TokensRevokedAt.Unix()
+}
+
+// HashPassword replaces the plain text password of u by its hash.
+func (u *User) HashPassword(h auth.PasswordHasher) (err error) {
+	hash, err := h.Hash(u.Password)
 	if err != nil {
 		return
 	}
+	u.Password = hash
 	return
 }
+
+func (u *User) CheckPassword(h auth.PasswordHasher, providedPassword string) error {
+	return h.Verify(u.Password, providedPassword)
+}
+
+// LogValue keeps the password hash and personal data out of the logs when a
+// user is logged as a whole.
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", u.ID.Hex()))
+}
+
+// UserFilter selects users in listings and exports, empty fields match
+// every user.
+type UserFilter struct {
+	Email  string
+	Locale string
+	Role   string
+	// Attributes match custom attributes by equality, values being typed
+	// after the AttributeSchema.
+	Attributes map[string]interface{}
+}
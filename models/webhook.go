@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook is an endpoint registered by an admin to receive the events it
+// subscribes to.
+type Webhook struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	URL    string             `bson:"url"`
+	Events []string           `bson:"events"`
+	// Secret is the HMAC-SHA256 key of the signatures, kept in clear to
+	// sign every delivery.
+	Secret    string    `bson:"secret"`
+	Active    bool      `bson:"active"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func (w Webhook) Subscribed(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses of a delivery. Pending ones wait for their next attempt, dead
+// ones ran out of attempts and form the dead letter list.
+const (
+	DeliveryPending   = "pending"
+	DeliveryInFlight  = "in_flight"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is the sending of an event to a webhook, queued until
+// the endpoint acknowledges it and kept as the delivery log.
+type WebhookDelivery struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	WebhookID primitive.ObjectID `bson:"webhook_id"`
+	EventID   primitive.ObjectID `bson:"event_id"`
+	Event     string             `bson:"event"`
+	// UserID and Seq are those of the event, the deliveries of a user to
+	// a webhook being sent in their order.
+	UserID string `bson:"user_id"`
+	Seq    int64  `bson:"seq"`
+	// Payload is the signed body, the same for every attempt.
+	Payload       string     `bson:"payload"`
+	Status        string     `bson:"status"`
+	Attempts      []Attempt  `bson:"attempts,omitempty"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty"`
+	CreatedAt     time.Time  `bson:"created_at"`
+	DeliveredAt   *time.Time `bson:"delivered_at,omitempty"`
+	// RedeliveryOf is the delivery an admin sent again.
+	RedeliveryOf *primitive.ObjectID `bson:"redelivery_of,omitempty"`
+}
+
+// Attempt is the outcome of one request of a delivery.
+type Attempt struct {
+	At         time.Time     `bson:"at"`
+	StatusCode int           `bson:"status_code,omitempty"`
+	Error      string        `bson:"error,omitempty"`
+	Duration   time.Duration `bson:"duration"`
+}
@@ -0,0 +1,93 @@
+package postal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Fields are the parts of an address.
+type Fields struct {
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+// genericPostalCode spots postal codes of addresses without a country.
+var genericPostalCode = regexp.MustCompile(`^\d{4,6}(-\d{3,4})?$`)
+
+// Parse splits a free-form address such as "1 Main St, Springfield, IL
+// 62701, US" on a best effort basis. The parts are separated by commas or
+// new lines, a trailing country code and a postal code valid for it are
+// picked out, the first remaining part is the street and the last ones the
+// city and region. Whatever cannot be told apart ends up in Line1 or Line2.
+func Parse(s string) Fields {
+	parts := make([]string, 0)
+	for _, p := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == ';' }) {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	f := Fields{}
+	if len(parts) > 1 && ValidCountry(parts[len(parts)-1]) && len(parts[len(parts)-1]) == 2 {
+		f.Country = NormalizeCountry(parts[len(parts)-1])
+		parts = parts[:len(parts)-1]
+	}
+
+	for i := len(parts) - 1; i > 0 && f.PostalCode == ""; i-- {
+		var rest string
+		f.PostalCode, rest = findPostalCode(parts[i], f.Country)
+		if f.PostalCode == "" {
+			continue
+		}
+		if rest == "" {
+			parts = append(parts[:i], parts[i+1:]...)
+		} else {
+			parts[i] = rest
+		}
+	}
+
+	switch len(parts) {
+	case 0:
+	case 1:
+		f.Line1 = parts[0]
+	case 2:
+		f.Line1, f.City = parts[0], parts[1]
+	case 3:
+		f.Line1, f.City, f.Region = parts[0], parts[1], parts[2]
+	default:
+		n := len(parts)
+		f.Line1 = parts[0]
+		f.Line2 = strings.Join(parts[1:n-2], ", ")
+		f.City, f.Region = parts[n-2], parts[n-1]
+	}
+	return f
+}
+
+// findPostalCode looks for a postal code among the words of part, trying
+// pairs of words too for codes such as "SW1A 1AA", and returns part without
+// it.
+func findPostalCode(part, country string) (code string, rest string) {
+	words := strings.Fields(part)
+	matches := func(candidate string) bool {
+		if country != "" {
+			return RequiresPostalCode(country) && ValidPostalCode(country, candidate)
+		}
+		return genericPostalCode.MatchString(candidate)
+	}
+
+	for size := 2; size >= 1; size-- {
+		for i := len(words) - size; i >= 0; i-- {
+			candidate := strings.Join(words[i:i+size], " ")
+			if !matches(candidate) {
+				continue
+			}
+			rest := append(append([]string{}, words[:i]...), words[i+size:]...)
+			return NormalizePostalCode(candidate), strings.Trim(strings.Join(rest, " "), " -")
+		}
+	}
+	return "", part
+}
@@ -0,0 +1,71 @@
+// Package postal validates ISO 3166 countries and their postal codes from
+// bundled rules, and parses legacy free-form addresses.
+package postal
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:embed rules.json
+var rulesJSON []byte
+
+type rules struct {
+	// Countries are the ISO 3166-1 alpha-2 codes.
+	Countries []string `json:"countries"`
+	// PostalCodes are the patterns of the countries whose addresses require
+	// a postal code, matched once normalized.
+	PostalCodes map[string]string `json:"postal_codes"`
+}
+
+var (
+	countries = make(map[string]bool)
+	patterns  = make(map[string]*regexp.Regexp)
+)
+
+func init() {
+	var r rules
+	if err := json.Unmarshal(rulesJSON, &r); err != nil {
+		panic(fmt.Sprintf("postal: reading rules: %v", err))
+	}
+	for _, c := range r.Countries {
+		countries[c] = true
+	}
+	for c, p := range r.PostalCodes {
+		patterns[c] = regexp.MustCompile(p)
+	}
+}
+
+// NormalizeCountry upper cases a country code.
+func NormalizeCountry(country string) string {
+	return strings.ToUpper(strings.TrimSpace(country))
+}
+
+// NormalizePostalCode upper cases a postal code and collapses its spaces.
+func NormalizePostalCode(code string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(code), " "))
+}
+
+// ValidCountry reports whether country is an ISO 3166-1 alpha-2 code.
+func ValidCountry(country string) bool {
+	return countries[NormalizeCountry(country)]
+}
+
+// RequiresPostalCode reports whether the addresses of country need one.
+func RequiresPostalCode(country string) bool {
+	_, ok := patterns[NormalizeCountry(country)]
+	return ok
+}
+
+// ValidPostalCode checks code against the rule of country. Countries without
+// a rule accept any code, or none.
+func ValidPostalCode(country, code string) bool {
+	p, ok := patterns[NormalizeCountry(country)]
+	if !ok {
+		return true
+	}
+	return p.MatchString(NormalizePostalCode(code))
+}
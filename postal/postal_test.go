@@ -0,0 +1,56 @@
+package postal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidCountry(t *testing.T) {
+	assert.True(t, ValidCountry("br"))
+	assert.True(t, ValidCountry("US"))
+	assert.False(t, ValidCountry("XX"))
+	assert.False(t, ValidCountry("USA"))
+	assert.Len(t, countries, 249)
+}
+
+func TestValidPostalCode(t *testing.T) {
+	cases := []struct {
+		country, code string
+		valid         bool
+	}{
+		{"US", "62701", true},
+		{"US", "62701-1234", true},
+		{"US", "6270", false},
+		{"BR", "01310-100", true},
+		{"PT", "1000-001", true},
+		{"PT", "1000", false},
+		{"GB", "sw1a 1aa", true},
+		{"GB", "12345", false},
+		{"CA", "K1A 0B1", true},
+		{"NL", "1012 AB", true},
+		{"HK", "", true},
+		{"HK", "anything", true},
+		{"DE", "", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.valid, ValidPostalCode(c.country, c.code), "%s %q", c.country, c.code)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]Fields{
+		"1 Main St, Springfield, IL 62701, US": {Line1: "1 Main St", City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US"},
+		"Rua Augusta 10, Lisboa, 1100-053, PT": {Line1: "Rua Augusta 10", City: "Lisboa", PostalCode: "1100-053", Country: "PT"},
+		"10 Downing St\nLondon\nSW1A 2AA\nGB":  {Line1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA", Country: "GB"},
+		"Av Paulista 1000, Apto 5, Bela Vista, Sao Paulo, SP, 01310-100, BR": {
+			Line1: "Av Paulista 1000", Line2: "Apto 5, Bela Vista", City: "Sao Paulo", Region: "SP", PostalCode: "01310-100", Country: "BR",
+		},
+		"Calle Mayor 5, 28013 Madrid": {Line1: "Calle Mayor 5", City: "Madrid", PostalCode: "28013"},
+		"somewhere":                   {Line1: "somewhere"},
+		"":                            {},
+	}
+	for in, expected := range cases {
+		assert.Equal(t, expected, Parse(in), in)
+	}
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AddressRepo manages the addresses embedded in the user documents, every
+// change being a single atomic update of the user.
+type AddressRepo interface {
+	List(ctx context.Context, userID string) ([]models.Address, response.ApiError)
+	// Add appends a and returns it as stored, failing with AddressLimitError
+	// once the user has models.MaxAddresses. A default a makes the other
+	// addresses not default in the same update, and the first address of a
+	// user is always the default.
+	Add(ctx context.Context, userID string, a models.Address) (models.Address, response.ApiError)
+	// Replace overwrites the fields of the address of a.ID, its default flag
+	// included, a default a making the other addresses not default in the
+	// same update.
+	Replace(ctx context.Context, userID string, a models.Address) response.ApiError
+	// Delete removes an address, making the first remaining one the default
+	// when the default was removed.
+	Delete(ctx context.Context, userID string, id string) response.ApiError
+}
+
+type addressMongoImpl struct {
+	db  *mongo.Collection
+	log *slog.Logger
+}
+
+func NewAddressMongo(mongoDb *mongo.Collection, logger *slog.Logger) AddressRepo {
+	return addressMongoImpl{
+		db:  mongoDb,
+		log: logger.With("component", "address_repo"),
+	}
+}
+
+func addressIDs(userID string, id string) (primitive.ObjectID, primitive.ObjectID, response.ApiError) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return userObjID, primitive.NilObjectID, response.BadRequestError
+	}
+	if id == "" {
+		return userObjID, primitive.NilObjectID, response.ApiError{}
+	}
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return userObjID, objID, response.BadRequestError
+	}
+	return userObjID, objID, response.ApiError{}
+}
+
+func (r addressMongoImpl) List(ctx context.Context, userID string) ([]models.Address, response.ApiError) {
+	userObjID, _, apiErr := addressIDs(userID, "")
+	if apiErr.Status != 0 {
+		return nil, apiErr
+	}
+
+	u := models.User{}
+	opts := options.FindOne().SetProjection(bson.D{{Key: "addresses", Value: 1}})
+	err := r.db.FindOne(ctx, bson.D{{Key: "_id", Value: userObjID}}, opts).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, response.ResourceNotFoundError
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error listing addresses", "user_id", userID, "error", err)
+		return nil, internalError(err)
+	}
+
+	if u.Addresses == nil {
+		return make([]models.Address, 0), response.ApiError{}
+	}
+	return u.Addresses, response.ApiError{}
+}
+
+func (r addressMongoImpl) Add(ctx context.Context, userID string, a models.Address) (models.Address, response.ApiError) {
+	userObjID, _, apiErr := addressIDs(userID, "")
+	if apiErr.Status != 0 {
+		return a, apiErr
+	}
+
+	// the filter misses once the last allowed slot is taken
+	filter := bson.D{
+		{Key: "_id", Value: userObjID},
+		{Key: fmt.Sprintf("addresses.%d", models.MaxAddresses-1), Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	current := bson.D{{Key: "$ifNull", Value: bson.A{"$addresses", bson.A{}}}}
+	var others, added interface{} = current, bson.D{{Key: "$mergeObjects", Value: bson.A{
+		bson.D{{Key: "$literal", Value: a}},
+		bson.D{{Key: "default", Value: bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$size", Value: current}}, 0}}}}},
+	}}}
+	if a.Default {
+		others = bson.D{{Key: "$map", Value: bson.D{
+			{Key: "input", Value: current},
+			{Key: "as", Value: "a"},
+			{Key: "in", Value: bson.D{{Key: "$mergeObjects", Value: bson.A{"$$a", bson.D{{Key: "default", Value: false}}}}}},
+		}}}
+		added = bson.D{{Key: "$literal", Value: a}}
+	}
+	// a pipeline rewrites the other addresses and defaults the first one in
+	// the same update, which $push cannot do
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "addresses", Value: bson.D{
+		{Key: "$concatArrays", Value: bson.A{others, bson.A{added}}},
+	}}}}}}
+	opts := options.FindOneAndUpdate().
+		SetProjection(bson.D{{Key: "addresses", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	u := models.User{}
+	err := r.db.FindOneAndUpdate(ctx, filter, update, opts).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return a, r.missedAdd(ctx, userObjID)
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error adding address", "user_id", userID, "error", err)
+		return a, internalError(err)
+	}
+
+	for _, stored := range u.Addresses {
+		if stored.ID == a.ID {
+			return stored, response.ApiError{}
+		}
+	}
+	return a, response.ApiError{}
+}
+
+// missedAdd tells a full address book from an unknown user once the filter
+// of Add missed.
+func (r addressMongoImpl) missedAdd(ctx context.Context, userObjID primitive.ObjectID) response.ApiError {
+	n, err := r.db.CountDocuments(ctx, bson.D{{Key: "_id", Value: userObjID}}, options.Count().SetLimit(1))
+	if err != nil {
+		r.log.ErrorContext(ctx, "error adding address", "user_id", userObjID.Hex(), "error", err)
+		return internalError(err)
+	}
+	if n == 0 {
+		return response.ResourceNotFoundError
+	}
+	return response.AddressLimitError
+}
+
+func (r addressMongoImpl) Replace(ctx context.Context, userID string, a models.Address) response.ApiError {
+	userObjID, _, apiErr := addressIDs(userID, "")
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+
+	filter := bson.D{{Key: "_id", Value: userObjID}, {Key: "addresses._id", Value: a.ID}}
+	set := bson.D{{Key: "addresses.$[chosen]", Value: a}}
+	filters := []interface{}{bson.D{{Key: "chosen._id", Value: a.ID}}}
+	if a.Default {
+		set = append(set, bson.E{Key: "addresses.$[other].default", Value: false})
+		filters = append(filters, bson.D{{Key: "other._id", Value: bson.D{{Key: "$ne", Value: a.ID}}}})
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: filters})
+	res, err := r.db.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: set}}, opts)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating address", "user_id", userID, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+	return response.ApiError{}
+}
+
+func (r addressMongoImpl) Delete(ctx context.Context, userID string, id string) response.ApiError {
+	userObjID, objID, apiErr := addressIDs(userID, id)
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+
+	filter := bson.D{{Key: "_id", Value: userObjID}, {Key: "addresses._id", Value: objID}}
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: "addresses", Value: bson.D{{Key: "_id", Value: objID}}}}}}
+	res, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error deleting address", "user_id", userID, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+
+	promote := bson.D{
+		{Key: "_id", Value: userObjID},
+		{Key: "addresses.0", Value: bson.D{{Key: "$exists", Value: true}}},
+		{Key: "addresses.default", Value: bson.D{{Key: "$ne", Value: true}}},
+	}
+	set := bson.D{{Key: "$set", Value: bson.D{{Key: "addresses.0.default", Value: true}}}}
+	if _, err := r.db.UpdateOne(ctx, promote, set); err != nil {
+		r.log.ErrorContext(ctx, "error promoting default address", "user_id", userID, "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
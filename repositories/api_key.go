@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ApiKeyRepo interface {
+	Save(ctx context.Context, k models.ApiKey) (models.ApiKey, response.ApiError)
+	FindByHash(ctx context.Context, hash string) (models.ApiKey, response.ApiError)
+	ListByUser(ctx context.Context, userID string) ([]models.ApiKey, response.ApiError)
+	Revoke(ctx context.Context, userID string, id string, at time.Time) response.ApiError
+	TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError
+}
+
+type apiKeyMongoImpl struct {
+	db  *mongo.Collection
+	log *slog.Logger
+}
+
+func NewApiKeyMongo(mongoDb *mongo.Collection, logger *slog.Logger) ApiKeyRepo {
+	return apiKeyMongoImpl{
+		db:  mongoDb,
+		log: logger.With("component", "api_key_repo"),
+	}
+}
+
+func (r apiKeyMongoImpl) Save(ctx context.Context, k models.ApiKey) (models.ApiKey, response.ApiError) {
+	res, err := r.db.InsertOne(ctx, k)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error saving api key", "error", err)
+		return k, internalError(err)
+	}
+	k.ID = res.InsertedID.(primitive.ObjectID)
+	return k, response.ApiError{}
+}
+
+func (r apiKeyMongoImpl) FindByHash(ctx context.Context, hash string) (models.ApiKey, response.ApiError) {
+	k := models.ApiKey{}
+	err := r.db.FindOne(ctx, bson.D{{Key: "hash", Value: hash}}).Decode(&k)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return k, response.ResourceNotFoundError
+		}
+		r.log.ErrorContext(ctx, "error finding api key", "error", err)
+		return k, internalError(err)
+	}
+	return k, response.ApiError{}
+}
+
+func (r apiKeyMongoImpl) ListByUser(ctx context.Context, userID string) ([]models.ApiKey, response.ApiError) {
+	result := make([]models.ApiKey, 0)
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", userID)
+		return result, response.BadRequestError
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	curr, err := r.db.Find(ctx, bson.D{{Key: "user_id", Value: objID}}, opts)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error listing api keys", "error", err)
+		return result, internalError(err)
+	}
+
+	if err := curr.All(ctx, &result); err != nil {
+		r.log.ErrorContext(ctx, "error decoding api keys", "error", err)
+		return result, internalError(err)
+	}
+
+	return result, response.ApiError{}
+}
+
+// Revoke marks the key as revoked, only when it belongs to userID so users
+// cannot revoke each other keys.
+func (r apiKeyMongoImpl) Revoke(ctx context.Context, userID string, id string, at time.Time) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return response.BadRequestError
+	}
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return response.BadRequestError
+	}
+
+	filter := bson.D{{Key: "_id", Value: objID}, {Key: "user_id", Value: ownerID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}}
+
+	res, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error revoking api key", "api_key_id", id, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+
+	return response.ApiError{}
+}
+
+func (r apiKeyMongoImpl) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: at}}}}
+	if _, err := r.db.UpdateByID(ctx, id, update); err != nil {
+		r.log.ErrorContext(ctx, "error updating api key last use", "api_key_id", id.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// usersSchemaID is the id of the schema of the user attributes, the only
+// one for now.
+const usersSchemaID = "users"
+
+type AttributeSchemaRepo interface {
+	// Get returns ResourceNotFoundError until a schema is saved.
+	Get(ctx context.Context) (models.AttributeSchema, response.ApiError)
+	// Save replaces the schema, incrementing its version.
+	Save(ctx context.Context, s models.AttributeSchema) (models.AttributeSchema, response.ApiError)
+}
+
+type attributeSchemaMongoImpl struct {
+	db  *mongo.Collection
+	log *slog.Logger
+}
+
+func NewAttributeSchemaMongo(mongoDb *mongo.Collection, logger *slog.Logger) AttributeSchemaRepo {
+	return attributeSchemaMongoImpl{
+		db:  mongoDb,
+		log: logger.With("component", "attribute_schema_repo"),
+	}
+}
+
+func (r attributeSchemaMongoImpl) Get(ctx context.Context) (models.AttributeSchema, response.ApiError) {
+	s := models.AttributeSchema{}
+	err := r.db.FindOne(ctx, bson.D{{Key: "_id", Value: usersSchemaID}}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s, response.ResourceNotFoundError
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error finding attribute schema", "error", err)
+		return s, internalError(err)
+	}
+	return s, response.ApiError{}
+}
+
+func (r attributeSchemaMongoImpl) Save(ctx context.Context, s models.AttributeSchema) (models.AttributeSchema, response.ApiError) {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "schema", Value: s.Schema}, {Key: "updated_at", Value: s.UpdatedAt}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	saved := models.AttributeSchema{}
+	err := r.db.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: usersSchemaID}}, update, opts).Decode(&saved)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error saving attribute schema", "error", err)
+		return s, internalError(err)
+	}
+	return saved, response.ApiError{}
+}
@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"user-api/cache"
+	"user-api/metrics"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	userCacheName = "users"
+	// tombstoneTTL is how long an invalidated user is not cached again, so a
+	// lookup which read the user before the write cannot cache it back.
+	tombstoneTTL = 10 * time.Second
+)
+
+// CachedUserRepo decorates a UserRepo caching the users found by id or email,
+// the lookups behind every authenticated request. Every write of a user
+// invalidates it, errors of the cache falling back to the next repo. The
+// password hash is not cached, cached users come without it.
+type CachedUserRepo struct {
+	next  UserRepo
+	cache cache.Cache
+	ttl   time.Duration
+	log   *slog.Logger
+}
+
+func NewCachedUserRepo(next UserRepo, c cache.Cache, ttl time.Duration, logger *slog.Logger) *CachedUserRepo {
+	return &CachedUserRepo{next: next, cache: c, ttl: ttl, log: logger.With("component", "user_cache")}
+}
+
+func userKey(id string) string {
+	return "user:" + id
+}
+
+func emailKey(email string) string {
+	return "user-email:" + email
+}
+
+// cached returns the cached user of id, a tombstone being a miss.
+func (r *CachedUserRepo) cached(ctx context.Context, id string) (models.User, bool) {
+	v, ok, err := r.cache.Get(ctx, userKey(id))
+	if err != nil {
+		r.log.WarnContext(ctx, "error reading user cache", "error", err)
+		return models.User{}, false
+	}
+	if !ok || len(v) == 0 {
+		return models.User{}, false
+	}
+	u := models.User{}
+	if err := bson.Unmarshal(v, &u); err != nil {
+		r.log.WarnContext(ctx, "error decoding cached user", "user_id", id, "error", err)
+		return models.User{}, false
+	}
+	return u, true
+}
+
+// fill caches u unless it was invalidated meanwhile.
+func (r *CachedUserRepo) fill(ctx context.Context, u models.User) {
+	u.Password = ""
+	v, err := bson.Marshal(u)
+	if err != nil {
+		r.log.WarnContext(ctx, "error encoding user", "error", err)
+		return
+	}
+	id := u.ID.Hex()
+	if _, err := r.cache.Add(ctx, userKey(id), v, r.ttl); err != nil {
+		r.log.WarnContext(ctx, "error writing user cache", "error", err)
+		return
+	}
+	if err := r.cache.Set(ctx, emailKey(u.Email), []byte(id), r.ttl); err != nil {
+		r.log.WarnContext(ctx, "error writing user cache", "error", err)
+	}
+}
+
+// Invalidate drops the cached user of id, for writes to the user documents
+// made around the UserRepo.
+func (r *CachedUserRepo) Invalidate(ctx context.Context, id string) {
+	if err := r.cache.Set(ctx, userKey(id), []byte{}, tombstoneTTL); err != nil {
+		r.log.ErrorContext(ctx, "error invalidating cached user", "user_id", id, "error", err)
+	}
+}
+
+func (r *CachedUserRepo) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
+	if u, ok := r.cached(ctx, id); ok {
+		metrics.CacheHit(userCacheName)
+		return u, response.ApiError{}
+	}
+	metrics.CacheMiss(userCacheName)
+
+	u, apiErr := r.next.FindById(ctx, id)
+	if apiErr.Status == 0 {
+		r.fill(ctx, u)
+	}
+	return u, apiErr
+}
+
+// FindByField caches the lookups by email, the email index being checked
+// against the cached user as it may predate an email change.
+func (r *CachedUserRepo) FindByField(ctx context.Context, value interface{}, key string) (models.User, response.ApiError) {
+	email, ok := value.(string)
+	if key != "email" || !ok {
+		return r.next.FindByField(ctx, value, key)
+	}
+
+	if id, ok, err := r.cache.Get(ctx, emailKey(email)); err != nil {
+		r.log.WarnContext(ctx, "error reading user cache", "error", err)
+	} else if ok {
+		if u, ok := r.cached(ctx, string(id)); ok && u.Email == email {
+			metrics.CacheHit(userCacheName)
+			return u, response.ApiError{}
+		}
+	}
+	metrics.CacheMiss(userCacheName)
+
+	u, apiErr := r.next.FindByField(ctx, value, key)
+	if apiErr.Status == 0 {
+		r.fill(ctx, u)
+	}
+	return u, apiErr
+}
+
+func (r *CachedUserRepo) PasswordHash(ctx context.Context, id string) (string, response.ApiError) {
+	return r.next.PasswordHash(ctx, id)
+}
+
+func (r *CachedUserRepo) Save(ctx context.Context, u models.User) response.ApiError {
+	return r.next.Save(ctx, u)
+}
+
+func (r *CachedUserRepo) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, error) {
+	return r.next.GetAll(ctx, filter, limit, page)
+}
+
+func (r *CachedUserRepo) Each(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	return r.next.Each(ctx, filter, fn)
+}
+
+func (r *CachedUserRepo) DeleteById(ctx context.Context, id string) response.ApiError {
+	apiErr := r.next.DeleteById(ctx, id)
+	r.Invalidate(ctx, id)
+	return apiErr
+}
+
+func (r *CachedUserRepo) UpdateByID(ctx context.Context, id string, u models.User) response.ApiError {
+	apiErr := r.next.UpdateByID(ctx, id, u)
+	r.Invalidate(ctx, id)
+	return apiErr
+}
+
+func (r *CachedUserRepo) UpdatePassword(ctx context.Context, id string, hash string) response.ApiError {
+	apiErr := r.next.UpdatePassword(ctx, id, hash)
+	r.Invalidate(ctx, id)
+	return apiErr
+}
+
+func (r *CachedUserRepo) UpdateRole(ctx context.Context, id string, role string) response.ApiError {
+	apiErr := r.next.UpdateRole(ctx, id, role)
+	r.Invalidate(ctx, id)
+	return apiErr
+}
+
+func (r *CachedUserRepo) RevokeTokens(ctx context.Context, id string, at time.Time) response.ApiError {
+	apiErr := r.next.RevokeTokens(ctx, id, at)
+	r.Invalidate(ctx, id)
+	return apiErr
+}
+
+func (r *CachedUserRepo) UpdateAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	apiErr := r.next.UpdateAttributes(ctx, id, attrs)
+	r.Invalidate(ctx, id)
+	return apiErr
+}
+
+func (r *CachedUserRepo) UpdateAvatar(ctx context.Context, id string, avatar *models.Avatar) (*models.Avatar, response.ApiError) {
+	previous, apiErr := r.next.UpdateAvatar(ctx, id, avatar)
+	r.Invalidate(ctx, id)
+	return previous, apiErr
+}
+
+func (r *CachedUserRepo) UpdateEmail(ctx context.Context, id string, from string, to string) response.ApiError {
+	apiErr := r.next.UpdateEmail(ctx, id, from, to)
+	r.Invalidate(ctx, id)
+	return apiErr
+}
+
+func (r *CachedUserRepo) SaveMany(ctx context.Context, users []models.User) []response.ApiError {
+	return r.next.SaveMany(ctx, users)
+}
+
+func (r *CachedUserRepo) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, response.ApiError) {
+	return r.next.ExistingEmails(ctx, emails)
+}
+
+// invalidatingAddressRepo drops the cached user of every address change, the
+// addresses being embedded in the user documents.
+type invalidatingAddressRepo struct {
+	next  AddressRepo
+	users *CachedUserRepo
+}
+
+func NewInvalidatingAddressRepo(next AddressRepo, users *CachedUserRepo) AddressRepo {
+	return invalidatingAddressRepo{next: next, users: users}
+}
+
+func (r invalidatingAddressRepo) List(ctx context.Context, userID string) ([]models.Address, response.ApiError) {
+	return r.next.List(ctx, userID)
+}
+
+func (r invalidatingAddressRepo) Add(ctx context.Context, userID string, a models.Address) (models.Address, response.ApiError) {
+	stored, apiErr := r.next.Add(ctx, userID, a)
+	r.users.Invalidate(ctx, userID)
+	return stored, apiErr
+}
+
+func (r invalidatingAddressRepo) Replace(ctx context.Context, userID string, a models.Address) response.ApiError {
+	apiErr := r.next.Replace(ctx, userID, a)
+	r.users.Invalidate(ctx, userID)
+	return apiErr
+}
+
+func (r invalidatingAddressRepo) Delete(ctx context.Context, userID string, id string) response.ApiError {
+	apiErr := r.next.Delete(ctx, userID, id)
+	r.users.Invalidate(ctx, userID)
+	return apiErr
+}
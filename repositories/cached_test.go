@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-api/cache"
+	"user-api/logging"
+	"user-api/metrics"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func cachedUser() models.User {
+	return models.User{ID: primitive.NewObjectID(), Name: "test", Email: "test@test.com", Role: models.RoleUser, Attributes: map[string]interface{}{"team": "core"}}
+}
+
+func TestCachedRepoHitsAfterMiss(t *testing.T) {
+	ctx := context.Background()
+	user := cachedUser()
+	id := user.ID.Hex()
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindById", mock.Anything, id).Return(user, response.ApiError{}).Once()
+	repo := NewCachedUserRepo(mockUserRepo, cache.NewLRU(10), time.Minute, logging.Discard())
+
+	hits := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(userCacheName, "hit"))
+	misses := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(userCacheName, "miss"))
+	_, apiErr := repo.FindById(ctx, id)
+	assert.Equal(t, 0, apiErr.Status)
+	u, apiErr := repo.FindById(ctx, id)
+	assert.Equal(t, 0, apiErr.Status)
+	byEmail, apiErr := repo.FindByField(ctx, user.Email, "email")
+	assert.Equal(t, 0, apiErr.Status)
+
+	assert.Equal(t, user, u)
+	assert.Equal(t, user, byEmail)
+	assert.Equal(t, hits+2, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(userCacheName, "hit")))
+	assert.Equal(t, misses+1, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(userCacheName, "miss")))
+	mockUserRepo.AssertNumberOfCalls(t, "FindById", 1)
+}
+
+func TestCachedRepoLeavesPasswordOut(t *testing.T) {
+	ctx := context.Background()
+	user := cachedUser()
+	user.Password = "$2a$04$hash"
+	id := user.ID.Hex()
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindById", mock.Anything, id).Return(user, response.ApiError{}).Once()
+	mockUserRepo.On("PasswordHash", mock.Anything, id).Return(user.Password, response.ApiError{})
+	lru := cache.NewLRU(10)
+	repo := NewCachedUserRepo(mockUserRepo, lru, time.Minute, logging.Discard())
+
+	_, apiErr := repo.FindById(ctx, id)
+	assert.Equal(t, 0, apiErr.Status)
+	cached, apiErr := repo.FindById(ctx, id)
+	assert.Equal(t, 0, apiErr.Status)
+	raw, _, _ := lru.Get(ctx, userKey(id))
+	hash, apiErr := repo.PasswordHash(ctx, id)
+	assert.Equal(t, 0, apiErr.Status)
+
+	assert.Empty(t, cached.Password)
+	assert.NotContains(t, string(raw), user.Password)
+	assert.Equal(t, user.Password, hash)
+}
+
+func TestCachedRepoInvalidatesOnWrites(t *testing.T) {
+	ctx := context.Background()
+	user := cachedUser()
+	id := user.ID.Hex()
+	admin := user
+	admin.Role = models.RoleAdmin
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindById", mock.Anything, id).Return(user, response.ApiError{}).Once()
+	mockUserRepo.On("UpdateRole", mock.Anything, id, models.RoleAdmin).Return(response.ApiError{})
+	mockUserRepo.On("FindById", mock.Anything, id).Return(admin, response.ApiError{})
+	lru := cache.NewLRU(10)
+	repo := NewCachedUserRepo(mockUserRepo, lru, time.Minute, logging.Discard())
+
+	repo.FindById(ctx, id)
+	assert.Equal(t, 0, repo.UpdateRole(ctx, id, models.RoleAdmin).Status)
+	u, _ := repo.FindById(ctx, id)
+
+	assert.Equal(t, models.RoleAdmin, u.Role)
+	mockUserRepo.AssertNumberOfCalls(t, "FindById", 2)
+}
+
+func TestCachedRepoDoesNotCacheBackStaleLookups(t *testing.T) {
+	ctx := context.Background()
+	user := cachedUser()
+	id := user.ID.Hex()
+	mockUserRepo := new(mocks.UserRepo)
+	repo := NewCachedUserRepo(mockUserRepo, cache.NewLRU(10), time.Minute, logging.Discard())
+	// the lookup reads the user, then the password changes before it caches it
+	mockUserRepo.On("FindById", mock.Anything, id).Run(func(mock.Arguments) {
+		repo.Invalidate(ctx, id)
+	}).Return(user, response.ApiError{})
+
+	repo.FindById(ctx, id)
+	repo.FindById(ctx, id)
+
+	mockUserRepo.AssertNumberOfCalls(t, "FindById", 2)
+}
+
+func TestCachedRepoChecksEmailOfIndex(t *testing.T) {
+	ctx := context.Background()
+	user := cachedUser()
+	changed := user
+	changed.Email = "new@test.com"
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindByField", mock.Anything, user.Email, "email").Return(user, response.ApiError{}).Once()
+	mockUserRepo.On("FindById", mock.Anything, user.ID.Hex()).Return(changed, response.ApiError{})
+	mockUserRepo.On("FindByField", mock.Anything, user.Email, "email").Return(models.User{}, response.ResourceNotFoundError)
+	lru := cache.NewLRU(10)
+	repo := NewCachedUserRepo(mockUserRepo, lru, time.Minute, logging.Discard())
+
+	repo.FindByField(ctx, user.Email, "email")
+	// the email changed on another instance once the cached user expired
+	lru.Delete(ctx, userKey(user.ID.Hex()))
+	repo.FindById(ctx, user.ID.Hex())
+	_, apiErr := repo.FindByField(ctx, user.Email, "email")
+
+	assert.Equal(t, response.ResourceNotFoundError.Code, apiErr.Code)
+}
+
+func TestCachedRepoSkipsErrors(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindByField", mock.Anything, "missing@test.com", "email").Return(models.User{}, response.ResourceNotFoundError)
+	lru := cache.NewLRU(10)
+	repo := NewCachedUserRepo(mockUserRepo, lru, time.Minute, logging.Discard())
+
+	repo.FindByField(ctx, "missing@test.com", "email")
+	repo.FindByField(ctx, "missing@test.com", "email")
+
+	assert.Equal(t, 0, lru.Len())
+	mockUserRepo.AssertNumberOfCalls(t, "FindByField", 2)
+}
+
+func TestInvalidatingAddressRepo(t *testing.T) {
+	ctx := context.Background()
+	user := cachedUser()
+	id := user.ID.Hex()
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindById", mock.Anything, id).Return(user, response.ApiError{})
+	mockAddressRepo := new(mocks.AddressRepo)
+	mockAddressRepo.On("Add", mock.Anything, id, mock.Anything).Return(models.Address{}, response.ApiError{})
+	users := NewCachedUserRepo(mockUserRepo, cache.NewLRU(10), time.Minute, logging.Discard())
+	addresses := NewInvalidatingAddressRepo(mockAddressRepo, users)
+
+	users.FindById(ctx, id)
+	addresses.Add(ctx, id, models.Address{})
+	users.FindById(ctx, id)
+
+	mockUserRepo.AssertNumberOfCalls(t, "FindById", 2)
+}
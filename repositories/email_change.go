@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EmailChangeRepo interface {
+	Save(ctx context.Context, c models.EmailChange) (models.EmailChange, response.ApiError)
+	// DeletePending deletes the unconfirmed changes of a user, a new
+	// request replacing them.
+	DeletePending(ctx context.Context, userID primitive.ObjectID) response.ApiError
+	Delete(ctx context.Context, id primitive.ObjectID) response.ApiError
+	// Confirm marks confirmed the change of confirmHash unless it expired,
+	// was confirmed or reverted, so a link is only used once.
+	Confirm(ctx context.Context, confirmHash string, at time.Time) (models.EmailChange, response.ApiError)
+	// Unconfirm undoes Confirm when the change could not be applied.
+	Unconfirm(ctx context.Context, id primitive.ObjectID) response.ApiError
+	// Revert marks reverted the change of revertHash unless it expired or
+	// was reverted, the returned change telling whether it was confirmed.
+	Revert(ctx context.Context, revertHash string, at time.Time) (models.EmailChange, response.ApiError)
+	// Unrevert undoes Revert when the change could not be undone.
+	Unrevert(ctx context.Context, id primitive.ObjectID) response.ApiError
+}
+
+type emailChangeMongoImpl struct {
+	db  *mongo.Collection
+	log *slog.Logger
+}
+
+func NewEmailChangeMongo(mongoDb *mongo.Collection, logger *slog.Logger) EmailChangeRepo {
+	return emailChangeMongoImpl{
+		db:  mongoDb,
+		log: logger.With("component", "email_change_repo"),
+	}
+}
+
+func (r emailChangeMongoImpl) Save(ctx context.Context, c models.EmailChange) (models.EmailChange, response.ApiError) {
+	res, err := r.db.InsertOne(ctx, c)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error saving email change", "error", err)
+		return c, internalError(err)
+	}
+	c.ID = res.InsertedID.(primitive.ObjectID)
+	return c, response.ApiError{}
+}
+
+func (r emailChangeMongoImpl) DeletePending(ctx context.Context, userID primitive.ObjectID) response.ApiError {
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "confirmed_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	if _, err := r.db.DeleteMany(ctx, filter); err != nil {
+		r.log.ErrorContext(ctx, "error deleting pending email changes", "user_id", userID.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+func (r emailChangeMongoImpl) Delete(ctx context.Context, id primitive.ObjectID) response.ApiError {
+	if _, err := r.db.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}}); err != nil {
+		r.log.ErrorContext(ctx, "error deleting email change", "email_change_id", id.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+func (r emailChangeMongoImpl) Confirm(ctx context.Context, confirmHash string, at time.Time) (models.EmailChange, response.ApiError) {
+	filter := bson.D{
+		{Key: "confirm_hash", Value: confirmHash},
+		{Key: "confirm_by", Value: bson.D{{Key: "$gt", Value: at}}},
+		{Key: "confirmed_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "reverted_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "confirmed_at", Value: at}}}}
+	return r.claim(ctx, filter, update)
+}
+
+func (r emailChangeMongoImpl) Unconfirm(ctx context.Context, id primitive.ObjectID) response.ApiError {
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "confirmed_at", Value: ""}}}}
+	if _, err := r.db.UpdateByID(ctx, id, update); err != nil {
+		r.log.ErrorContext(ctx, "error unconfirming email change", "email_change_id", id.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+func (r emailChangeMongoImpl) Revert(ctx context.Context, revertHash string, at time.Time) (models.EmailChange, response.ApiError) {
+	filter := bson.D{
+		{Key: "revert_hash", Value: revertHash},
+		{Key: "revert_by", Value: bson.D{{Key: "$gt", Value: at}}},
+		{Key: "reverted_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "reverted_at", Value: at}}}}
+	return r.claim(ctx, filter, update)
+}
+
+func (r emailChangeMongoImpl) Unrevert(ctx context.Context, id primitive.ObjectID) response.ApiError {
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "reverted_at", Value: ""}}}}
+	if _, err := r.db.UpdateByID(ctx, id, update); err != nil {
+		r.log.ErrorContext(ctx, "error unreverting email change", "email_change_id", id.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+// claim applies update to the change matching filter in one operation, so
+// concurrent uses of a link cannot both succeed.
+func (r emailChangeMongoImpl) claim(ctx context.Context, filter, update bson.D) (models.EmailChange, response.ApiError) {
+	c := models.EmailChange{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.db.FindOneAndUpdate(ctx, filter, update, opts).Decode(&c)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c, response.InvalidLinkError
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating email change", "error", err)
+		return c, internalError(err)
+	}
+	return c, response.ApiError{}
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"user-api/metrics"
+	"user-api/models"
+	"user-api/response"
+)
+
+type instrumentedUserRepo struct {
+	next UserRepo
+}
+
+// NewInstrumentedUserRepo decorates a UserRepo recording the latency of every
+// call in metrics.RepositoryDuration.
+func NewInstrumentedUserRepo(next UserRepo) UserRepo {
+	return instrumentedUserRepo{next: next}
+}
+
+func observe(method string, start time.Time, code string) {
+	if code == "" {
+		code = "OK"
+	}
+	metrics.RepositoryDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
+
+func (r instrumentedUserRepo) Save(ctx context.Context, u models.User) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.Save(ctx, u)
+	observe("Save", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, error) {
+	start := time.Now()
+	u, err := r.next.GetAll(ctx, filter, limit, page)
+	code := ""
+	if err != nil {
+		code = response.InternalServerError.Code
+	}
+	observe("GetAll", start, code)
+	return u, err
+}
+
+func (r instrumentedUserRepo) FindByField(ctx context.Context, value interface{}, key string) (models.User, response.ApiError) {
+	start := time.Now()
+	u, apiErr := r.next.FindByField(ctx, value, key)
+	observe("FindByField", start, apiErr.Code)
+	return u, apiErr
+}
+
+func (r instrumentedUserRepo) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
+	start := time.Now()
+	u, apiErr := r.next.FindById(ctx, id)
+	observe("FindById", start, apiErr.Code)
+	return u, apiErr
+}
+
+func (r instrumentedUserRepo) DeleteById(ctx context.Context, id string) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.DeleteById(ctx, id)
+	observe("DeleteById", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) UpdateByID(ctx context.Context, id string, u models.User) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.UpdateByID(ctx, id, u)
+	observe("UpdateByID", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) UpdatePassword(ctx context.Context, id string, hash string) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.UpdatePassword(ctx, id, hash)
+	observe("UpdatePassword", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) PasswordHash(ctx context.Context, id string) (string, response.ApiError) {
+	start := time.Now()
+	hash, apiErr := r.next.PasswordHash(ctx, id)
+	observe("PasswordHash", start, apiErr.Code)
+	return hash, apiErr
+}
+
+func (r instrumentedUserRepo) UpdateRole(ctx context.Context, id string, role string) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.UpdateRole(ctx, id, role)
+	observe("UpdateRole", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) RevokeTokens(ctx context.Context, id string, at time.Time) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.RevokeTokens(ctx, id, at)
+	observe("RevokeTokens", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) UpdateAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.UpdateAttributes(ctx, id, attrs)
+	observe("UpdateAttributes", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) UpdateAvatar(ctx context.Context, id string, avatar *models.Avatar) (*models.Avatar, response.ApiError) {
+	start := time.Now()
+	previous, apiErr := r.next.UpdateAvatar(ctx, id, avatar)
+	observe("UpdateAvatar", start, apiErr.Code)
+	return previous, apiErr
+}
+
+func (r instrumentedUserRepo) UpdateEmail(ctx context.Context, id string, from string, to string) response.ApiError {
+	start := time.Now()
+	apiErr := r.next.UpdateEmail(ctx, id, from, to)
+	observe("UpdateEmail", start, apiErr.Code)
+	return apiErr
+}
+
+func (r instrumentedUserRepo) SaveMany(ctx context.Context, users []models.User) []response.ApiError {
+	start := time.Now()
+	errs := r.next.SaveMany(ctx, users)
+	code := ""
+	for _, e := range errs {
+		if e.Code != "" {
+			code = e.Code
+			break
+		}
+	}
+	observe("SaveMany", start, code)
+	return errs
+}
+
+func (r instrumentedUserRepo) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, response.ApiError) {
+	start := time.Now()
+	existing, apiErr := r.next.ExistingEmails(ctx, emails)
+	observe("ExistingEmails", start, apiErr.Code)
+	return existing, apiErr
+}
+
+func (r instrumentedUserRepo) Each(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	start := time.Now()
+	err := r.next.Each(ctx, filter, fn)
+	code := ""
+	if err != nil {
+		code = response.InternalServerError.Code
+	}
+	observe("Each", start, code)
+	return err
+}
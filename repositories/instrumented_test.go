@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"user-api/metrics"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInstrumentedRepoDelegatesAndObserves(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepo)
+	repo := NewInstrumentedUserRepo(mockUserRepo)
+	user := models.User{Name: "test"}
+	mockUserRepo.On("FindById", mock.Anything, "id").Return(user, response.ApiError{})
+	mockUserRepo.On("DeleteById", mock.Anything, "id").Return(response.ResourceNotFoundError)
+
+	before := testutil.CollectAndCount(metrics.RepositoryDuration)
+	u, apiErr := repo.FindById(context.Background(), "id")
+	delErr := repo.DeleteById(context.Background(), "id")
+
+	assert.Equal(t, user.Name, u.Name)
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, response.ResourceNotFoundError.Code, delErr.Code)
+	assert.Equal(t, before+2, testutil.CollectAndCount(metrics.RepositoryDuration))
+	mockUserRepo.AssertExpectations(t)
+}
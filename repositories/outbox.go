@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OutboxRepo stores the events of the user changes until the relay
+// published them.
+type OutboxRepo interface {
+	// Append stores events with the next sequence numbers of their users,
+	// to be called in the transaction of the change making them.
+	Append(ctx context.Context, events ...models.Event) response.ApiError
+	// Heads returns the oldest unpublished event of each user, as long as
+	// it is due at now, at most limit of them.
+	Heads(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, response.ApiError)
+	// MarkSent records that the event id was published to sink.
+	MarkSent(ctx context.Context, id primitive.ObjectID, sink string) response.ApiError
+	MarkPublished(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError
+	// MarkFailed records a failed relay of the event id, retried at
+	// nextAttemptAt.
+	MarkFailed(ctx context.Context, id primitive.ObjectID, lastError string, nextAttemptAt time.Time) response.ApiError
+	// Lease makes owner the only relay until ttl elapses, held is false
+	// while another owner has the lease.
+	Lease(ctx context.Context, owner string, ttl time.Duration) (held bool, apiErr response.ApiError)
+	Release(ctx context.Context, owner string) response.ApiError
+}
+
+const outboxLeaseID = "relay"
+
+type outboxMongoImpl struct {
+	db        *mongo.Collection
+	sequences *mongo.Collection
+	leases    *mongo.Collection
+	log       *slog.Logger
+}
+
+// NewOutboxMongo keeps the events in outbox, the last sequence number of
+// every user in outbox_sequences and the lease of the relay in
+// outbox_leases of db.
+func NewOutboxMongo(db *mongo.Database, logger *slog.Logger) OutboxRepo {
+	return outboxMongoImpl{
+		db:        db.Collection("outbox"),
+		sequences: db.Collection("outbox_sequences"),
+		leases:    db.Collection("outbox_leases"),
+		log:       logger.With("component", "outbox_repo"),
+	}
+}
+
+// Append increments the sequence of the user, which makes concurrent
+// transactions changing the same user conflict so sequence numbers follow
+// the commits.
+func (r outboxMongoImpl) Append(ctx context.Context, events ...models.Event) response.ApiError {
+	for _, e := range events {
+		seq := struct {
+			Seq int64 `bson:"seq"`
+		}{}
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+		err := r.sequences.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: e.UserID}}, bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: 1}}}}, opts).Decode(&seq)
+		if err != nil {
+			r.log.ErrorContext(ctx, "error incrementing event sequence", "user_id", e.UserID, "error", err)
+			return internalError(err)
+		}
+
+		e.Seq = seq.Seq
+		o := models.OutboxEvent{Event: e, NextAttemptAt: e.OccurredAt}
+		if _, err := r.db.InsertOne(ctx, o); err != nil {
+			r.log.ErrorContext(ctx, "error saving outbox event", "event", e.Type, "user_id", e.UserID, "error", err)
+			return internalError(err)
+		}
+	}
+	return response.ApiError{}
+}
+
+func (r outboxMongoImpl) Heads(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, response.ApiError) {
+	result := make([]models.OutboxEvent, 0)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "published", Value: false}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "user_id", Value: 1}, {Key: "seq", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$user_id"}, {Key: "head", Value: bson.D{{Key: "$first", Value: "$$ROOT"}}}}}},
+		{{Key: "$replaceWith", Value: "$head"}},
+		{{Key: "$match", Value: bson.D{{Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: now}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "occurred_at", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	curr, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error finding unpublished events", "error", err)
+		return result, internalError(err)
+	}
+	if err := curr.All(ctx, &result); err != nil {
+		r.log.ErrorContext(ctx, "error decoding outbox events", "error", err)
+		return result, internalError(err)
+	}
+	return result, response.ApiError{}
+}
+
+func (r outboxMongoImpl) MarkSent(ctx context.Context, id primitive.ObjectID, sink string) response.ApiError {
+	return r.update(ctx, id, bson.D{{Key: "$addToSet", Value: bson.D{{Key: "sinks", Value: sink}}}})
+}
+
+func (r outboxMongoImpl) MarkPublished(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError {
+	return r.update(ctx, id, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "published", Value: true}, {Key: "published_at", Value: at}}},
+		{Key: "$unset", Value: bson.D{{Key: "last_error", Value: ""}}},
+	})
+}
+
+func (r outboxMongoImpl) MarkFailed(ctx context.Context, id primitive.ObjectID, lastError string, nextAttemptAt time.Time) response.ApiError {
+	return r.update(ctx, id, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "last_error", Value: lastError}, {Key: "next_attempt_at", Value: nextAttemptAt}}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	})
+}
+
+func (r outboxMongoImpl) update(ctx context.Context, id primitive.ObjectID, update bson.D) response.ApiError {
+	if _, err := r.db.UpdateByID(ctx, id, update); err != nil {
+		r.log.ErrorContext(ctx, "error updating outbox event", "event_id", id.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+// Lease upserts the lease when it is missing, expired or already ours. When
+// another owner holds it the filter misses and the upsert fails on the _id
+// unique index.
+func (r outboxMongoImpl) Lease(ctx context.Context, owner string, ttl time.Duration) (bool, response.ApiError) {
+	now := time.Now().UTC()
+	filter := bson.D{
+		{Key: "_id", Value: outboxLeaseID},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lt", Value: now}}}},
+			bson.D{{Key: "owner", Value: owner}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "owner", Value: owner},
+		{Key: "expires_at", Value: now.Add(ttl)},
+	}}}
+
+	_, err := r.leases.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, response.ApiError{}
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error taking relay lease", "error", err)
+		return false, internalError(err)
+	}
+	return true, response.ApiError{}
+}
+
+func (r outboxMongoImpl) Release(ctx context.Context, owner string) response.ApiError {
+	if _, err := r.leases.DeleteOne(ctx, bson.D{{Key: "_id", Value: outboxLeaseID}, {Key: "owner", Value: owner}}); err != nil {
+		r.log.ErrorContext(ctx, "error releasing relay lease", "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
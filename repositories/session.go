@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepo interface {
+	Save(ctx context.Context, s models.Session) (models.Session, response.ApiError)
+	FindByFamily(ctx context.Context, familyID string) (models.Session, response.ApiError)
+	ListActive(ctx context.Context, userID string, now time.Time) ([]models.Session, response.ApiError)
+	Revoke(ctx context.Context, userID string, id string, at time.Time) response.ApiError
+	RevokeAllExcept(ctx context.Context, userID string, keepFamilyID string, at time.Time) response.ApiError
+	TouchLastSeen(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError
+}
+
+type sessionMongoImpl struct {
+	db  *mongo.Collection
+	log *slog.Logger
+}
+
+func NewSessionMongo(mongoDb *mongo.Collection, logger *slog.Logger) SessionRepo {
+	return sessionMongoImpl{
+		db:  mongoDb,
+		log: logger.With("component", "session_repo"),
+	}
+}
+
+func (r sessionMongoImpl) Save(ctx context.Context, s models.Session) (models.Session, response.ApiError) {
+	res, err := r.db.InsertOne(ctx, s)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error saving session", "error", err)
+		return s, internalError(err)
+	}
+	s.ID = res.InsertedID.(primitive.ObjectID)
+	return s, response.ApiError{}
+}
+
+func (r sessionMongoImpl) FindByFamily(ctx context.Context, familyID string) (models.Session, response.ApiError) {
+	s := models.Session{}
+	err := r.db.FindOne(ctx, bson.D{{Key: "family_id", Value: familyID}}).Decode(&s)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return s, response.ResourceNotFoundError
+		}
+		r.log.ErrorContext(ctx, "error finding session", "error", err)
+		return s, internalError(err)
+	}
+	return s, response.ApiError{}
+}
+
+func (r sessionMongoImpl) ListActive(ctx context.Context, userID string, now time.Time) ([]models.Session, response.ApiError) {
+	result := make([]models.Session, 0)
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return result, response.BadRequestError
+	}
+
+	filter := bson.D{
+		{Key: "user_id", Value: objID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+
+	curr, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error listing sessions", "error", err)
+		return result, internalError(err)
+	}
+	if err := curr.All(ctx, &result); err != nil {
+		r.log.ErrorContext(ctx, "error decoding sessions", "error", err)
+		return result, internalError(err)
+	}
+
+	return result, response.ApiError{}
+}
+
+func (r sessionMongoImpl) Revoke(ctx context.Context, userID string, id string, at time.Time) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return response.BadRequestError
+	}
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return response.BadRequestError
+	}
+
+	filter := bson.D{{Key: "_id", Value: objID}, {Key: "user_id", Value: ownerID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}}
+
+	res, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error revoking session", "session_id", id, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+	return response.ApiError{}
+}
+
+// RevokeAllExcept revokes every session of userID but the one of
+// keepFamilyID, which may be empty to revoke them all.
+func (r sessionMongoImpl) RevokeAllExcept(ctx context.Context, userID string, keepFamilyID string, at time.Time) response.ApiError {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return response.BadRequestError
+	}
+
+	filter := bson.D{
+		{Key: "user_id", Value: ownerID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "family_id", Value: bson.D{{Key: "$ne", Value: keepFamilyID}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}}
+
+	if _, err := r.db.UpdateMany(ctx, filter, update); err != nil {
+		r.log.ErrorContext(ctx, "error revoking sessions", "user_id", userID, "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+func (r sessionMongoImpl) TouchLastSeen(ctx context.Context, id primitive.ObjectID, at time.Time) response.ApiError {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_seen_at", Value: at}}}}
+	if _, err := r.db.UpdateByID(ctx, id, update); err != nil {
+		r.log.ErrorContext(ctx, "error updating session last seen", "session_id", id.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
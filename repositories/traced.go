@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"user-api/models"
+	"user-api/response"
+	"user-api/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracedUserRepo struct {
+	next UserRepo
+}
+
+// NewTracedUserRepo decorates a UserRepo opening a client span around every
+// call, child of the span carried in the call context.
+func NewTracedUserRepo(next UserRepo) UserRepo {
+	return tracedUserRepo{next: next}
+}
+
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", "mongodb"))
+	return tracing.Start(ctx, "UserRepo."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func (r tracedUserRepo) Save(ctx context.Context, u models.User) response.ApiError {
+	ctx, span := startSpan(ctx, "Save")
+	defer span.End()
+
+	apiErr := r.next.Save(ctx, u)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, error) {
+	ctx, span := startSpan(ctx, "GetAll", attribute.Int64("limit", int64(limit)), attribute.Int64("page", int64(page)))
+	defer span.End()
+
+	u, err := r.next.GetAll(ctx, filter, limit, page)
+	tracing.RecordError(span, err)
+	return u, err
+}
+
+func (r tracedUserRepo) FindByField(ctx context.Context, value interface{}, key string) (models.User, response.ApiError) {
+	ctx, span := startSpan(ctx, "FindByField", attribute.String("key", key))
+	defer span.End()
+
+	u, apiErr := r.next.FindByField(ctx, value, key)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return u, apiErr
+}
+
+func (r tracedUserRepo) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
+	ctx, span := startSpan(ctx, "FindById", attribute.String("user.id", id))
+	defer span.End()
+
+	u, apiErr := r.next.FindById(ctx, id)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return u, apiErr
+}
+
+func (r tracedUserRepo) DeleteById(ctx context.Context, id string) response.ApiError {
+	ctx, span := startSpan(ctx, "DeleteById", attribute.String("user.id", id))
+	defer span.End()
+
+	apiErr := r.next.DeleteById(ctx, id)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) UpdateByID(ctx context.Context, id string, u models.User) response.ApiError {
+	ctx, span := startSpan(ctx, "UpdateByID", attribute.String("user.id", id))
+	defer span.End()
+
+	apiErr := r.next.UpdateByID(ctx, id, u)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) UpdatePassword(ctx context.Context, id string, hash string) response.ApiError {
+	ctx, span := startSpan(ctx, "UpdatePassword", attribute.String("user.id", id))
+	defer span.End()
+
+	apiErr := r.next.UpdatePassword(ctx, id, hash)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) PasswordHash(ctx context.Context, id string) (string, response.ApiError) {
+	ctx, span := startSpan(ctx, "PasswordHash", attribute.String("user.id", id))
+	defer span.End()
+
+	hash, apiErr := r.next.PasswordHash(ctx, id)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return hash, apiErr
+}
+
+func (r tracedUserRepo) UpdateRole(ctx context.Context, id string, role string) response.ApiError {
+	ctx, span := startSpan(ctx, "UpdateRole", attribute.String("user.id", id))
+	defer span.End()
+
+	apiErr := r.next.UpdateRole(ctx, id, role)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) RevokeTokens(ctx context.Context, id string, at time.Time) response.ApiError {
+	ctx, span := startSpan(ctx, "RevokeTokens", attribute.String("user.id", id))
+	defer span.End()
+
+	apiErr := r.next.RevokeTokens(ctx, id, at)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) UpdateAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	ctx, span := startSpan(ctx, "UpdateAttributes", attribute.String("user.id", id))
+	defer span.End()
+
+	apiErr := r.next.UpdateAttributes(ctx, id, attrs)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) UpdateAvatar(ctx context.Context, id string, avatar *models.Avatar) (*models.Avatar, response.ApiError) {
+	ctx, span := startSpan(ctx, "UpdateAvatar", attribute.String("user.id", id))
+	defer span.End()
+
+	previous, apiErr := r.next.UpdateAvatar(ctx, id, avatar)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return previous, apiErr
+}
+
+func (r tracedUserRepo) UpdateEmail(ctx context.Context, id string, from string, to string) response.ApiError {
+	ctx, span := startSpan(ctx, "UpdateEmail", attribute.String("user.id", id))
+	defer span.End()
+
+	apiErr := r.next.UpdateEmail(ctx, id, from, to)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (r tracedUserRepo) SaveMany(ctx context.Context, users []models.User) []response.ApiError {
+	ctx, span := startSpan(ctx, "SaveMany", attribute.Int("db.batch_size", len(users)))
+	defer span.End()
+
+	errs := r.next.SaveMany(ctx, users)
+	for _, e := range errs {
+		if e.Status != 0 {
+			tracing.RecordApiError(span, e.Code, e.Status)
+			break
+		}
+	}
+	return errs
+}
+
+func (r tracedUserRepo) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, response.ApiError) {
+	ctx, span := startSpan(ctx, "ExistingEmails", attribute.Int("db.batch_size", len(emails)))
+	defer span.End()
+
+	existing, apiErr := r.next.ExistingEmails(ctx, emails)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return existing, apiErr
+}
+
+func (r tracedUserRepo) Each(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	ctx, span := startSpan(ctx, "Each")
+	defer span.End()
+
+	err := r.next.Each(ctx, filter, fn)
+	tracing.RecordError(span, err)
+	return err
+}
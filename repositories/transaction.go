@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// transactionAttempts bounds the runs of a transaction failing with a
+// transient error, such as a write conflicting with a concurrent
+// transaction on the same user.
+const transactionAttempts = 3
+
+// The label and code of the mongo errors a transaction run again may not
+// meet.
+const (
+	transientTransactionError = "TransientTransactionError"
+	writeConflict             = 112
+)
+
+// Transactor runs a function in a transaction, the repositories called with
+// the context it is given joining the transaction.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) response.ApiError) response.ApiError
+}
+
+type mongoTransactor struct {
+	client *mongo.Client
+	log    *slog.Logger
+}
+
+// NewMongoTransactor returns a Transactor of client, whose server must be a
+// replica set or a sharded cluster, as CheckTransactions makes sure.
+func NewMongoTransactor(client *mongo.Client, logger *slog.Logger) Transactor {
+	return mongoTransactor{client: client, log: logger.With("component", "transactor")}
+}
+
+// CheckTransactions fails unless the server of client runs transactions,
+// a standalone server being unable to.
+func CheckTransactions(ctx context.Context, client *mongo.Client) error {
+	hello := struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}{}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return fmt.Errorf("checking that mongo runs transactions: %w", err)
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return errors.New("mongo is a standalone server, which cannot run the transactions of the user changes: run it as a replica set, a single node one being enough")
+	}
+	return nil
+}
+
+// WithTransaction commits the writes of fn unless it fails, running it again
+// when it fails with a transient error.
+func (t mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) response.ApiError) response.ApiError {
+	for attempt := 1; ; attempt++ {
+		apiErr := t.run(ctx, fn)
+		if !apiErr.Transient || attempt == transactionAttempts || ctx.Err() != nil {
+			return apiErr
+		}
+		t.log.InfoContext(ctx, "retrying transaction", "attempt", attempt)
+	}
+}
+
+func (t mongoTransactor) run(ctx context.Context, fn func(ctx context.Context) response.ApiError) response.ApiError {
+	session, err := t.client.StartSession()
+	if err != nil {
+		t.log.ErrorContext(ctx, "error starting session", "error", err)
+		return response.InternalServerError
+	}
+	defer session.EndSession(ctx)
+
+	var apiErr response.ApiError
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		apiErr = fn(ctx)
+		if apiErr.Status != 0 {
+			return nil, apiErr
+		}
+		return nil, nil
+	})
+	if err != nil && !errors.As(err, &response.ApiError{}) {
+		t.log.ErrorContext(ctx, "error committing transaction", "error", err)
+		return internalError(err)
+	}
+	return apiErr
+}
+
+// internalError is the error the repositories return when mongo fails with
+// err, transient when running the transaction again may succeed.
+func internalError(err error) response.ApiError {
+	apiErr := response.InternalServerError
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		apiErr.Transient = serverErr.HasErrorLabel(transientTransactionError) || serverErr.HasErrorCode(writeConflict)
+	}
+	return apiErr
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestInternalErrorMarksTransientFailures(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"transient label", mongo.CommandError{Code: 251, Labels: []string{transientTransactionError}}, true},
+		{"write conflict", mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: writeConflict}}}, true},
+		{"wrapped", fmt.Errorf("saving: %w", mongo.CommandError{Code: writeConflict}), true},
+		{"duplicate key", mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}, false},
+		{"other", errors.New("connection refused"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			apiErr := internalError(c.err)
+
+			assert.Equal(t, response.InternalServerError.Code, apiErr.Code)
+			assert.Equal(t, c.transient, apiErr.Transient)
+		})
+	}
+}
@@ -2,7 +2,12 @@ package repositories
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+	"user-api/logging"
 	"user-api/models"
 	"user-api/response"
 
@@ -10,127 +15,466 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type UserRepo interface {
-	Save(u models.User) response.ApiError
-	GetAll(limit uint64, page uint64) ([]models.User, error)
-	FindByField(value interface{}, key string) (models.User, response.ApiError)
-	FindById(id string) (models.User, response.ApiError)
-	DeleteById(id string) response.ApiError
-	UpdateByID(id string, u models.User) (apiErr response.ApiError)
+	Save(ctx context.Context, u models.User) response.ApiError
+	GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, error)
+	// Each streams every user matching filter to fn through a cursor,
+	// stopping at the first error fn returns.
+	Each(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error
+	FindByField(ctx context.Context, value interface{}, key string) (models.User, response.ApiError)
+	FindById(ctx context.Context, id string) (models.User, response.ApiError)
+	// PasswordHash reads the password hash of a user, which cached users
+	// lack.
+	PasswordHash(ctx context.Context, id string) (string, response.ApiError)
+	DeleteById(ctx context.Context, id string) response.ApiError
+	UpdateByID(ctx context.Context, id string, u models.User) (apiErr response.ApiError)
+	UpdatePassword(ctx context.Context, id string, hash string) response.ApiError
+	UpdateRole(ctx context.Context, id string, role string) response.ApiError
+	// RevokeTokens rejects the jwts without a session issued until at.
+	RevokeTokens(ctx context.Context, id string, at time.Time) response.ApiError
+	// UpdateAttributes replaces the custom attributes of a user.
+	UpdateAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError
+	// UpdateAvatar replaces the avatar of a user, nil removing it, and
+	// returns the previous one so its images can be deleted.
+	UpdateAvatar(ctx context.Context, id string, avatar *models.Avatar) (*models.Avatar, response.ApiError)
+	// UpdateEmail replaces the email of a user as long as it still is from,
+	// the unique index rejecting emails in use.
+	UpdateEmail(ctx context.Context, id string, from string, to string) response.ApiError
+	// SaveMany inserts users in one round trip, the returned errors are
+	// indexed like users.
+	SaveMany(ctx context.Context, users []models.User) []response.ApiError
+	ExistingEmails(ctx context.Context, emails []string) (map[string]bool, response.ApiError)
 }
 
 type userMongoImpl struct {
 	db  *mongo.Collection
-	ctx context.Context
+	log *slog.Logger
 }
 
-func NewUserMongo(mongoDb *mongo.Collection, ctx context.Context) UserRepo {
+func NewUserMongo(mongoDb *mongo.Collection, logger *slog.Logger) UserRepo {
 	return userMongoImpl{
 		db:  mongoDb,
-		ctx: ctx,
+		log: logger.With("component", "user_repo"),
 	}
 }
 
-func (m userMongoImpl) Save(u models.User) response.ApiError {
-	_, err := m.db.InsertOne(m.ctx, u)
+func (m userMongoImpl) Save(ctx context.Context, u models.User) response.ApiError {
+	res, err := m.db.InsertOne(ctx, u)
 	if err != nil {
-		log.Printf("[UserRepo] Error saving user %s", err.Error())
-		return response.InternalServerError
+		m.log.ErrorContext(ctx, "error saving user", "error", err)
+		return internalError(err)
 	}
-	log.Printf("[UserRepo] user inserted %v", u)
+	m.log.DebugContext(ctx, "user inserted", "user_id", res.InsertedID)
 	return response.ApiError{}
 }
 
-func (m userMongoImpl) GetAll(limit uint64, page uint64) ([]models.User, error) {
+// filterDoc builds the query of filter, the role filter also matches users
+// stored before roles existed as they are regular users.
+func filterDoc(filter models.UserFilter) bson.D {
+	doc := bson.D{}
+	if filter.Email != "" {
+		doc = append(doc, bson.E{Key: "email", Value: filter.Email})
+	}
+	if filter.Locale != "" {
+		doc = append(doc, bson.E{Key: "locale", Value: filter.Locale})
+	}
+	switch filter.Role {
+	case "":
+	case models.RoleUser:
+		doc = append(doc, bson.E{Key: "role", Value: bson.D{{Key: "$in", Value: bson.A{models.RoleUser, nil}}}})
+	default:
+		doc = append(doc, bson.E{Key: "role", Value: filter.Role})
+	}
+	// the names are checked against the schema, sorted for stable queries
+	names := make([]string, 0, len(filter.Attributes))
+	for name := range filter.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc = append(doc, bson.E{Key: "attributes." + name, Value: filter.Attributes[name]})
+	}
+	return doc
+}
+
+func (m userMongoImpl) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, error) {
 	result := make([]models.User, 0)
 
 	l := int64(limit)
 	skip := int64(page*limit - limit)
 	opt := options.FindOptions{Limit: &l, Skip: &skip}
 
-	curr, err := m.db.Find(m.ctx, bson.D{}, &opt)
+	curr, err := m.db.Find(ctx, filterDoc(filter), &opt)
 	if err != nil {
 		return result, err
 	}
 
-	for curr.Next(m.ctx) {
+	for curr.Next(ctx) {
 		var el models.User
 		if err := curr.Decode(&el); err != nil {
-			log.Println(err)
+			m.log.ErrorContext(ctx, "error decoding user", "error", err)
 			return nil, err
 		}
 
 		result = append(result, el)
 	}
 
-	log.Printf("[UserRepo] Users found %v", len(result))
+	m.log.DebugContext(ctx, "users found", "count", len(result))
 
 	return result, nil
 }
 
-func (r userMongoImpl) FindByField(value interface{}, key string) (models.User, response.ApiError) {
+func (m userMongoImpl) Each(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(1000)
+	curr, err := m.db.Find(ctx, filterDoc(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var u models.User
+		if err := curr.Decode(&u); err != nil {
+			m.log.ErrorContext(ctx, "error decoding user", "error", err)
+			return err
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+
+	return curr.Err()
+}
+
+func (r userMongoImpl) FindByField(ctx context.Context, value interface{}, key string) (models.User, response.ApiError) {
 	u := models.User{}
-	err := r.db.FindOne(r.ctx, bson.D{{key, value}}, options.FindOne()).Decode(&u)
+	err := r.db.FindOne(ctx, bson.D{{Key: key, Value: value}}, options.FindOne()).Decode(&u)
 
 	if err != nil {
 		if err.Error() == mongo.ErrNoDocuments.Error() {
-			log.Printf("[UserRepo] No document found with value %s and key %s", value, key)
+			r.log.DebugContext(ctx, "no document found", "key", key, "value_fingerprint", logging.Fingerprint(fmt.Sprint(value)))
 			return u, response.ResourceNotFoundError
 		}
-		log.Printf("[UserRepo] Error getting document with value %s and key %s, error: %s", value, key, err.Error())
-		return u, response.InternalServerError
+		r.log.ErrorContext(ctx, "error getting document", "key", key, "value_fingerprint", logging.Fingerprint(fmt.Sprint(value)), "error", err)
+		return u, internalError(err)
 	}
 	return u, response.ApiError{}
 }
 
-func (r userMongoImpl) FindById(id string) (models.User, response.ApiError) {
+func (r userMongoImpl) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
 	objID, err := primitive.ObjectIDFromHex(id)
 
 	if err != nil {
-		log.Printf("[UserRepo] Invalid id format %s", id)
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
 		return models.User{}, response.BadRequestError
 	}
 
-	return r.FindByField(objID, "_id")
+	return r.FindByField(ctx, objID, "_id")
 }
 
-func (r userMongoImpl) DeleteById(id string) response.ApiError {
+func (r userMongoImpl) PasswordHash(ctx context.Context, id string) (string, response.ApiError) {
 	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return "", response.BadRequestError
+	}
 
+	u := models.User{}
+	opts := options.FindOne().SetProjection(bson.D{{Key: "password", Value: 1}})
+	err = r.db.FindOne(ctx, bson.D{{Key: "_id", Value: objID}}, opts).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", response.ResourceNotFoundError
+	}
 	if err != nil {
-		log.Printf("[UserRepo] Invalid id format %s", id)
+		r.log.ErrorContext(ctx, "error reading password hash", "user_id", id, "error", err)
+		return "", internalError(err)
+	}
+	return u.Password, response.ApiError{}
+}
+
+func (r userMongoImpl) DeleteById(ctx context.Context, id string) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
 		return response.BadRequestError
 	}
 
-	_, err = r.db.DeleteOne(r.ctx, bson.D{{"_id", objID}}, options.Delete())
+	_, err = r.db.DeleteOne(ctx, bson.D{{Key: "_id", Value: objID}}, options.Delete())
 
 	if err != nil {
-		log.Printf("[UserRepo] Unexpected error deleting user by id: %s", err.Error())
-		return response.InternalServerError
+		r.log.ErrorContext(ctx, "unexpected error deleting user", "user_id", id, "error", err)
+		return internalError(err)
 	}
 
 	return response.ApiError{}
 }
 
-func (r userMongoImpl) UpdateByID(id string, u models.User) (apiErr response.ApiError) {
+func (r userMongoImpl) UpdateByID(ctx context.Context, id string, u models.User) (apiErr response.ApiError) {
 	objID, err := primitive.ObjectIDFromHex(id)
 
 	if err != nil {
-		log.Printf("[UserRepo] Invalid id format %s", id)
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
 		return response.BadRequestError
 	}
 
-	filter := bson.D{{"_id", objID}}
-	update := bson.D{{"$set", bson.D{{"age", u.Age}, {"address", u.Address}, {"Name", u.Name}}}}
+	if u.Address != "" {
+		if apiErr := r.reparseLegacyAddress(ctx, objID, u.Address); apiErr.Status != 0 {
+			return apiErr
+		}
+	}
+
+	filter := bson.D{{Key: "_id", Value: objID}}
+	set := bson.D{{Key: "name", Value: u.Name}}
+	if u.Address != "" {
+		set = append(set, bson.E{Key: "address", Value: u.Address})
+	}
+	if u.Locale != "" {
+		set = append(set, bson.E{Key: "locale", Value: u.Locale})
+	}
+	if u.Attributes != nil {
+		set = append(set, bson.E{Key: "attributes", Value: u.Attributes})
+	}
+	update := bson.D{}
+	if u.BirthDate != nil {
+		// the birth date supersedes the legacy age
+		set = append(set, bson.E{Key: "birth_date", Value: u.BirthDate}, bson.E{Key: "birth_date_estimated", Value: u.BirthDateEstimated})
+		update = append(update, bson.E{Key: "$unset", Value: bson.D{{Key: "age", Value: ""}}})
+	}
+	update = append(bson.D{{Key: "$set", Value: set}}, update...)
 
-	_, err = r.db.UpdateOne(r.ctx, filter, update)
+	_, err = r.db.UpdateOne(ctx, filter, update)
 
 	if err != nil {
-		log.Printf("[UserRepo] Error updating user document: %s", err.Error())
-		return response.InternalServerError
+		r.log.ErrorContext(ctx, "error updating user document", "user_id", id, "error", err)
+		return internalError(err)
 	}
 
 	return
 }
+
+// reparseLegacyAddress replaces the addresses parsed from the legacy address
+// of a user when it changes to address. Users who have structured addresses
+// of their own keep them.
+func (r userMongoImpl) reparseLegacyAddress(ctx context.Context, objID primitive.ObjectID, address string) response.ApiError {
+	filter := bson.D{
+		{Key: "_id", Value: objID},
+		{Key: "address", Value: bson.D{{Key: "$ne", Value: address}}},
+		{Key: "addresses", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$elemMatch", Value: bson.D{{Key: "legacy", Value: bson.D{{Key: "$ne", Value: true}}}}}}}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "addresses", Value: []models.Address{models.LegacyAddress(address)}}}}}
+	if _, err := r.db.UpdateOne(ctx, filter, update); err != nil {
+		r.log.ErrorContext(ctx, "error parsing the legacy address", "user_id", objID.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+func (r userMongoImpl) UpdatePassword(ctx context.Context, id string, hash string) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return response.BadRequestError
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: hash}}}}
+	res, err := r.db.UpdateByID(ctx, objID, update)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating user password", "user_id", id, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+
+	return response.ApiError{}
+}
+
+func (r userMongoImpl) UpdateRole(ctx context.Context, id string, role string) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return response.BadRequestError
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "role", Value: role}}}}
+	res, err := r.db.UpdateByID(ctx, objID, update)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating user role", "user_id", id, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+
+	return response.ApiError{}
+}
+
+func (r userMongoImpl) RevokeTokens(ctx context.Context, id string, at time.Time) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return response.BadRequestError
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "tokens_revoked_at", Value: at}}}}
+	if _, err := r.db.UpdateByID(ctx, objID, update); err != nil {
+		r.log.ErrorContext(ctx, "error revoking user tokens", "user_id", id, "error", err)
+		return internalError(err)
+	}
+
+	return response.ApiError{}
+}
+
+func (r userMongoImpl) UpdateAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return response.BadRequestError
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "attributes", Value: attrs}}}}
+	if len(attrs) == 0 {
+		update = bson.D{{Key: "$unset", Value: bson.D{{Key: "attributes", Value: ""}}}}
+	}
+	res, err := r.db.UpdateByID(ctx, objID, update)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating user attributes", "user_id", id, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+
+	return response.ApiError{}
+}
+
+func (r userMongoImpl) UpdateAvatar(ctx context.Context, id string, avatar *models.Avatar) (*models.Avatar, response.ApiError) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return nil, response.BadRequestError
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "avatar", Value: avatar}}}}
+	if avatar == nil {
+		update = bson.D{{Key: "$unset", Value: bson.D{{Key: "avatar", Value: ""}}}}
+	}
+	opts := options.FindOneAndUpdate().
+		SetProjection(bson.D{{Key: "avatar", Value: 1}}).
+		SetReturnDocument(options.Before)
+	var previous models.User
+	err = r.db.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: objID}}, update, opts).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, response.ResourceNotFoundError
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating user avatar", "user_id", id, "error", err)
+		return nil, internalError(err)
+	}
+
+	return previous.Avatar, response.ApiError{}
+}
+
+func (r userMongoImpl) UpdateEmail(ctx context.Context, id string, from string, to string) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return response.BadRequestError
+	}
+
+	filter := bson.D{{Key: "_id", Value: objID}, {Key: "email", Value: from}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "email", Value: to}}}}
+	res, err := r.db.UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return response.EmailAlreadyInUse
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating user email", "user_id", id, "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+
+	return response.ApiError{}
+}
+
+func (m userMongoImpl) SaveMany(ctx context.Context, users []models.User) []response.ApiError {
+	errs := make([]response.ApiError, len(users))
+	if len(users) == 0 {
+		return errs
+	}
+
+	docs := make([]interface{}, len(users))
+	for i, u := range users {
+		docs[i] = u
+	}
+
+	_, err := m.db.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return errs
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		m.log.ErrorContext(ctx, "error inserting users", "error", err)
+		for i := range errs {
+			errs[i] = internalError(err)
+		}
+		return errs
+	}
+
+	for _, we := range bulkErr.WriteErrors {
+		if mongo.IsDuplicateKeyError(we) {
+			errs[we.Index] = response.EmailAlreadyInUse
+			continue
+		}
+		m.log.ErrorContext(ctx, "error inserting user", "index", we.Index, "error", we.Message)
+		errs[we.Index] = internalError(we)
+	}
+	return errs
+}
+
+func (m userMongoImpl) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, response.ApiError) {
+	existing := make(map[string]bool)
+	if len(emails) == 0 {
+		return existing, response.ApiError{}
+	}
+
+	filter := bson.D{{Key: "email", Value: bson.D{{Key: "$in", Value: emails}}}}
+	opts := options.Find().SetProjection(bson.D{{Key: "email", Value: 1}})
+	curr, err := m.db.Find(ctx, filter, opts)
+	if err != nil {
+		m.log.ErrorContext(ctx, "error finding existing emails", "error", err)
+		return existing, internalError(err)
+	}
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var u models.User
+		if err := curr.Decode(&u); err != nil {
+			m.log.ErrorContext(ctx, "error decoding user", "error", err)
+			return existing, internalError(err)
+		}
+		existing[u.Email] = true
+	}
+	if err := curr.Err(); err != nil {
+		m.log.ErrorContext(ctx, "error iterating users", "error", err)
+		return existing, internalError(err)
+	}
+
+	return existing, response.ApiError{}
+}
+
+func (m userMongoImpl) Name() string {
+	return "mongo"
+}
+
+// Check pings the primary so readiness fails when the backend is unreachable.
+func (m userMongoImpl) Check(ctx context.Context) error {
+	return m.db.Database().Client().Ping(ctx, readpref.Primary())
+}
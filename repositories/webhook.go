@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepo interface {
+	Save(ctx context.Context, w models.Webhook) (models.Webhook, response.ApiError)
+	List(ctx context.Context) ([]models.Webhook, response.ApiError)
+	FindById(ctx context.Context, id string) (models.Webhook, response.ApiError)
+	// Update replaces the url, events and active flag of w, its secret is
+	// kept.
+	Update(ctx context.Context, w models.Webhook) response.ApiError
+	Delete(ctx context.Context, id string) response.ApiError
+	// Subscribed returns the active webhooks subscribed to eventType.
+	Subscribed(ctx context.Context, eventType string) ([]models.Webhook, response.ApiError)
+}
+
+type webhookMongoImpl struct {
+	db  *mongo.Collection
+	log *slog.Logger
+}
+
+func NewWebhookMongo(mongoDb *mongo.Collection, logger *slog.Logger) WebhookRepo {
+	return webhookMongoImpl{
+		db:  mongoDb,
+		log: logger.With("component", "webhook_repo"),
+	}
+}
+
+func (r webhookMongoImpl) Save(ctx context.Context, w models.Webhook) (models.Webhook, response.ApiError) {
+	res, err := r.db.InsertOne(ctx, w)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error saving webhook", "error", err)
+		return w, internalError(err)
+	}
+	w.ID = res.InsertedID.(primitive.ObjectID)
+	return w, response.ApiError{}
+}
+
+func (r webhookMongoImpl) List(ctx context.Context) ([]models.Webhook, response.ApiError) {
+	return r.find(ctx, bson.D{})
+}
+
+func (r webhookMongoImpl) Subscribed(ctx context.Context, eventType string) ([]models.Webhook, response.ApiError) {
+	return r.find(ctx, bson.D{{Key: "active", Value: true}, {Key: "events", Value: eventType}})
+}
+
+func (r webhookMongoImpl) find(ctx context.Context, filter bson.D) ([]models.Webhook, response.ApiError) {
+	result := make([]models.Webhook, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	curr, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error listing webhooks", "error", err)
+		return result, internalError(err)
+	}
+	if err := curr.All(ctx, &result); err != nil {
+		r.log.ErrorContext(ctx, "error decoding webhooks", "error", err)
+		return result, internalError(err)
+	}
+	return result, response.ApiError{}
+}
+
+func (r webhookMongoImpl) FindById(ctx context.Context, id string) (models.Webhook, response.ApiError) {
+	w := models.Webhook{}
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "webhook_id", id)
+		return w, response.BadRequestError
+	}
+
+	err = r.db.FindOne(ctx, bson.D{{Key: "_id", Value: objID}}).Decode(&w)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return w, response.ResourceNotFoundError
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error finding webhook", "webhook_id", id, "error", err)
+		return w, internalError(err)
+	}
+	return w, response.ApiError{}
+}
+
+func (r webhookMongoImpl) Update(ctx context.Context, w models.Webhook) response.ApiError {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "url", Value: w.URL},
+		{Key: "events", Value: w.Events},
+		{Key: "active", Value: w.Active},
+		{Key: "updated_at", Value: w.UpdatedAt},
+	}}}
+	res, err := r.db.UpdateByID(ctx, w.ID, update)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error updating webhook", "webhook_id", w.ID.Hex(), "error", err)
+		return internalError(err)
+	}
+	if res.MatchedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+	return response.ApiError{}
+}
+
+func (r webhookMongoImpl) Delete(ctx context.Context, id string) response.ApiError {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "webhook_id", id)
+		return response.BadRequestError
+	}
+
+	res, err := r.db.DeleteOne(ctx, bson.D{{Key: "_id", Value: objID}})
+	if err != nil {
+		r.log.ErrorContext(ctx, "error deleting webhook", "webhook_id", id, "error", err)
+		return internalError(err)
+	}
+	if res.DeletedCount == 0 {
+		return response.ResourceNotFoundError
+	}
+	return response.ApiError{}
+}
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"user-api/models"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeliveryRepo is the persistent queue of the webhook deliveries, kept
+// afterwards as their log.
+type DeliveryRepo interface {
+	SaveMany(ctx context.Context, deliveries []models.WebhookDelivery) response.ApiError
+	FindById(ctx context.Context, webhookID primitive.ObjectID, id string) (models.WebhookDelivery, response.ApiError)
+	// List returns the deliveries of a webhook, newest first, status
+	// filtering them when set.
+	List(ctx context.Context, webhookID primitive.ObjectID, status string, limit uint64, page uint64) ([]models.WebhookDelivery, response.ApiError)
+	// Claim marks in flight until lease the oldest delivery due at now, or
+	// whose previous claim expired, so a single worker attempts it. A
+	// delivery waits for the earlier pending or in flight deliveries of its
+	// user to the same webhook. Found is false when none is due.
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (d models.WebhookDelivery, found bool, apiErr response.ApiError)
+	// Record appends attempt to a claimed delivery and sets its status,
+	// nextAttemptAt being used by pending ones.
+	Record(ctx context.Context, id primitive.ObjectID, attempt models.Attempt, status string, nextAttemptAt time.Time) response.ApiError
+	// DeleteByWebhook drops the queue and log of a deleted webhook.
+	DeleteByWebhook(ctx context.Context, webhookID primitive.ObjectID) response.ApiError
+}
+
+type deliveryMongoImpl struct {
+	db  *mongo.Collection
+	log *slog.Logger
+}
+
+func NewDeliveryMongo(mongoDb *mongo.Collection, logger *slog.Logger) DeliveryRepo {
+	return deliveryMongoImpl{
+		db:  mongoDb,
+		log: logger.With("component", "webhook_delivery_repo"),
+	}
+}
+
+func (r deliveryMongoImpl) SaveMany(ctx context.Context, deliveries []models.WebhookDelivery) response.ApiError {
+	if len(deliveries) == 0 {
+		return response.ApiError{}
+	}
+	docs := make([]interface{}, len(deliveries))
+	for i, d := range deliveries {
+		docs[i] = d
+	}
+	if _, err := r.db.InsertMany(ctx, docs); err != nil {
+		r.log.ErrorContext(ctx, "error saving webhook deliveries", "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+func (r deliveryMongoImpl) FindById(ctx context.Context, webhookID primitive.ObjectID, id string) (models.WebhookDelivery, response.ApiError) {
+	d := models.WebhookDelivery{}
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.InfoContext(ctx, "invalid id format", "delivery_id", id)
+		return d, response.BadRequestError
+	}
+
+	err = r.db.FindOne(ctx, bson.D{{Key: "_id", Value: objID}, {Key: "webhook_id", Value: webhookID}}).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return d, response.ResourceNotFoundError
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "error finding webhook delivery", "delivery_id", id, "error", err)
+		return d, internalError(err)
+	}
+	return d, response.ApiError{}
+}
+
+func (r deliveryMongoImpl) List(ctx context.Context, webhookID primitive.ObjectID, status string, limit uint64, page uint64) ([]models.WebhookDelivery, response.ApiError) {
+	result := make([]models.WebhookDelivery, 0)
+	filter := bson.D{{Key: "webhook_id", Value: webhookID}}
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(limit * (page - 1)))
+	curr, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error listing webhook deliveries", "error", err)
+		return result, internalError(err)
+	}
+	if err := curr.All(ctx, &result); err != nil {
+		r.log.ErrorContext(ctx, "error decoding webhook deliveries", "error", err)
+		return result, internalError(err)
+	}
+	return result, response.ApiError{}
+}
+
+// claimCandidates is how many due deliveries a claim tries, in case other
+// workers take the first ones.
+const claimCandidates = 10
+
+// Claim only considers the oldest pending or in flight delivery of every
+// user and webhook, so a delivery waiting for a retry holds back the later
+// ones of its user while the other users go on.
+func (r deliveryMongoImpl) Claim(ctx context.Context, now time.Time, lease time.Duration) (models.WebhookDelivery, bool, response.ApiError) {
+	d := models.WebhookDelivery{}
+	due := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "status", Value: models.DeliveryPending}, {Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: now}}}},
+		bson.D{{Key: "status", Value: models.DeliveryInFlight}, {Key: "locked_until", Value: bson.D{{Key: "$lte", Value: now}}}},
+	}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{models.DeliveryPending, models.DeliveryInFlight}}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "webhook_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "seq", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "webhook_id", Value: "$webhook_id"}, {Key: "user_id", Value: "$user_id"}}},
+			{Key: "head", Value: bson.D{{Key: "$first", Value: "$$ROOT"}}},
+		}}},
+		{{Key: "$replaceWith", Value: "$head"}},
+		{{Key: "$match", Value: due}},
+		{{Key: "$sort", Value: bson.D{{Key: "next_attempt_at", Value: 1}}}},
+		{{Key: "$limit", Value: claimCandidates}},
+		{{Key: "$project", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	curr, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		r.log.ErrorContext(ctx, "error finding due webhook deliveries", "error", err)
+		return d, false, internalError(err)
+	}
+	candidates := make([]struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}, 0)
+	if err := curr.All(ctx, &candidates); err != nil {
+		r.log.ErrorContext(ctx, "error decoding due webhook deliveries", "error", err)
+		return d, false, internalError(err)
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: models.DeliveryInFlight},
+		{Key: "locked_until", Value: now.Add(lease)},
+	}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	for _, c := range candidates {
+		// due again in the filter, another worker may have claimed it since
+		filter := append(bson.D{{Key: "_id", Value: c.ID}}, due...)
+		err := r.db.FindOneAndUpdate(ctx, filter, update, opts).Decode(&d)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			r.log.ErrorContext(ctx, "error claiming webhook delivery", "error", err)
+			return d, false, internalError(err)
+		}
+		return d, true, response.ApiError{}
+	}
+	return d, false, response.ApiError{}
+}
+
+func (r deliveryMongoImpl) Record(ctx context.Context, id primitive.ObjectID, attempt models.Attempt, status string, nextAttemptAt time.Time) response.ApiError {
+	set := bson.D{{Key: "status", Value: status}, {Key: "next_attempt_at", Value: nextAttemptAt}}
+	if status == models.DeliveryDelivered {
+		set = append(set, bson.E{Key: "delivered_at", Value: attempt.At})
+	}
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$push", Value: bson.D{{Key: "attempts", Value: attempt}}},
+		{Key: "$unset", Value: bson.D{{Key: "locked_until", Value: ""}}},
+	}
+	if _, err := r.db.UpdateByID(ctx, id, update); err != nil {
+		r.log.ErrorContext(ctx, "error recording webhook delivery attempt", "delivery_id", id.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
+
+func (r deliveryMongoImpl) DeleteByWebhook(ctx context.Context, webhookID primitive.ObjectID) response.ApiError {
+	if _, err := r.db.DeleteMany(ctx, bson.D{{Key: "webhook_id", Value: webhookID}}); err != nil {
+		r.log.ErrorContext(ctx, "error deleting webhook deliveries", "webhook_id", webhookID.Hex(), "error", err)
+		return internalError(err)
+	}
+	return response.ApiError{}
+}
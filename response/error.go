@@ -3,17 +3,56 @@ package response
 import "net/http"
 
 type ApiError struct {
-	Error  string `json:"error"`
-	Code   string `json:"code"`
-	Status int    `json:"status"`
+	Message string       `json:"error"`
+	Code    string       `json:"code"`
+	Status  int          `json:"status"`
+	Detail  string       `json:"detail,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+	// Transient marks the failures a transaction run again may not meet,
+	// such as a write conflicting with a concurrent transaction.
+	Transient bool `json:"-"`
+}
+
+// FieldError describes one failed validation rule of one request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ApiError) Error() string {
+	if e.Detail != "" {
+		return e.Message + ": " + e.Detail
+	}
+	return e.Message
+}
+
+// WithDetail returns a copy of e explaining this specific occurrence.
+func (e ApiError) WithDetail(detail string) ApiError {
+	e.Detail = detail
+	return e
 }
 
 var (
-	NotFoundError           = ApiError{Error: "Resource not found", Code: "NOT_FOUND_ERROR", Status: http.StatusNotFound}
-	InternalServerError     = ApiError{Error: "Internal server error", Code: "INTERNAL_SERVER_ERROR", Status: http.StatusInternalServerError}
-	BadRequestError         = ApiError{Error: "Invalid parse user input", Code: "BAD_REQUEST", Status: http.StatusBadRequest}
-	EmailAlreadyInUse       = ApiError{Error: "Email already in use", Code: "EMAIL_IN_USE", Status: http.StatusConflict}
-	ResourceNotFoundError   = ApiError{Error: "Resource not found", Code: "RESOURCE_NOT_FOUND", Status: http.StatusNotFound}
-	InvalidCredentialsError = ApiError{Error: "Invalid credentials", Code: "INVALID_CREDENTIALS", Status: http.StatusBadRequest}
-	InvalidTokenError       = ApiError{Error: "Invalid token", Code: "INVALID_TOKEN", Status: http.StatusUnauthorized}
+	NotFoundError             = ApiError{Message: "Resource not found", Code: "NOT_FOUND_ERROR", Status: http.StatusNotFound}
+	InternalServerError       = ApiError{Message: "Internal server error", Code: "INTERNAL_SERVER_ERROR", Status: http.StatusInternalServerError}
+	BadRequestError           = ApiError{Message: "Invalid parse user input", Code: "BAD_REQUEST", Status: http.StatusBadRequest}
+	EmailAlreadyInUse         = ApiError{Message: "Email already in use", Code: "EMAIL_IN_USE", Status: http.StatusConflict}
+	ResourceNotFoundError     = ApiError{Message: "Resource not found", Code: "RESOURCE_NOT_FOUND", Status: http.StatusNotFound}
+	InvalidCredentialsError   = ApiError{Message: "Invalid credentials", Code: "INVALID_CREDENTIALS", Status: http.StatusBadRequest}
+	InvalidTokenError         = ApiError{Message: "Invalid token", Code: "INVALID_TOKEN", Status: http.StatusUnauthorized}
+	ValidationError           = ApiError{Message: "Validation failed", Code: "VALIDATION_ERROR", Status: http.StatusBadRequest}
+	DifferentUserError        = ApiError{Message: "Cannot change a different user", Code: "DIFFERENT_USER", Status: http.StatusUnauthorized}
+	MethodNotAllowedError     = ApiError{Message: "Method not allowed", Code: "METHOD_NOT_ALLOWED", Status: http.StatusMethodNotAllowed}
+	InvalidApiKeyError        = ApiError{Message: "Invalid api key", Code: "INVALID_API_KEY", Status: http.StatusUnauthorized}
+	InvalidScopeError         = ApiError{Message: "Invalid api key scope", Code: "INVALID_SCOPE", Status: http.StatusBadRequest}
+	InsufficientScopeError    = ApiError{Message: "Insufficient scope", Code: "INSUFFICIENT_SCOPE", Status: http.StatusForbidden}
+	InvalidCSRFTokenError     = ApiError{Message: "Invalid CSRF token", Code: "INVALID_CSRF_TOKEN", Status: http.StatusForbidden}
+	UnsupportedMediaTypeError = ApiError{Message: "Unsupported media type", Code: "UNSUPPORTED_MEDIA_TYPE", Status: http.StatusUnsupportedMediaType}
+	AddressLimitError         = ApiError{Message: "Too many addresses", Code: "ADDRESS_LIMIT", Status: http.StatusConflict}
+	InvalidSchemaError        = ApiError{Message: "Invalid attribute schema", Code: "INVALID_SCHEMA", Status: http.StatusBadRequest}
+	PayloadTooLargeError      = ApiError{Message: "Payload too large", Code: "PAYLOAD_TOO_LARGE", Status: http.StatusRequestEntityTooLarge}
+	InvalidImageError         = ApiError{Message: "Invalid image", Code: "INVALID_IMAGE", Status: http.StatusBadRequest}
+	InvalidLinkError          = ApiError{Message: "Invalid or expired link", Code: "INVALID_LINK", Status: http.StatusBadRequest}
 )
@@ -0,0 +1,115 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"user-api/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	problemTypeBase    = "/problems/"
+)
+
+// Problem is the RFC 7807 body of every error response.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblem builds the problem of e with human readable texts in locale,
+// type, code and field codes never depend on the locale.
+func NewProblem(e ApiError, instance string, locale string) Problem {
+	errs := make([]FieldError, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		fe.Message = i18n.ValidationMessage(locale, fe.Field, strings.ToLower(fe.Code), fe.Param)
+		errs = append(errs, fe)
+	}
+	return Problem{
+		Type:     problemTypeBase + strings.ToLower(strings.ReplaceAll(e.Code, "_", "-")),
+		Title:    i18n.ErrorTitle(locale, e.Code, e.Message),
+		Status:   e.Status,
+		Detail:   i18n.ErrorDetail(locale, e.Code, e.Detail),
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   errs,
+	}
+}
+
+// Abort stops the handler chain and renders e at once, so the middlewares
+// wrapping the handler see the status of the problem.
+func Abort(c *gin.Context, e ApiError) {
+	_ = c.Error(e)
+	c.Abort()
+	render(c, e)
+}
+
+// ProblemMiddleware renders the last error attached to the context with
+// c.Error rather than Abort. It must be the innermost middleware, the outer
+// ones reading the status once it rendered.
+func ProblemMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		render(c, c.Errors.Last().Err)
+	}
+}
+
+// render writes err as application/problem+json in the negotiated locale,
+// unless the response was already written. Errors that are not an ApiError
+// are reported as InternalServerError so internals never leak to the client.
+func render(c *gin.Context, err error) {
+	if c.Writer.Written() {
+		return
+	}
+
+	var apiErr ApiError
+	if !errors.As(err, &apiErr) || apiErr.Status == 0 {
+		apiErr = InternalServerError
+	}
+
+	locale := i18n.Locale(c)
+	p := NewProblem(apiErr, c.Request.URL.Path, locale)
+	c.Header("Content-Language", locale)
+	c.Render(p.Status, problemRender{p})
+}
+
+// Recovery reports panics as InternalServerError problems.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		Abort(c, InternalServerError)
+	})
+}
+
+func NoRoute(c *gin.Context) {
+	Abort(c, NotFoundError)
+}
+
+func NoMethod(c *gin.Context) {
+	Abort(c, MethodNotAllowedError)
+}
+
+type problemRender struct {
+	problem Problem
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.problem)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ProblemContentType)
+}
@@ -0,0 +1,100 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"user-api/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(h gin.HandlerFunc, headers ...string) (*httptest.ResponseRecorder, Problem) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ProblemMiddleware(), Recovery())
+	router.NoRoute(NoRoute)
+	router.GET("/v1/users/:id", h)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/42", nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	router.ServeHTTP(w, req)
+
+	p := Problem{}
+	json.Unmarshal(w.Body.Bytes(), &p)
+	return w, p
+}
+
+func TestApiErrorRenderedAsProblem(t *testing.T) {
+	w, p := serve(func(c *gin.Context) { Abort(c, EmailAlreadyInUse) })
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "/problems/email-in-use", p.Type)
+	assert.Equal(t, EmailAlreadyInUse.Message, p.Title)
+	assert.Equal(t, EmailAlreadyInUse.Code, p.Code)
+	assert.Equal(t, "/v1/users/42", p.Instance)
+}
+
+func TestValidationErrorListsFieldViolations(t *testing.T) {
+	v := url.Values{"password": {"min:6"}, "email": {"required", "email"}}
+
+	w, p := serve(func(c *gin.Context) { Abort(c, NewValidationError(v)) })
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ValidationError.Code, p.Code)
+	assert.Equal(t, []FieldError{
+		{Field: "email", Code: "REQUIRED", Message: "The email field is required"},
+		{Field: "email", Code: "EMAIL", Message: "The email field must be a valid email address"},
+		{Field: "password", Code: "MIN", Param: "6", Message: "The password field must be at least 6 characters"},
+	}, p.Errors)
+}
+
+func TestUnknownErrorHidesDetails(t *testing.T) {
+	w, p := serve(func(c *gin.Context) {
+		c.Error(errors.New("mongo: connection refused"))
+		c.Abort()
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, InternalServerError.Code, p.Code)
+	assert.NotContains(t, w.Body.String(), "mongo")
+}
+
+func TestPanicRenderedAsProblem(t *testing.T) {
+	w, p := serve(func(c *gin.Context) { panic("boom") })
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, InternalServerError.Code, p.Code)
+}
+
+func TestProblemLocalizedFromAcceptLanguage(t *testing.T) {
+	v := url.Values{"name": {"required"}}
+
+	w, p := serve(func(c *gin.Context) { Abort(c, NewValidationError(v)) }, "Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
+
+	assert.Equal(t, "pt", w.Header().Get("Content-Language"))
+	assert.Equal(t, "Falha de validação", p.Title)
+	assert.Equal(t, "Um ou mais campos são inválidos", p.Detail)
+	assert.Equal(t, ValidationError.Code, p.Code)
+	assert.Equal(t, "REQUIRED", p.Errors[0].Code)
+	assert.Equal(t, "O campo name é obrigatório", p.Errors[0].Message)
+}
+
+func TestProblemLocalizedFromPreferredLocale(t *testing.T) {
+	w, p := serve(func(c *gin.Context) {
+		c.Set(i18n.PreferredLocaleKey, i18n.Spanish)
+		Abort(c, EmailAlreadyInUse)
+	}, "Accept-Language", "en-US")
+
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+	assert.Equal(t, "El email ya está en uso", p.Title)
+	assert.Equal(t, EmailAlreadyInUse.Code, p.Code)
+}
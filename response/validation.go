@@ -0,0 +1,36 @@
+package response
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"user-api/i18n"
+)
+
+// NewValidationError converts the failed rules reported by dto ValidateFields,
+// field name to rules like "min:6", into a ValidationError with one FieldError
+// per failed rule.
+func NewValidationError(v url.Values) ApiError {
+	fields := make([]string, 0, len(v))
+	for f := range v {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	errs := make([]FieldError, 0, len(v))
+	for _, f := range fields {
+		for _, rule := range v[f] {
+			name, param, _ := strings.Cut(rule, ":")
+			errs = append(errs, FieldError{
+				Field:   f,
+				Code:    strings.ToUpper(name),
+				Param:   param,
+				Message: i18n.ValidationMessage(i18n.DefaultLocale, f, name, param),
+			})
+		}
+	}
+
+	e := ValidationError.WithDetail("One or more fields are invalid")
+	e.Errors = errs
+	return e
+}
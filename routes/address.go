@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+	"user-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetAddressRoutes(r *gin.RouterGroup, c controllers.AddressController, a controllers.AuthController) {
+	read := a.RequireScope(models.ScopeUsersRead)
+	write := a.RequireScope(models.ScopeUsersWrite)
+
+	r.GET("/:id/addresses", read, c.List())
+	r.POST("/:id/addresses", write, c.Create())
+	r.GET("/:id/addresses/:addressId", read, c.Get())
+	r.PUT("/:id/addresses/:addressId", write, c.Update())
+	r.DELETE("/:id/addresses/:addressId", write, c.Delete())
+}
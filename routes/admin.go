@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+	"user-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetAdminRoutes(r *gin.RouterGroup, c controllers.AdminUserController, attrs controllers.AttributeController, a controllers.AuthController) {
+	r.Use(a.RequireScope(models.ScopeAdmin))
+	r.POST("/users/import", c.Import())
+	r.GET("/users/export", c.Export())
+	r.PUT("/users/:id/attributes", attrs.SetUserAttributes())
+	r.GET("/attributes/schema", attrs.Schema())
+	r.PUT("/attributes/schema", attrs.SetSchema())
+}
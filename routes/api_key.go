@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+	"user-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetApiKeyRoutes(r *gin.RouterGroup, c controllers.ApiKeyController, a controllers.AuthController) {
+	r.Use(a.RequireScope(models.ScopeApiKeysManage))
+	r.POST("", c.Create())
+	r.GET("", c.List())
+	r.DELETE("/:id", c.Revoke())
+}
@@ -9,4 +9,5 @@ import (
 func SetAuthRoutes(r *gin.RouterGroup, c controllers.AuthController) {
 	r.POST("/register", c.Register())
 	r.POST("/login", c.Login())
+	r.POST("/logout", c.Logout())
 }
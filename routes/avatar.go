@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+	"user-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetAvatarRoutes(r *gin.RouterGroup, c controllers.AvatarController, a controllers.AuthController) {
+	write := a.RequireScope(models.ScopeUsersWrite)
+
+	r.PUT("/:id/avatar", write, c.Put())
+	r.DELETE("/:id/avatar", write, c.Delete())
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+	"user-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetEmailChangeRoutes registers the request route on the users group and
+// the routes of the emailed links on the public group.
+func SetEmailChangeRoutes(users *gin.RouterGroup, public *gin.RouterGroup, c controllers.EmailChangeController, a controllers.AuthController) {
+	users.POST("/me/email", a.RequireScope(models.ScopeUsersWrite), c.Request())
+
+	// the links open pages posting their token, GET changing nothing
+	public.GET("/confirm", c.ConfirmPage())
+	public.POST("/confirm", c.Confirm())
+	public.GET("/revert", c.RevertPage())
+	public.POST("/revert", c.Revert())
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetHealthRoutes(r gin.IRoutes, c controllers.HealthController) {
+	r.GET("/healthz", c.Liveness())
+	r.GET("/readyz", c.Readiness())
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+	"user-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSessionRoutes registers the /me/sessions routes on the users group.
+func SetSessionRoutes(r *gin.RouterGroup, c controllers.SessionController, a controllers.AuthController) {
+	r.GET("/me/sessions", a.RequireScope(models.ScopeUsersRead), c.List())
+	r.DELETE("/me/sessions/:sid", a.RequireScope(models.ScopeUsersWrite), c.Revoke())
+}
@@ -2,13 +2,22 @@ package routes
 
 import (
 	"user-api/controllers/v1"
+	"user-api/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetUsersRoutes(r *gin.RouterGroup, c controllers.UserController) {
-	r.GET("", c.GetAll())
-	r.DELETE("/:id", c.Delete())
-	r.GET("/:id", c.GetById())
-	r.PUT("/:id", c.Update())
+func SetUsersRoutes(r *gin.RouterGroup, c controllers.UserController, a controllers.AuthController) {
+	read := a.RequireScope(models.ScopeUsersRead)
+	write := a.RequireScope(models.ScopeUsersWrite)
+
+	r.GET("", read, c.GetAll())
+	r.GET("/me", read, c.Me())
+	r.PUT("/me", write, c.UpdateMe())
+	r.PATCH("/me", write, c.PatchMe())
+	r.DELETE("/me", write, c.DeleteMe())
+	r.POST("/me/password", write, c.ChangePassword())
+	r.DELETE("/:id", write, c.Delete())
+	r.GET("/:id", read, c.GetById())
+	r.PUT("/:id", write, c.Update())
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"user-api/controllers/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetWebhookRoutes registers the webhook endpoints on the admin group, which
+// already requires the admin scope.
+func SetWebhookRoutes(r *gin.RouterGroup, c controllers.WebhookController) {
+	r.POST("/webhooks", c.Create())
+	r.GET("/webhooks", c.List())
+	r.GET("/webhooks/:id", c.Get())
+	r.PUT("/webhooks/:id", c.Update())
+	r.DELETE("/webhooks/:id", c.Delete())
+	r.GET("/webhooks/:id/deliveries", c.Deliveries())
+	r.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", c.Redeliver())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/urfave/cli/v2"
+)
+
+func seedCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "seed",
+		Usage: "Create sample users for development, existing ones are kept",
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "count", Value: 10, Usage: "sample users to create"},
+			&cli.StringFlag{Name: "domain", Value: "example.com", Usage: "email domain of the sample users"},
+			&cli.StringFlag{Name: "admin-email", Usage: "also create an admin with this email"},
+			&cli.StringFlag{Name: "password", Usage: "password of every seeded user, generated and printed on stderr when omitted"},
+		},
+		Action: seed,
+	}
+}
+
+func seed(c *cli.Context) error {
+	password, err := passwordFlag(c)
+	if err != nil {
+		return err
+	}
+
+	users := make([]models.User, 0, c.Int("count")+1)
+	if email := c.String("admin-email"); email != "" {
+		birthDate := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+		admin := models.NewUser("Seed Admin", &birthDate, email, password, "1 Seed Street")
+		admin.Role = models.RoleAdmin
+		users = append(users, *admin)
+	}
+	for i := 1; i <= c.Int("count"); i++ {
+		email := fmt.Sprintf("seed-%03d@%s", i, c.String("domain"))
+		birthDate := time.Date(1950+i%55, time.Month(1+i%12), 1+i%28, 0, 0, 0, 0, time.UTC)
+		users = append(users, *models.NewUser(fmt.Sprintf("Seed User %d", i), &birthDate, email, password, fmt.Sprintf("%d Seed Street", i)))
+	}
+
+	return withApp(c, func(ctx context.Context, a *app) error {
+		created := 0
+		for _, u := range users {
+			_, apiErr := a.userSvc.Register(ctx, u)
+			if apiErr.Code == response.EmailAlreadyInUse.Code {
+				continue
+			}
+			if apiErr.Status != 0 {
+				return commandError("seeding "+u.Email, apiErr)
+			}
+			created++
+		}
+
+		fmt.Fprintf(os.Stderr, "%d users created, %d already existed\n", created, len(users)-created)
+		return nil
+	})
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"user-api/auth"
+	"user-api/config"
+	"user-api/controllers/v1"
+	docs "user-api/docs"
+	"user-api/health"
+	"user-api/logging"
+	"user-api/metrics"
+	"user-api/migrations"
+	"user-api/response"
+	routes "user-api/routes"
+	"user-api/storage"
+	"user-api/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"     // swagger embed files
+	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
+	"github.com/urfave/cli/v2"
+)
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "serve",
+		Usage:  "Run the HTTP api",
+		Action: serve,
+	}
+}
+
+func serve(c *cli.Context) error {
+	cfg := config.Load()
+
+	//init logger
+	logger := newLogger(cfg, os.Stdout)
+
+	//init tracing
+	shutdownTracing, err := tracing.Init(c.Context, tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("initializing tracing: %w", err)
+	}
+
+	ctx := context.TODO()
+	a, err := newApp(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer a.close(ctx)
+
+	//apply migrations, instances starting together wait for the one migrating
+	if cfg.MigrateOnStart {
+		if _, err := a.migrations.Up(ctx, 0, migrations.Options{Wait: cfg.MigrateLockWait}); err != nil {
+			return fmt.Errorf("migrating: %w", err)
+		}
+	}
+
+	//init outbox relay, before the health checks covering its nats sink
+	relay, err := a.newOutboxRelay()
+	if err != nil {
+		return err
+	}
+
+	//init health checks
+	healthRegistry := health.NewRegistry(cfg.HealthCheckTimeout)
+	if hc, ok := a.userMongo.(health.HealthChecker); ok {
+		healthRegistry.Register(hc)
+	}
+	if hc, ok := a.blobStore.(health.HealthChecker); ok {
+		healthRegistry.Register(hc)
+	}
+	if hc, ok := a.userCache.(health.HealthChecker); ok {
+		healthRegistry.Register(hc)
+	}
+	if a.natsSink != nil {
+		healthRegistry.Register(a.natsSink)
+	}
+
+	//init controller
+	userController := controllers.NewUserJson(a.userSvc, a.attributeSvc, logger)
+	authController := controllers.NewAuth(a.userSvc, a.apiKeySvc, a.sessionSvc, controllers.AuthOptions{
+		LegacyTokenHeader: cfg.AuthLegacyHeader,
+		Cookie: controllers.CookieOptions{
+			Enabled:  cfg.AuthCookieEnabled,
+			Name:     cfg.AuthCookieName,
+			CSRFName: cfg.AuthCSRFCookieName,
+			Domain:   cfg.AuthCookieDomain,
+			Secure:   cfg.AuthCookieSecure,
+			SameSite: auth.ParseSameSite(cfg.AuthCookieSameSite),
+		},
+	}, logger)
+	apiKeyController := controllers.NewApiKey(a.apiKeySvc, logger)
+	sessionController := controllers.NewSession(a.sessionSvc, logger)
+	addressController := controllers.NewAddress(a.addressSvc, logger)
+	adminUserController := controllers.NewAdminUser(a.importSvc, a.exportSvc, a.attributeSvc, int64(cfg.ImportMaxBytes), logger)
+	attributeController := controllers.NewAttribute(a.attributeSvc, a.userSvc, logger)
+	avatarController := controllers.NewAvatar(a.avatarSvc, int64(cfg.AvatarMaxBytes), logger)
+	emailChangeController := controllers.NewEmailChange(a.emailChangeSvc, logger)
+	webhookController := controllers.NewWebhook(a.webhookSvc, logger)
+	healthController := controllers.NewHealth(healthRegistry)
+
+	//init v1 router
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(response.NoRoute)
+	router.NoMethod(response.NoMethod)
+	// problems are rendered innermost so the other middlewares see their status
+	router.Use(
+		response.Recovery(),
+		logging.RequestIDMiddleware(),
+		tracing.GinMiddleware(),
+		metrics.GinMiddleware(),
+		logging.AccessLog(logger),
+		response.ProblemMiddleware(),
+	)
+	docs.SwaggerInfo.BasePath = "/v1"
+	v1 := router.Group("/v1")
+
+	//set routes
+	routes.SetHealthRoutes(router, healthController)
+	userGroup := v1.Group("/users")
+	userGroup.Use(authController.VerifyToken())
+	routes.SetSessionRoutes(userGroup, sessionController, authController)
+	routes.SetAddressRoutes(userGroup, addressController, authController)
+	routes.SetAvatarRoutes(userGroup, avatarController, authController)
+	routes.SetEmailChangeRoutes(userGroup, v1.Group("/auth/email"), emailChangeController, authController)
+	routes.SetUsersRoutes(userGroup, userController, authController)
+	apiKeyGroup := v1.Group("/api-keys")
+	apiKeyGroup.Use(authController.VerifyToken())
+	routes.SetApiKeyRoutes(apiKeyGroup, apiKeyController, authController)
+	adminGroup := v1.Group("/admin")
+	adminGroup.Use(authController.VerifyToken())
+	routes.SetAdminRoutes(adminGroup, adminUserController, attributeController, authController)
+	routes.SetWebhookRoutes(adminGroup, webhookController)
+	routes.SetAuthRoutes(v1.Group("/auth"), authController)
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	if local, ok := a.blobStore.(*storage.LocalStore); ok {
+		router.GET(blobRoute+"/*key", gin.WrapH(http.StripPrefix(blobRoute, local.Handler())))
+	}
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+
+	//send the queued webhook deliveries until shutdown
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	dispatched := make(chan struct{})
+	go func() {
+		a.newWebhookDispatcher().Run(dispatchCtx)
+		close(dispatched)
+	}()
+
+	//publish the stored events until shutdown
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayed := make(chan struct{})
+	go func() {
+		relay.Run(relayCtx)
+		close(relayed)
+	}()
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("error starting server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	//report not ready so the orchestrator stops routing traffic before we stop accepting it
+	logger.Info("shutting down, draining connections")
+	healthRegistry.SetDraining()
+	time.Sleep(cfg.ShutdownDrain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("error shutting down server", "error", err)
+	}
+	// an interrupted attempt is retried once its lease expires
+	stopDispatch()
+	stopRelay()
+	select {
+	case <-dispatched:
+	case <-shutdownCtx.Done():
+		logger.Error("error stopping webhook dispatcher", "error", shutdownCtx.Err())
+	}
+	select {
+	case <-relayed:
+	case <-shutdownCtx.Done():
+		logger.Error("error stopping outbox relay", "error", shutdownCtx.Err())
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("error flushing traces", "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AddressService interface {
+	List(ctx context.Context, userID string) ([]models.Address, response.ApiError)
+	Get(ctx context.Context, userID string, id string) (models.Address, response.ApiError)
+	// Create adds a to the addresses of the user, makeDefault being implied
+	// for the first one.
+	Create(ctx context.Context, userID string, a models.Address, makeDefault bool) (models.Address, response.ApiError)
+	// Update replaces the address a.ID. The default address stays default
+	// until another one is made default.
+	Update(ctx context.Context, userID string, a models.Address, makeDefault bool) (models.Address, response.ApiError)
+	Delete(ctx context.Context, userID string, id string) response.ApiError
+}
+
+type addressServiceImpl struct {
+	r      repositories.AddressRepo
+	outbox *Outbox
+	log    *slog.Logger
+}
+
+func NewAddress(r repositories.AddressRepo, outbox *Outbox, logger *slog.Logger) AddressService {
+	return addressServiceImpl{
+		r:      r,
+		outbox: outbox,
+		log:    logger.With("component", "address_service"),
+	}
+}
+
+func (svc addressServiceImpl) List(ctx context.Context, userID string) ([]models.Address, response.ApiError) {
+	return svc.r.List(ctx, userID)
+}
+
+func (svc addressServiceImpl) Get(ctx context.Context, userID string, id string) (models.Address, response.ApiError) {
+	addresses, apiErr := svc.r.List(ctx, userID)
+	if apiErr.Status != 0 {
+		return models.Address{}, apiErr
+	}
+
+	for _, a := range addresses {
+		if a.ID.Hex() == id {
+			return a, response.ApiError{}
+		}
+	}
+	return models.Address{}, response.ResourceNotFoundError
+}
+
+func (svc addressServiceImpl) Create(ctx context.Context, userID string, a models.Address, makeDefault bool) (models.Address, response.ApiError) {
+	a.ID = primitive.NewObjectID()
+	a.Default = makeDefault
+	stored := a
+	apiErr := svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		var apiErr response.ApiError
+		stored, apiErr = svc.r.Add(ctx, userID, a)
+		return apiErr
+	}, updatedEvent(userID, "addresses"))
+	if apiErr.Status != 0 {
+		a.Default = false
+		return a, apiErr
+	}
+
+	svc.log.InfoContext(ctx, "address created", "user_id", userID, "address_id", stored.ID.Hex())
+	return stored, response.ApiError{}
+}
+
+func (svc addressServiceImpl) Update(ctx context.Context, userID string, a models.Address, makeDefault bool) (models.Address, response.ApiError) {
+	current, apiErr := svc.Get(ctx, userID, a.ID.Hex())
+	if apiErr.Status != 0 {
+		return a, apiErr
+	}
+
+	a.Default = current.Default || makeDefault
+	apiErr = svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.Replace(ctx, userID, a)
+	}, updatedEvent(userID, "addresses"))
+	if apiErr.Status != 0 {
+		a.Default = current.Default
+		return a, apiErr
+	}
+
+	return a, response.ApiError{}
+}
+
+func (svc addressServiceImpl) Delete(ctx context.Context, userID string, id string) response.ApiError {
+	apiErr := svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.Delete(ctx, userID, id)
+	}, updatedEvent(userID, "addresses"))
+	if apiErr.Status == 0 {
+		svc.log.InfoContext(ctx, "address deleted", "user_id", userID, "address_id", id)
+	}
+	return apiErr
+}
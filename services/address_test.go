@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const addressUserID = "5f1e2d3c4b5a697887766554"
+
+func TestCreateReturnsStoredDefault(t *testing.T) {
+	r := new(mocks.AddressRepo)
+	r.On("Add", mock.Anything, addressUserID, mock.MatchedBy(func(a models.Address) bool { return !a.Default })).
+		Return(func(_ context.Context, _ string, a models.Address) models.Address {
+			a.Default = true
+			return a
+		}, func(context.Context, string, models.Address) response.ApiError { return response.ApiError{} })
+	svc := addressServiceImpl{r: r, log: logging.Discard()}
+
+	a, apiErr := svc.Create(context.Background(), addressUserID, models.Address{Label: models.AddressHome}, false)
+
+	assert.Equal(t, response.ApiError{}, apiErr)
+	assert.True(t, a.Default)
+	assert.False(t, a.ID.IsZero())
+	r.AssertExpectations(t)
+}
+
+func TestCreateAsksForDefault(t *testing.T) {
+	r := new(mocks.AddressRepo)
+	r.On("Add", mock.Anything, addressUserID, mock.MatchedBy(func(a models.Address) bool { return a.Default })).
+		Return(models.Address{Default: true}, response.ApiError{})
+	svc := addressServiceImpl{r: r, log: logging.Discard()}
+
+	a, apiErr := svc.Create(context.Background(), addressUserID, models.Address{Label: models.AddressBilling}, true)
+
+	assert.Equal(t, response.ApiError{}, apiErr)
+	assert.True(t, a.Default)
+	r.AssertExpectations(t)
+}
+
+func TestCreateAddressLimit(t *testing.T) {
+	r := new(mocks.AddressRepo)
+	r.On("Add", mock.Anything, addressUserID, mock.Anything).Return(models.Address{}, response.AddressLimitError)
+	svc := addressServiceImpl{r: r, log: logging.Discard()}
+
+	a, apiErr := svc.Create(context.Background(), addressUserID, models.Address{}, true)
+
+	assert.Equal(t, response.AddressLimitError, apiErr)
+	assert.False(t, a.Default)
+	r.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestUpdateKeepsDefaultFlag(t *testing.T) {
+	current := models.Address{ID: primitive.NewObjectID(), Label: models.AddressHome, Default: true}
+	r := new(mocks.AddressRepo)
+	r.On("List", mock.Anything, addressUserID).Return([]models.Address{current}, response.ApiError{})
+	r.On("Replace", mock.Anything, addressUserID, mock.MatchedBy(func(a models.Address) bool { return a.Default && a.City == "Lisboa" })).Return(response.ApiError{})
+	svc := addressServiceImpl{r: r, log: logging.Discard()}
+
+	a, apiErr := svc.Update(context.Background(), addressUserID, models.Address{ID: current.ID, City: "Lisboa"}, false)
+
+	assert.Equal(t, response.ApiError{}, apiErr)
+	assert.True(t, a.Default)
+	r.AssertExpectations(t)
+}
+
+func TestUpdateMakesDefaultInReplace(t *testing.T) {
+	current := models.Address{ID: primitive.NewObjectID(), Label: models.AddressBilling}
+	r := new(mocks.AddressRepo)
+	r.On("List", mock.Anything, addressUserID).Return([]models.Address{{ID: primitive.NewObjectID(), Default: true}, current}, response.ApiError{})
+	r.On("Replace", mock.Anything, addressUserID, mock.MatchedBy(func(a models.Address) bool { return a.Default && a.ID == current.ID })).Return(response.ApiError{})
+	svc := addressServiceImpl{r: r, log: logging.Discard()}
+
+	a, apiErr := svc.Update(context.Background(), addressUserID, models.Address{ID: current.ID, City: "Porto"}, true)
+
+	assert.Equal(t, response.ApiError{}, apiErr)
+	assert.True(t, a.Default)
+	r.AssertExpectations(t)
+}
+
+func TestUpdateUnknownAddress(t *testing.T) {
+	r := new(mocks.AddressRepo)
+	r.On("List", mock.Anything, addressUserID).Return([]models.Address{}, response.ApiError{})
+	svc := addressServiceImpl{r: r, log: logging.Discard()}
+
+	_, apiErr := svc.Update(context.Background(), addressUserID, models.Address{ID: primitive.NewObjectID()}, true)
+
+	assert.Equal(t, response.ResourceNotFoundError, apiErr)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"user-api/auth"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+)
+
+type ApiKeyService interface {
+	Create(ctx context.Context, owner models.User, name string, scopes []string, ttl time.Duration) (string, models.ApiKey, response.ApiError)
+	List(ctx context.Context, userID string) ([]models.ApiKey, response.ApiError)
+	Revoke(ctx context.Context, userID string, id string) response.ApiError
+	Authenticate(ctx context.Context, key string) (models.ApiKey, response.ApiError)
+}
+
+type apiKeyServiceImpl struct {
+	r   repositories.ApiKeyRepo
+	log *slog.Logger
+	now func() time.Time
+}
+
+func NewApiKey(r repositories.ApiKeyRepo, logger *slog.Logger) ApiKeyService {
+	return apiKeyServiceImpl{
+		r:   r,
+		log: logger.With("component", "api_key_service"),
+		now: time.Now,
+	}
+}
+
+// Create issues a key for owner returning it in plain text alongside the
+// stored record, the plain text key cannot be recovered afterwards.
+func (svc apiKeyServiceImpl) Create(ctx context.Context, owner models.User, name string, scopes []string, ttl time.Duration) (string, models.ApiKey, response.ApiError) {
+	if apiErr := validateScopes(owner, scopes); apiErr.Status != 0 {
+		return "", models.ApiKey{}, apiErr
+	}
+
+	key, prefix, hash, err := auth.GenerateApiKey()
+	if err != nil {
+		svc.log.ErrorContext(ctx, "error generating api key", "error", err)
+		return "", models.ApiKey{}, response.InternalServerError
+	}
+
+	now := svc.now().UTC()
+	k := models.ApiKey{
+		UserID:    owner.ID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	k, apiErr := svc.r.Save(ctx, k)
+	if apiErr.Status != 0 {
+		return "", k, apiErr
+	}
+
+	svc.log.InfoContext(ctx, "api key created", "user_id", owner.ID.Hex(), "api_key_id", k.ID.Hex(), "scopes", scopes)
+	return key, k, response.ApiError{}
+}
+
+func validateScopes(owner models.User, scopes []string) response.ApiError {
+	if len(scopes) == 0 {
+		return response.InvalidScopeError.WithDetail("at least one scope is required")
+	}
+	for _, s := range scopes {
+		if s == models.ScopeAdmin && owner.IsAdmin() {
+			continue
+		}
+		if !contains(models.UserScopes, s) {
+			return response.InvalidScopeError.WithDetail("scope " + s + " cannot be granted")
+		}
+	}
+	return response.ApiError{}
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (svc apiKeyServiceImpl) List(ctx context.Context, userID string) ([]models.ApiKey, response.ApiError) {
+	return svc.r.ListByUser(ctx, userID)
+}
+
+func (svc apiKeyServiceImpl) Revoke(ctx context.Context, userID string, id string) response.ApiError {
+	apiErr := svc.r.Revoke(ctx, userID, id, svc.now().UTC())
+	if apiErr.Status == 0 {
+		svc.log.InfoContext(ctx, "api key revoked", "user_id", userID, "api_key_id", id)
+	}
+	return apiErr
+}
+
+// Authenticate resolves a plain text key, rejecting unknown, revoked and
+// expired ones with the same error so callers cannot probe key states.
+func (svc apiKeyServiceImpl) Authenticate(ctx context.Context, key string) (models.ApiKey, response.ApiError) {
+	if !auth.LooksLikeApiKey(key) {
+		return models.ApiKey{}, response.InvalidApiKeyError
+	}
+
+	k, apiErr := svc.r.FindByHash(ctx, auth.HashApiKey(key))
+	if apiErr.Status != 0 {
+		if apiErr.Status == response.ResourceNotFoundError.Status {
+			return k, response.InvalidApiKeyError
+		}
+		return k, apiErr
+	}
+
+	now := svc.now().UTC()
+	if k.Revoked() || k.Expired(now) {
+		svc.log.InfoContext(ctx, "api key rejected", "api_key_id", k.ID.Hex(), "revoked", k.Revoked())
+		return models.ApiKey{}, response.InvalidApiKeyError
+	}
+
+	svc.r.TouchLastUsed(ctx, k.ID, now)
+	return k, response.ApiError{}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-api/auth"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var fixedNow = time.Date(2022, 8, 10, 12, 0, 0, 0, time.UTC)
+
+func newApiKeyService(r *mocks.ApiKeyRepo) apiKeyServiceImpl {
+	return apiKeyServiceImpl{r: r, log: logging.Discard(), now: func() time.Time { return fixedNow }}
+}
+
+func TestCreateApiKeyStoresOnlyHash(t *testing.T) {
+	mockRepo := new(mocks.ApiKeyRepo)
+	svc := newApiKeyService(mockRepo)
+	owner := models.User{ID: primitive.NewObjectID()}
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("models.ApiKey")).Return(func(_ context.Context, k models.ApiKey) models.ApiKey {
+		return k
+	}, response.ApiError{})
+
+	key, k, apiErr := svc.Create(context.Background(), owner, "backup job", []string{models.ScopeUsersRead}, time.Hour)
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.True(t, auth.LooksLikeApiKey(key))
+	assert.Equal(t, auth.HashApiKey(key), k.Hash)
+	assert.NotContains(t, k.Hash, key)
+	assert.Equal(t, key[:len(k.Prefix)], k.Prefix)
+	assert.Equal(t, owner.ID, k.UserID)
+	assert.Equal(t, fixedNow.Add(time.Hour), k.ExpiresAt)
+}
+
+func TestCreateApiKeyAdminScopeReservedToAdmins(t *testing.T) {
+	mockRepo := new(mocks.ApiKeyRepo)
+	svc := newApiKeyService(mockRepo)
+
+	_, _, apiErr := svc.Create(context.Background(), models.User{Role: models.RoleUser}, "job", []string{models.ScopeAdmin}, time.Hour)
+
+	assert.Equal(t, response.InvalidScopeError.Code, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticateApiKey(t *testing.T) {
+	key, _, hash, _ := auth.GenerateApiKey()
+	revokedAt := fixedNow.Add(-time.Minute)
+	cases := []struct {
+		name     string
+		stored   models.ApiKey
+		expected string
+	}{
+		{"valid", models.ApiKey{ExpiresAt: fixedNow.Add(time.Hour)}, ""},
+		{"expired", models.ApiKey{ExpiresAt: fixedNow}, response.InvalidApiKeyError.Code},
+		{"revoked", models.ApiKey{ExpiresAt: fixedNow.Add(time.Hour), RevokedAt: &revokedAt}, response.InvalidApiKeyError.Code},
+	}
+
+	for _, c := range cases {
+		mockRepo := new(mocks.ApiKeyRepo)
+		svc := newApiKeyService(mockRepo)
+		mockRepo.On("FindByHash", mock.Anything, hash).Return(c.stored, response.ApiError{})
+		mockRepo.On("TouchLastUsed", mock.Anything, mock.Anything, fixedNow).Return(response.ApiError{})
+
+		_, apiErr := svc.Authenticate(context.Background(), key)
+
+		assert.Equal(t, c.expected, apiErr.Code, c.name)
+	}
+}
+
+func TestAuthenticateUnknownApiKey(t *testing.T) {
+	mockRepo := new(mocks.ApiKeyRepo)
+	svc := newApiKeyService(mockRepo)
+	mockRepo.On("FindByHash", mock.Anything, mock.Anything).Return(models.ApiKey{}, response.ResourceNotFoundError)
+
+	_, apiErr := svc.Authenticate(context.Background(), "uak_unknownkeyvalue")
+
+	assert.Equal(t, response.InvalidApiKeyError.Code, apiErr.Code)
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+	"user-api/attributes"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+)
+
+type AttributeService interface {
+	// Schema returns the current schema, version 0 being the empty schema
+	// defining no attribute.
+	Schema(ctx context.Context) (models.AttributeSchema, response.ApiError)
+	// SetSchema replaces the schema. Stored attributes are not checked again,
+	// they are on their next change.
+	SetSchema(ctx context.Context, raw []byte) (models.AttributeSchema, response.ApiError)
+	// Validate checks attrs against the schema, as set by an admin.
+	Validate(ctx context.Context, attrs map[string]interface{}) response.ApiError
+	// ValidateChange checks attrs against the schema as set by the user
+	// whose current attributes are before, read only ones being left as is.
+	ValidateChange(ctx context.Context, before, after map[string]interface{}) response.ApiError
+	// ParseFilter types the values of the attribute filters of a query.
+	ParseFilter(ctx context.Context, query map[string]string) (map[string]interface{}, response.ApiError)
+}
+
+type attributeServiceImpl struct {
+	r     repositories.AttributeSchemaRepo
+	ttl   time.Duration
+	cache *schemaCache
+	log   *slog.Logger
+}
+
+type schemaCache struct {
+	mu       sync.Mutex
+	schema   *attributes.Schema
+	loadedAt time.Time
+}
+
+// NewAttribute caches the compiled schema for ttl, bounding how late an
+// instance sees a schema saved by another one.
+func NewAttribute(r repositories.AttributeSchemaRepo, ttl time.Duration, logger *slog.Logger) AttributeService {
+	return attributeServiceImpl{
+		r:     r,
+		ttl:   ttl,
+		cache: &schemaCache{},
+		log:   logger.With("component", "attribute_service"),
+	}
+}
+
+func (svc attributeServiceImpl) Schema(ctx context.Context) (models.AttributeSchema, response.ApiError) {
+	s, apiErr := svc.r.Get(ctx)
+	if apiErr.Status == response.ResourceNotFoundError.Status {
+		return models.AttributeSchema{Schema: `{"type":"object","properties":{}}`}, response.ApiError{}
+	}
+	return s, apiErr
+}
+
+func (svc attributeServiceImpl) SetSchema(ctx context.Context, raw []byte) (models.AttributeSchema, response.ApiError) {
+	compiled, err := attributes.Compile(raw)
+	if err != nil {
+		return models.AttributeSchema{}, response.InvalidSchemaError.WithDetail(err.Error())
+	}
+
+	s, apiErr := svc.r.Save(ctx, models.AttributeSchema{Schema: string(raw), UpdatedAt: time.Now()})
+	if apiErr.Status != 0 {
+		return s, apiErr
+	}
+
+	svc.cache.mu.Lock()
+	svc.cache.schema, svc.cache.loadedAt = compiled, time.Now()
+	svc.cache.mu.Unlock()
+
+	svc.log.InfoContext(ctx, "attribute schema changed", "version", s.Version, "attributes", compiled.Names())
+	return s, response.ApiError{}
+}
+
+// compiled returns the cached schema, reading it again once older than ttl.
+func (svc attributeServiceImpl) compiled(ctx context.Context) (*attributes.Schema, response.ApiError) {
+	svc.cache.mu.Lock()
+	defer svc.cache.mu.Unlock()
+	if svc.cache.schema != nil && time.Since(svc.cache.loadedAt) < svc.ttl {
+		return svc.cache.schema, response.ApiError{}
+	}
+
+	s, apiErr := svc.r.Get(ctx)
+	switch {
+	case apiErr.Status == response.ResourceNotFoundError.Status:
+		svc.cache.schema = attributes.Empty
+	case apiErr.Status != 0:
+		return nil, apiErr
+	default:
+		compiled, err := attributes.Compile([]byte(s.Schema))
+		if err != nil {
+			svc.log.ErrorContext(ctx, "error compiling stored attribute schema", "version", s.Version, "error", err)
+			return nil, response.InternalServerError
+		}
+		svc.cache.schema = compiled
+	}
+	svc.cache.loadedAt = time.Now()
+	return svc.cache.schema, response.ApiError{}
+}
+
+func (svc attributeServiceImpl) Validate(ctx context.Context, attrs map[string]interface{}) response.ApiError {
+	schema, apiErr := svc.compiled(ctx)
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+	if v := schema.Validate(attrs); len(v) != 0 {
+		return response.NewValidationError(v)
+	}
+	return response.ApiError{}
+}
+
+func (svc attributeServiceImpl) ValidateChange(ctx context.Context, before, after map[string]interface{}) response.ApiError {
+	schema, apiErr := svc.compiled(ctx)
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+	if changed := schema.ChangedReadOnly(before, after); len(changed) != 0 {
+		v := url.Values{}
+		for _, name := range changed {
+			v.Add(attributes.Field+"."+name, "read_only")
+		}
+		return response.NewValidationError(v)
+	}
+	if v := schema.Validate(after); len(v) != 0 {
+		return response.NewValidationError(v)
+	}
+	return response.ApiError{}
+}
+
+func (svc attributeServiceImpl) ParseFilter(ctx context.Context, query map[string]string) (map[string]interface{}, response.ApiError) {
+	if len(query) == 0 {
+		return nil, response.ApiError{}
+	}
+	schema, apiErr := svc.compiled(ctx)
+	if apiErr.Status != 0 {
+		return nil, apiErr
+	}
+
+	filter := make(map[string]interface{}, len(query))
+	v := url.Values{}
+	for name, raw := range query {
+		value, rule := schema.ParseValue(name, raw)
+		if rule != "" {
+			v.Add(attributes.Field+"."+name, rule)
+			continue
+		}
+		filter[name] = value
+	}
+	if len(v) != 0 {
+		return nil, response.NewValidationError(v)
+	}
+	return filter, response.ApiError{}
+}
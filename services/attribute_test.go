@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testAttributeSchema = `{
+	"type": "object",
+	"properties": {
+		"marketing_opt_in": {"type": "boolean"},
+		"plan": {"type": "string", "enum": ["free", "pro"], "readOnly": true}
+	}
+}`
+
+// testAttributes is the attribute service of a deployment without schema.
+func testAttributes() AttributeService {
+	r := new(mocks.AttributeSchemaRepo)
+	r.On("Get", mock.Anything).Return(models.AttributeSchema{}, response.ResourceNotFoundError)
+	return NewAttribute(r, time.Minute, logging.Discard())
+}
+
+func newAttributeService(schema string) (AttributeService, *mocks.AttributeSchemaRepo) {
+	r := new(mocks.AttributeSchemaRepo)
+	r.On("Get", mock.Anything).Return(models.AttributeSchema{Schema: schema, Version: 1}, response.ApiError{})
+	return NewAttribute(r, time.Minute, logging.Discard()), r
+}
+
+func TestValidateChangeKeepsReadOnlyAttributes(t *testing.T) {
+	svc, _ := newAttributeService(testAttributeSchema)
+	ctx := context.Background()
+	before := map[string]interface{}{"plan": "pro"}
+
+	apiErr := svc.ValidateChange(ctx, before, map[string]interface{}{"plan": "pro", "marketing_opt_in": true})
+	assert.Equal(t, 0, apiErr.Status)
+
+	apiErr = svc.ValidateChange(ctx, before, map[string]interface{}{"plan": "free"})
+	assert.Equal(t, response.ValidationError.Code, apiErr.Code)
+	assert.Equal(t, "attributes.plan", apiErr.Errors[0].Field)
+	assert.Equal(t, "READ_ONLY", apiErr.Errors[0].Code)
+
+	assert.Equal(t, 0, svc.Validate(ctx, map[string]interface{}{"plan": "free"}).Status)
+	assert.Equal(t, "SCHEMA", svc.Validate(ctx, map[string]interface{}{"plan": "gold"}).Errors[0].Code)
+}
+
+func TestAttributeSchemaIsCached(t *testing.T) {
+	svc, r := newAttributeService(testAttributeSchema)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 0, svc.Validate(context.Background(), nil).Status)
+	}
+
+	r.AssertNumberOfCalls(t, "Get", 1)
+}
+
+func TestSetSchemaRejectsInvalidSchema(t *testing.T) {
+	svc, r := newAttributeService(testAttributeSchema)
+
+	_, apiErr := svc.SetSchema(context.Background(), []byte(`{"type": "array"}`))
+
+	assert.Equal(t, response.InvalidSchemaError.Code, apiErr.Code)
+	assert.NotEmpty(t, apiErr.Detail)
+	r.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestSetSchemaReplacesCachedSchema(t *testing.T) {
+	svc, r := newAttributeService(testAttributeSchema)
+	r.On("Save", mock.Anything, mock.AnythingOfType("models.AttributeSchema")).Return(models.AttributeSchema{Version: 2}, response.ApiError{})
+	ctx := context.Background()
+
+	_, apiErr := svc.SetSchema(ctx, []byte(`{"type": "object", "properties": {"crm_id": {"type": "string"}}}`))
+	assert.Equal(t, 0, apiErr.Status)
+
+	assert.Equal(t, 0, svc.Validate(ctx, map[string]interface{}{"crm_id": "42"}).Status)
+	assert.Equal(t, "UNKNOWN", svc.Validate(ctx, map[string]interface{}{"plan": "pro"}).Errors[0].Code)
+	r.AssertNotCalled(t, "Get", mock.Anything)
+}
+
+func TestParseFilter(t *testing.T) {
+	svc, _ := newAttributeService(testAttributeSchema)
+
+	filter, apiErr := svc.ParseFilter(context.Background(), map[string]string{"marketing_opt_in": "true"})
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, map[string]interface{}{"marketing_opt_in": true}, filter)
+
+	_, apiErr = svc.ParseFilter(context.Background(), map[string]string{"crm_id": "42"})
+	assert.Equal(t, "UNKNOWN", apiErr.Errors[0].Code)
+}
+
+func TestUpdateByIdRejectsReadOnlyChange(t *testing.T) {
+	attrs, _ := newAttributeService(testAttributeSchema)
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindById", mock.Anything, "id").Return(models.User{Attributes: map[string]interface{}{"plan": "free"}}, response.ApiError{})
+	svc := userServiceImpl{r: mockUserRepo, attributes: attrs, log: logging.Discard()}
+
+	apiErr := svc.UpdateById(context.Background(), "id", models.User{Name: "test", Attributes: map[string]interface{}{"plan": "pro"}})
+
+	assert.Equal(t, response.ValidationError.Code, apiErr.Code)
+	mockUserRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything)
+}
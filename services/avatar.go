@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+	"user-api/images"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+	"user-api/storage"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// avatarSizes are the sides in pixels of the square images of an avatar,
+// the first being its url and the others its thumbnails.
+var avatarSizes = []int{512, 256, 128, 64}
+
+type AvatarService interface {
+	// Set makes the image data the avatar of the user id, deleting the
+	// images of the previous one.
+	Set(ctx context.Context, id string, data []byte) (models.Avatar, response.ApiError)
+	Delete(ctx context.Context, id string) response.ApiError
+	// Remove deletes the images of an avatar no user refers to anymore,
+	// failures are only logged.
+	Remove(ctx context.Context, avatar models.Avatar)
+}
+
+type avatarServiceImpl struct {
+	r      repositories.UserRepo
+	store  storage.BlobStore
+	outbox *Outbox
+	log    *slog.Logger
+}
+
+func NewAvatar(r repositories.UserRepo, store storage.BlobStore, outbox *Outbox, logger *slog.Logger) AvatarService {
+	return avatarServiceImpl{
+		r:      r,
+		store:  store,
+		outbox: outbox,
+		log:    logger.With("component", "avatar_service"),
+	}
+}
+
+func (svc avatarServiceImpl) Set(ctx context.Context, id string, data []byte) (models.Avatar, response.ApiError) {
+	// the id is part of the blob keys
+	if !primitive.IsValidObjectID(id) {
+		svc.log.InfoContext(ctx, "invalid id format", "user_id", id)
+		return models.Avatar{}, response.BadRequestError
+	}
+
+	squares, err := images.Squares(data, avatarSizes)
+	switch {
+	case errors.Is(err, images.ErrUnsupportedFormat):
+		return models.Avatar{}, response.UnsupportedMediaTypeError
+	case errors.Is(err, images.ErrTooManyPixels):
+		return models.Avatar{}, response.InvalidImageError.WithDetail(fmt.Sprintf("Images are limited to %d pixels", images.MaxPixels))
+	case err != nil:
+		svc.log.InfoContext(ctx, "invalid avatar image", "user_id", id, "error", err)
+		return models.Avatar{}, response.InvalidImageError
+	}
+
+	// every upload has its own keys, so cached images are never stale
+	version := primitive.NewObjectID().Hex()
+	avatar := models.Avatar{Thumbnails: map[string]string{}, UpdatedAt: time.Now().UTC()}
+	for i, img := range squares {
+		key := fmt.Sprintf("avatars/%s/%s/%d%s", id, version, avatarSizes[i], extension(img.ContentType))
+		if err := svc.store.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+			svc.log.ErrorContext(ctx, "error storing avatar image", "user_id", id, "key", key, "error", err)
+			svc.Remove(ctx, avatar)
+			return models.Avatar{}, response.InternalServerError
+		}
+		avatar.Keys = append(avatar.Keys, key)
+		if i == 0 {
+			avatar.URL = svc.store.URL(key)
+		} else {
+			avatar.Thumbnails[strconv.Itoa(avatarSizes[i])] = svc.store.URL(key)
+		}
+	}
+
+	previous, apiErr := svc.updateAvatar(ctx, id, &avatar)
+	if apiErr.Status != 0 {
+		svc.Remove(ctx, avatar)
+		return models.Avatar{}, apiErr
+	}
+	if previous != nil {
+		svc.Remove(ctx, *previous)
+	}
+
+	svc.log.InfoContext(ctx, "avatar changed", "user_id", id)
+	return avatar, response.ApiError{}
+}
+
+func (svc avatarServiceImpl) Delete(ctx context.Context, id string) response.ApiError {
+	previous, apiErr := svc.updateAvatar(ctx, id, nil)
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+	if previous != nil {
+		svc.Remove(ctx, *previous)
+	}
+	return response.ApiError{}
+}
+
+// updateAvatar stores avatar with the event of the change, returning the
+// previous one.
+func (svc avatarServiceImpl) updateAvatar(ctx context.Context, id string, avatar *models.Avatar) (previous *models.Avatar, apiErr response.ApiError) {
+	apiErr = svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		var apiErr response.ApiError
+		previous, apiErr = svc.r.UpdateAvatar(ctx, id, avatar)
+		return apiErr
+	}, updatedEvent(id, "avatar"))
+	return previous, apiErr
+}
+
+func (svc avatarServiceImpl) Remove(ctx context.Context, avatar models.Avatar) {
+	for _, key := range avatar.Keys {
+		if err := svc.store.Delete(ctx, key); err != nil {
+			svc.log.ErrorContext(ctx, "error deleting avatar image, it is orphaned", "key", key, "error", err)
+		}
+	}
+}
+
+func extension(contentType string) string {
+	if contentType == "image/png" {
+		return ".png"
+	}
+	return ".jpg"
+}
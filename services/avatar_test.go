@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	storagemocks "user-api/mocks/storage"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 600, 400))))
+	return buf.Bytes()
+}
+
+func blobURL(key string) string {
+	return "/blobs/" + key
+}
+
+func TestSetAvatarReplacesPrevious(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	previous := &models.Avatar{Keys: []string{"avatars/" + id + "/old/512.png"}}
+	mockUserRepo := new(mocks.UserRepo)
+	mockStore := new(storagemocks.BlobStore)
+	mockStore.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "image/png").Return(nil)
+	mockStore.On("URL", mock.Anything).Return(blobURL)
+	mockStore.On("Delete", mock.Anything, previous.Keys[0]).Return(nil)
+	mockUserRepo.On("UpdateAvatar", mock.Anything, id, mock.AnythingOfType("*models.Avatar")).Return(previous, response.ApiError{})
+	svc := NewAvatar(mockUserRepo, mockStore, nil, logging.Discard())
+
+	avatar, apiErr := svc.Set(context.Background(), id, testPNG(t))
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Len(t, avatar.Keys, 4)
+	assert.Regexp(t, "^/blobs/avatars/"+id+"/[0-9a-f]{24}/512.png$", avatar.URL)
+	assert.Len(t, avatar.Thumbnails, 3)
+	assert.Equal(t, "/blobs/"+avatar.Keys[3], avatar.Thumbnails["64"])
+	mockStore.AssertNumberOfCalls(t, "Put", 4)
+	mockStore.AssertCalled(t, "Delete", mock.Anything, previous.Keys[0])
+}
+
+func TestSetAvatarRemovesImagesOfUnknownUser(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockUserRepo := new(mocks.UserRepo)
+	mockStore := new(storagemocks.BlobStore)
+	mockStore.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockStore.On("URL", mock.Anything).Return(blobURL)
+	mockStore.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	mockUserRepo.On("UpdateAvatar", mock.Anything, id, mock.Anything).Return(nil, response.ResourceNotFoundError)
+	svc := NewAvatar(mockUserRepo, mockStore, nil, logging.Discard())
+
+	_, apiErr := svc.Set(context.Background(), id, testPNG(t))
+
+	assert.Equal(t, response.ResourceNotFoundError.Code, apiErr.Code)
+	mockStore.AssertNumberOfCalls(t, "Delete", 4)
+}
+
+func TestSetAvatarStoreFailure(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	mockStore := new(storagemocks.BlobStore)
+	mockStore.On("Put", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasSuffix(key, "/512.png") }), mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockStore.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("unavailable"))
+	mockStore.On("URL", mock.Anything).Return(blobURL)
+	mockStore.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	svc := NewAvatar(new(mocks.UserRepo), mockStore, nil, logging.Discard())
+
+	_, apiErr := svc.Set(context.Background(), id, testPNG(t))
+
+	assert.Equal(t, response.InternalServerError.Code, apiErr.Code)
+	mockStore.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestSetAvatarRejectsOtherContent(t *testing.T) {
+	svc := NewAvatar(new(mocks.UserRepo), new(storagemocks.BlobStore), nil, logging.Discard())
+
+	_, apiErr := svc.Set(context.Background(), primitive.NewObjectID().Hex(), []byte("GIF89a not really"))
+	assert.Equal(t, response.InvalidImageError.Code, apiErr.Code)
+
+	_, apiErr = svc.Set(context.Background(), primitive.NewObjectID().Hex(), []byte("%PDF-1.4"))
+	assert.Equal(t, response.UnsupportedMediaTypeError.Code, apiErr.Code)
+
+	_, apiErr = svc.Set(context.Background(), "../..", testPNG(t))
+	assert.Equal(t, response.BadRequestError.Code, apiErr.Code)
+}
+
+func TestDeleteByIdRemovesAvatar(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	user := models.User{Avatar: &models.Avatar{Keys: []string{"avatars/" + id + "/v/512.jpg", "avatars/" + id + "/v/64.jpg"}}}
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindById", mock.Anything, id).Return(user, response.ApiError{})
+	mockUserRepo.On("DeleteById", mock.Anything, id).Return(response.ApiError{})
+	mockStore := new(storagemocks.BlobStore)
+	mockStore.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	svc := userServiceImpl{r: mockUserRepo, avatars: NewAvatar(mockUserRepo, mockStore, nil, logging.Discard()), log: logging.Discard()}
+
+	apiErr := svc.DeleteById(context.Background(), id)
+
+	assert.Equal(t, 0, apiErr.Status)
+	for _, key := range user.Avatar.Keys {
+		mockStore.AssertCalled(t, "Delete", mock.Anything, key)
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+	"user-api/auth"
+	"user-api/i18n"
+	"user-api/mail"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+)
+
+type EmailChangeOptions struct {
+	// ConfirmURL and RevertURL are the pages the emailed links open, the
+	// token being added as the token query parameter.
+	ConfirmURL string
+	RevertURL  string
+	ConfirmTTL time.Duration
+	RevertTTL  time.Duration
+}
+
+type EmailChangeService interface {
+	// Request checks the password of u and mails a confirmation link to
+	// email and a revert link to the current email of u, replacing the
+	// pending requests of u.
+	Request(ctx context.Context, u models.User, password string, email string) response.ApiError
+	// Confirm applies the change of a confirmation link token and ends
+	// every session of the user.
+	Confirm(ctx context.Context, token string) (models.EmailChange, response.ApiError)
+	// Revert cancels the change of a revert link token, or undoes it once
+	// confirmed, and ends every session of the user.
+	Revert(ctx context.Context, token string) (models.EmailChange, response.ApiError)
+}
+
+type emailChangeServiceImpl struct {
+	r        repositories.EmailChangeRepo
+	users    repositories.UserRepo
+	sessions SessionService
+	mailer   mail.Mailer
+	hasher   auth.PasswordHasher
+	outbox   *Outbox
+	opts     EmailChangeOptions
+	log      *slog.Logger
+	now      func() time.Time
+}
+
+func NewEmailChange(r repositories.EmailChangeRepo, users repositories.UserRepo, sessions SessionService, mailer mail.Mailer, hasher auth.PasswordHasher, outbox *Outbox, opts EmailChangeOptions, logger *slog.Logger) EmailChangeService {
+	return emailChangeServiceImpl{
+		r:        r,
+		users:    users,
+		sessions: sessions,
+		mailer:   mailer,
+		hasher:   hasher,
+		outbox:   outbox,
+		opts:     opts,
+		log:      logger.With("component", "email_change_service"),
+		now:      time.Now,
+	}
+}
+
+func (svc emailChangeServiceImpl) Request(ctx context.Context, u models.User, password string, email string) response.ApiError {
+	if apiErr := withPasswordHash(ctx, svc.users, &u); apiErr.Status != 0 {
+		return apiErr
+	}
+	if err := u.CheckPassword(svc.hasher, password); err != nil {
+		svc.log.InfoContext(ctx, "invalid password", "user", u, "error", err)
+		return response.InvalidCredentialsError
+	}
+
+	// the unique index and the lookups by email are case sensitive
+	email = strings.ToLower(email)
+	if strings.EqualFold(email, u.Email) {
+		return response.EmailAlreadyInUse
+	}
+	_, apiErr := svc.users.FindByField(ctx, email, "email")
+	if apiErr.Status == 0 {
+		return response.EmailAlreadyInUse
+	}
+	if apiErr.Status != response.ResourceNotFoundError.Status {
+		return apiErr
+	}
+
+	confirmToken, confirmHash, err := auth.GenerateLinkToken()
+	if err != nil {
+		svc.log.ErrorContext(ctx, "error generating link token", "error", err)
+		return response.InternalServerError
+	}
+	revertToken, revertHash, err := auth.GenerateLinkToken()
+	if err != nil {
+		svc.log.ErrorContext(ctx, "error generating link token", "error", err)
+		return response.InternalServerError
+	}
+
+	if apiErr := svc.r.DeletePending(ctx, u.ID); apiErr.Status != 0 {
+		return apiErr
+	}
+	now := svc.now().UTC()
+	change, apiErr := svc.r.Save(ctx, models.EmailChange{
+		UserID:      u.ID,
+		OldEmail:    u.Email,
+		NewEmail:    email,
+		ConfirmHash: confirmHash,
+		RevertHash:  revertHash,
+		CreatedAt:   now,
+		ConfirmBy:   now.Add(svc.opts.ConfirmTTL),
+		RevertBy:    now.Add(svc.opts.RevertTTL),
+	})
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+
+	if err := svc.send(ctx, u, change, confirmToken, revertToken); err != nil {
+		svc.log.ErrorContext(ctx, "error mailing email change links", "user", u, "error", err)
+		svc.r.Delete(ctx, change.ID)
+		return response.InternalServerError
+	}
+
+	svc.log.InfoContext(ctx, "email change requested", "user", u)
+	return response.ApiError{}
+}
+
+// send mails the confirmation link to the new email and the revert link to
+// the old one, in the language of u.
+func (svc emailChangeServiceImpl) send(ctx context.Context, u models.User, change models.EmailChange, confirmToken, revertToken string) error {
+	subject, body := i18n.Mail(u.Locale, "email_change_confirm", u.Name, change.NewEmail, link(svc.opts.ConfirmURL, confirmToken), int(svc.opts.ConfirmTTL.Hours()))
+	if err := svc.mailer.Send(ctx, mail.Message{To: change.NewEmail, Subject: subject, Body: body}); err != nil {
+		return err
+	}
+
+	subject, body = i18n.Mail(u.Locale, "email_change_notice", u.Name, change.NewEmail, link(svc.opts.RevertURL, revertToken), int(svc.opts.RevertTTL.Hours()/24))
+	return svc.mailer.Send(ctx, mail.Message{To: change.OldEmail, Subject: subject, Body: body})
+}
+
+func link(page string, token string) string {
+	u, err := url.Parse(page)
+	if err != nil {
+		return page + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (svc emailChangeServiceImpl) Confirm(ctx context.Context, token string) (models.EmailChange, response.ApiError) {
+	change, apiErr := svc.r.Confirm(ctx, auth.HashLinkToken(token), svc.now().UTC())
+	if apiErr.Status != 0 {
+		return change, apiErr
+	}
+
+	userID := change.UserID.Hex()
+	apiErr = svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.users.UpdateEmail(ctx, userID, change.OldEmail, change.NewEmail)
+	}, updatedEvent(userID, "email"))
+	if apiErr.Status != 0 {
+		svc.log.InfoContext(ctx, "email change not applied", "user_id", userID, "code", apiErr.Code)
+		svc.r.Unconfirm(ctx, change.ID)
+		return change, apiErr
+	}
+
+	svc.log.InfoContext(ctx, "email changed", "user_id", userID)
+	return change, svc.sessions.RevokeOthers(ctx, userID, "")
+}
+
+func (svc emailChangeServiceImpl) Revert(ctx context.Context, token string) (models.EmailChange, response.ApiError) {
+	change, apiErr := svc.r.Revert(ctx, auth.HashLinkToken(token), svc.now().UTC())
+	if apiErr.Status != 0 {
+		return change, apiErr
+	}
+
+	userID := change.UserID.Hex()
+	if change.ConfirmedAt != nil {
+		apiErr := svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+			return svc.users.UpdateEmail(ctx, userID, change.NewEmail, change.OldEmail)
+		}, updatedEvent(userID, "email"))
+		if apiErr.Status != 0 {
+			svc.log.InfoContext(ctx, "email change not reverted", "user_id", userID, "code", apiErr.Code)
+			svc.r.Unrevert(ctx, change.ID)
+			return change, apiErr
+		}
+	}
+
+	svc.log.InfoContext(ctx, "email change reverted", "user_id", userID, "confirmed", change.ConfirmedAt != nil)
+	return change, svc.sessions.RevokeOthers(ctx, userID, "")
+}
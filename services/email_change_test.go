@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"user-api/auth"
+	"user-api/logging"
+	"user-api/mail"
+	mailmocks "user-api/mocks/mail"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testEmailChangeOptions = EmailChangeOptions{
+	ConfirmURL: "https://example.com/email/confirm",
+	RevertURL:  "https://example.com/email/revert",
+	ConfirmTTL: 24 * time.Hour,
+	RevertTTL:  7 * 24 * time.Hour,
+}
+
+func emailChangeUser(t *testing.T) models.User {
+	u := models.User{ID: primitive.NewObjectID(), Name: "Ana", Email: "ana@example.com", Password: "c0rrect-Horse", Locale: "pt"}
+	assert.Nil(t, u.HashPassword(testHasher))
+	return u
+}
+
+// linkTokenOf returns the token query parameter of the link in body.
+func linkTokenOf(t *testing.T, body string) string {
+	for _, field := range strings.Fields(body) {
+		if u, err := url.Parse(field); err == nil && u.Query().Get("token") != "" {
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("no link in %q", body)
+	return ""
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	u := emailChangeUser(t)
+	mockRepo := new(mocks.EmailChangeRepo)
+	mockUserRepo := new(mocks.UserRepo)
+	mockMailer := new(mailmocks.Mailer)
+	mockUserRepo.On("FindByField", mock.Anything, "new@example.com", "email").Return(models.User{}, response.ResourceNotFoundError)
+	mockRepo.On("DeletePending", mock.Anything, u.ID).Return(response.ApiError{})
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(func(_ context.Context, c models.EmailChange) models.EmailChange {
+		c.ID = primitive.NewObjectID()
+		return c
+	}, response.ApiError{})
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+	svc := NewEmailChange(mockRepo, mockUserRepo, nil, mockMailer, testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	apiErr := svc.Request(context.Background(), u, "c0rrect-Horse", "New@Example.com")
+
+	assert.Equal(t, 0, apiErr.Status)
+	saved := mockRepo.Calls[1].Arguments.Get(1).(models.EmailChange)
+	assert.Equal(t, "ana@example.com", saved.OldEmail)
+	assert.Equal(t, "new@example.com", saved.NewEmail)
+	assert.Equal(t, 24*time.Hour, saved.ConfirmBy.Sub(saved.CreatedAt))
+	assert.Equal(t, 7*24*time.Hour, saved.RevertBy.Sub(saved.CreatedAt))
+
+	confirm := mockMailer.Calls[0].Arguments.Get(1).(mail.Message)
+	assert.Equal(t, "new@example.com", confirm.To)
+	assert.Contains(t, confirm.Body, "https://example.com/email/confirm?token=")
+	assert.Equal(t, saved.ConfirmHash, auth.HashLinkToken(linkTokenOf(t, confirm.Body)))
+
+	notice := mockMailer.Calls[1].Arguments.Get(1).(mail.Message)
+	assert.Equal(t, "ana@example.com", notice.To)
+	assert.Contains(t, notice.Body, "new@example.com")
+	assert.Equal(t, saved.RevertHash, auth.HashLinkToken(linkTokenOf(t, notice.Body)))
+}
+
+func TestRequestEmailChangeWrongPassword(t *testing.T) {
+	u := emailChangeUser(t)
+	mockRepo := new(mocks.EmailChangeRepo)
+	svc := NewEmailChange(mockRepo, new(mocks.UserRepo), nil, new(mailmocks.Mailer), testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	apiErr := svc.Request(context.Background(), u, "wrong", "new@example.com")
+
+	assert.Equal(t, response.InvalidCredentialsError.Code, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestRequestEmailChangeEmailInUse(t *testing.T) {
+	u := emailChangeUser(t)
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindByField", mock.Anything, "taken@example.com", "email").Return(models.User{Email: "taken@example.com"}, response.ApiError{})
+	svc := NewEmailChange(new(mocks.EmailChangeRepo), mockUserRepo, nil, new(mailmocks.Mailer), testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	assert.Equal(t, response.EmailAlreadyInUse.Code, svc.Request(context.Background(), u, "c0rrect-Horse", "taken@example.com").Code)
+	assert.Equal(t, response.EmailAlreadyInUse.Code, svc.Request(context.Background(), u, "c0rrect-Horse", "Taken@Example.com").Code)
+	assert.Equal(t, response.EmailAlreadyInUse.Code, svc.Request(context.Background(), u, "c0rrect-Horse", u.Email).Code)
+	assert.Equal(t, response.EmailAlreadyInUse.Code, svc.Request(context.Background(), u, "c0rrect-Horse", strings.ToUpper(u.Email)).Code)
+}
+
+func TestRequestEmailChangeMailFailure(t *testing.T) {
+	u := emailChangeUser(t)
+	id := primitive.NewObjectID()
+	mockRepo := new(mocks.EmailChangeRepo)
+	mockUserRepo := new(mocks.UserRepo)
+	mockMailer := new(mailmocks.Mailer)
+	mockUserRepo.On("FindByField", mock.Anything, mock.Anything, "email").Return(models.User{}, response.ResourceNotFoundError)
+	mockRepo.On("DeletePending", mock.Anything, u.ID).Return(response.ApiError{})
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(models.EmailChange{ID: id}, response.ApiError{})
+	mockRepo.On("Delete", mock.Anything, id).Return(response.ApiError{})
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(errors.New("unavailable"))
+	svc := NewEmailChange(mockRepo, mockUserRepo, nil, mockMailer, testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	apiErr := svc.Request(context.Background(), u, "c0rrect-Horse", "new@example.com")
+
+	assert.Equal(t, response.InternalServerError.Code, apiErr.Code)
+	mockRepo.AssertCalled(t, "Delete", mock.Anything, id)
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	change := models.EmailChange{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), OldEmail: "ana@example.com", NewEmail: "new@example.com"}
+	userID := change.UserID.Hex()
+	mockRepo := new(mocks.EmailChangeRepo)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessions := new(mocks.SessionRepo)
+	mockRepo.On("Confirm", mock.Anything, auth.HashLinkToken("token"), mock.Anything).Return(change, response.ApiError{})
+	mockUserRepo.On("UpdateEmail", mock.Anything, userID, "ana@example.com", "new@example.com").Return(response.ApiError{})
+	mockSessions.On("RevokeAllExcept", mock.Anything, userID, "", mock.Anything).Return(response.ApiError{})
+	mockUserRepo.On("RevokeTokens", mock.Anything, userID, mock.AnythingOfType("time.Time")).Return(response.ApiError{})
+	svc := NewEmailChange(mockRepo, mockUserRepo, NewSession(mockSessions, mockUserRepo, logging.Discard()), nil, testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	_, apiErr := svc.Confirm(context.Background(), "token")
+
+	assert.Equal(t, 0, apiErr.Status)
+	mockSessions.AssertCalled(t, "RevokeAllExcept", mock.Anything, userID, "", mock.Anything)
+}
+
+func TestConfirmEmailChangeTakenMeanwhile(t *testing.T) {
+	change := models.EmailChange{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), OldEmail: "ana@example.com", NewEmail: "new@example.com"}
+	mockRepo := new(mocks.EmailChangeRepo)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessions := new(mocks.SessionRepo)
+	mockRepo.On("Confirm", mock.Anything, mock.Anything, mock.Anything).Return(change, response.ApiError{})
+	mockRepo.On("Unconfirm", mock.Anything, change.ID).Return(response.ApiError{})
+	mockUserRepo.On("UpdateEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(response.EmailAlreadyInUse)
+	svc := NewEmailChange(mockRepo, mockUserRepo, NewSession(mockSessions, mockUserRepo, logging.Discard()), nil, testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	_, apiErr := svc.Confirm(context.Background(), "token")
+
+	assert.Equal(t, response.EmailAlreadyInUse.Code, apiErr.Code)
+	mockRepo.AssertCalled(t, "Unconfirm", mock.Anything, change.ID)
+	mockSessions.AssertNotCalled(t, "RevokeAllExcept", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRevertConfirmedEmailChange(t *testing.T) {
+	confirmedAt := time.Now()
+	change := models.EmailChange{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), OldEmail: "ana@example.com", NewEmail: "new@example.com", ConfirmedAt: &confirmedAt}
+	userID := change.UserID.Hex()
+	mockRepo := new(mocks.EmailChangeRepo)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessions := new(mocks.SessionRepo)
+	mockRepo.On("Revert", mock.Anything, auth.HashLinkToken("token"), mock.Anything).Return(change, response.ApiError{})
+	mockUserRepo.On("UpdateEmail", mock.Anything, userID, "new@example.com", "ana@example.com").Return(response.ApiError{})
+	mockSessions.On("RevokeAllExcept", mock.Anything, userID, "", mock.Anything).Return(response.ApiError{})
+	mockUserRepo.On("RevokeTokens", mock.Anything, userID, mock.AnythingOfType("time.Time")).Return(response.ApiError{})
+	svc := NewEmailChange(mockRepo, mockUserRepo, NewSession(mockSessions, mockUserRepo, logging.Discard()), nil, testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	_, apiErr := svc.Revert(context.Background(), "token")
+
+	assert.Equal(t, 0, apiErr.Status)
+	mockUserRepo.AssertCalled(t, "UpdateEmail", mock.Anything, userID, "new@example.com", "ana@example.com")
+	mockSessions.AssertCalled(t, "RevokeAllExcept", mock.Anything, userID, "", mock.Anything)
+}
+
+func TestRevertPendingEmailChange(t *testing.T) {
+	change := models.EmailChange{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), OldEmail: "ana@example.com", NewEmail: "new@example.com"}
+	mockRepo := new(mocks.EmailChangeRepo)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessions := new(mocks.SessionRepo)
+	mockRepo.On("Revert", mock.Anything, mock.Anything, mock.Anything).Return(change, response.ApiError{})
+	mockSessions.On("RevokeAllExcept", mock.Anything, change.UserID.Hex(), "", mock.Anything).Return(response.ApiError{})
+	mockUserRepo.On("RevokeTokens", mock.Anything, change.UserID.Hex(), mock.AnythingOfType("time.Time")).Return(response.ApiError{})
+	svc := NewEmailChange(mockRepo, mockUserRepo, NewSession(mockSessions, mockUserRepo, logging.Discard()), nil, testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	_, apiErr := svc.Revert(context.Background(), "token")
+
+	assert.Equal(t, 0, apiErr.Status)
+	mockUserRepo.AssertNotCalled(t, "UpdateEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEmailChangeInvalidLink(t *testing.T) {
+	mockRepo := new(mocks.EmailChangeRepo)
+	mockRepo.On("Confirm", mock.Anything, mock.Anything, mock.Anything).Return(models.EmailChange{}, response.InvalidLinkError)
+	svc := NewEmailChange(mockRepo, new(mocks.UserRepo), nil, nil, testHasher, nil, testEmailChangeOptions, logging.Discard())
+
+	_, apiErr := svc.Confirm(context.Background(), "used")
+
+	assert.Equal(t, response.InvalidLinkError.Code, apiErr.Code)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"user-api/events"
+	"user-api/metrics"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+	"user-api/webhooks"
+)
+
+// Outbox stores the changes of the users with their events in one
+// transaction, so an event is relayed if and only if its change is stored.
+type Outbox struct {
+	tx repositories.Transactor
+	r  repositories.OutboxRepo
+}
+
+func NewOutbox(tx repositories.Transactor, r repositories.OutboxRepo) *Outbox {
+	return &Outbox{tx: tx, r: r}
+}
+
+// Store runs write and appends events in its transaction unless it fails.
+// A nil Outbox runs write alone, recording no event.
+func (o *Outbox) Store(ctx context.Context, write func(ctx context.Context) response.ApiError, evts ...models.Event) response.ApiError {
+	if o == nil {
+		return write(ctx)
+	}
+	return o.tx.WithTransaction(ctx, func(ctx context.Context) response.ApiError {
+		apiErr := write(ctx)
+		if apiErr.Status != 0 {
+			return apiErr
+		}
+		if appendErr := o.r.Append(ctx, evts...); appendErr.Status != 0 {
+			return appendErr
+		}
+		return apiErr
+	})
+}
+
+// OutboxOptions tunes the relay of the stored events.
+type OutboxOptions struct {
+	// PollInterval is how long the relay waits before looking for
+	// unpublished events again once none is due.
+	PollInterval time.Duration
+	// BatchSize bounds the users whose next event is relayed per round.
+	BatchSize int
+	// Lease is how long a relay stays the only one after each round, the
+	// relay of another instance taking over once it elapsed.
+	Lease time.Duration
+	// RetryBase and RetryMax bound the exponential backoff of an event the
+	// sinks failed to accept, the later events of its user waiting for it.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+}
+
+// OutboxRelay publishes the stored events to the sinks, at least once and
+// in order for every user. A single instance relays at a time.
+type OutboxRelay struct {
+	r     repositories.OutboxRepo
+	sinks []events.Sink
+	owner string
+	opts  OutboxOptions
+	log   *slog.Logger
+	now   func() time.Time
+}
+
+// NewOutboxRelay returns a relay to sinks, owner naming the instance
+// holding the lease.
+func NewOutboxRelay(r repositories.OutboxRepo, sinks []events.Sink, owner string, opts OutboxOptions, logger *slog.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		r:     r,
+		sinks: sinks,
+		owner: owner,
+		opts:  opts,
+		log:   logger.With("component", "outbox_relay"),
+		now:   time.Now,
+	}
+}
+
+// Run relays the events until ctx is done, releasing the lease on return so
+// another instance takes over at once.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	defer r.r.Release(context.Background(), r.owner)
+	for {
+		relayed, _ := r.RelayOnce(ctx)
+		if relayed > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.opts.PollInterval):
+		}
+	}
+}
+
+// RelayOnce takes or renews the lease and publishes the next event of up to
+// BatchSize users, returning how many were published.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (relayed int, apiErr response.ApiError) {
+	now := r.now()
+	held, apiErr := r.r.Lease(ctx, r.owner, r.opts.Lease)
+	if apiErr.Status != 0 || !held {
+		return 0, apiErr
+	}
+	// the round stops halfway through the lease so it is renewed in time
+	deadline := now.Add(r.opts.Lease / 2)
+
+	heads, apiErr := r.r.Heads(ctx, now, r.opts.BatchSize)
+	if apiErr.Status != 0 {
+		return 0, apiErr
+	}
+	for _, e := range heads {
+		if ctx.Err() != nil || r.now().After(deadline) {
+			break
+		}
+		if r.relay(ctx, e) {
+			relayed++
+		}
+	}
+	return relayed, response.ApiError{}
+}
+
+// relay publishes e to the sinks it was not yet published to, scheduling a
+// retry when one fails.
+func (r *OutboxRelay) relay(ctx context.Context, e models.OutboxEvent) bool {
+	for _, sink := range r.sinks {
+		if e.SentTo(sink.Name()) {
+			continue
+		}
+		if err := sink.Publish(ctx, e.Event); err != nil {
+			metrics.OutboxRelayed(sink.Name(), metrics.OutboxFailed)
+			next := r.now().Add(webhooks.Backoff(e.Attempts+1, r.opts.RetryBase, r.opts.RetryMax)).UTC()
+			r.log.WarnContext(ctx, "error publishing event", "sink", sink.Name(), "event_id", e.ID.Hex(), "user_id", e.UserID, "attempts", e.Attempts+1, "next_attempt_at", next, "error", err)
+			r.r.MarkFailed(ctx, e.ID, sink.Name()+": "+err.Error(), next)
+			return false
+		}
+		metrics.OutboxRelayed(sink.Name(), metrics.OutboxPublished)
+		if apiErr := r.r.MarkSent(ctx, e.ID, sink.Name()); apiErr.Status != 0 {
+			return false
+		}
+	}
+	return r.r.MarkPublished(ctx, e.ID, r.now().UTC()).Status == 0
+}
+
+type webhookSink struct {
+	svc WebhookService
+}
+
+// NewWebhookSink returns the sink queuing the deliveries of the events to
+// the subscribed webhooks.
+func NewWebhookSink(svc WebhookService) events.Sink {
+	return webhookSink{svc: svc}
+}
+
+func (s webhookSink) Name() string {
+	return "webhooks"
+}
+
+func (s webhookSink) Publish(ctx context.Context, e models.Event) error {
+	if apiErr := s.svc.Publish(ctx, e); apiErr.Status != 0 {
+		return apiErr
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"user-api/events"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+	"user-api/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testOutboxOptions = OutboxOptions{
+	PollInterval: time.Millisecond,
+	BatchSize:    10,
+	Lease:        time.Minute,
+	RetryBase:    time.Second,
+	RetryMax:     time.Minute,
+}
+
+// testSink records the events it is given, failing with err when set.
+type testSink struct {
+	name      string
+	err       error
+	published []models.Event
+}
+
+func (s *testSink) Name() string {
+	return s.name
+}
+
+func (s *testSink) Publish(_ context.Context, e models.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.published = append(s.published, e)
+	return nil
+}
+
+// newTestTransactor returns a Transactor running the functions it is given
+// as is.
+func newTestTransactor() *mocks.Transactor {
+	tx := new(mocks.Transactor)
+	tx.On("WithTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(ctx context.Context) response.ApiError) response.ApiError {
+		return fn(ctx)
+	})
+	return tx
+}
+
+func TestStoreAppendsEventsOfWrite(t *testing.T) {
+	e := models.NewEvent(models.EventUserDeleted, "user", nil)
+	mockOutbox := new(mocks.OutboxRepo)
+	mockOutbox.On("Append", mock.Anything, e).Return(response.ApiError{})
+	written := false
+
+	apiErr := NewOutbox(newTestTransactor(), mockOutbox).Store(context.Background(), func(ctx context.Context) response.ApiError {
+		written = true
+		return response.ApiError{}
+	}, e)
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.True(t, written)
+	mockOutbox.AssertExpectations(t)
+}
+
+func TestStoreSkipsEventsOfFailedWrite(t *testing.T) {
+	mockOutbox := new(mocks.OutboxRepo)
+
+	apiErr := NewOutbox(newTestTransactor(), mockOutbox).Store(context.Background(), func(ctx context.Context) response.ApiError {
+		return response.ResourceNotFoundError
+	}, models.NewEvent(models.EventUserDeleted, "user", nil))
+
+	assert.Equal(t, response.ResourceNotFoundError, apiErr)
+	mockOutbox.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+}
+
+func TestRegisterStoresUserCreated(t *testing.T) {
+	u := models.NewUser("test", &testBirthDate, "test@test.com", "pass", "add")
+	mockUserRepo := new(mocks.UserRepo)
+	mockOutbox := new(mocks.OutboxRepo)
+	mockUserRepo.On("FindByField", mock.Anything, u.Email, "email").Return(models.User{}, response.ResourceNotFoundError)
+	mockUserRepo.On("Save", mock.Anything, mock.AnythingOfType("models.User")).Return(response.ApiError{})
+	mockOutbox.On("Append", mock.Anything, mock.AnythingOfType("models.Event")).Return(response.ApiError{})
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, outbox: NewOutbox(newTestTransactor(), mockOutbox), log: logging.Discard()}
+
+	registered, apiErr := svc.Register(context.Background(), *u)
+
+	assert.Equal(t, 0, apiErr.Status)
+	e := mockOutbox.Calls[0].Arguments.Get(1).(models.Event)
+	assert.Equal(t, models.EventUserCreated, e.Type)
+	assert.Equal(t, registered.ID.Hex(), e.UserID)
+}
+
+func testOutboxEvent(userID string, seq int64) models.OutboxEvent {
+	e := models.NewEvent(models.EventUserUpdated, userID, nil)
+	e.Seq = seq
+	return models.OutboxEvent{Event: e}
+}
+
+func newTestRelay(r *mocks.OutboxRepo, sinks ...events.Sink) *OutboxRelay {
+	relay := NewOutboxRelay(r, sinks, "test", testOutboxOptions, logging.Discard())
+	relay.now = func() time.Time { return testDispatchTime }
+	return relay
+}
+
+func TestRelayPublishesHeadOfEveryUser(t *testing.T) {
+	first, second := testOutboxEvent("a", 1), testOutboxEvent("b", 3)
+	mockOutbox := new(mocks.OutboxRepo)
+	mockOutbox.On("Lease", mock.Anything, "test", time.Minute).Return(true, response.ApiError{})
+	mockOutbox.On("Heads", mock.Anything, testDispatchTime, 10).Return([]models.OutboxEvent{first, second}, response.ApiError{})
+	mockOutbox.On("MarkSent", mock.Anything, mock.Anything, "log").Return(response.ApiError{})
+	mockOutbox.On("MarkPublished", mock.Anything, mock.Anything, testDispatchTime).Return(response.ApiError{})
+	sink := &testSink{name: "log"}
+
+	relayed, apiErr := newTestRelay(mockOutbox, sink).RelayOnce(context.Background())
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, 2, relayed)
+	assert.Equal(t, []models.Event{first.Event, second.Event}, sink.published)
+	mockOutbox.AssertCalled(t, "MarkPublished", mock.Anything, first.ID, testDispatchTime)
+	mockOutbox.AssertCalled(t, "MarkPublished", mock.Anything, second.ID, testDispatchTime)
+}
+
+func TestRelayRetriesOnlyFailedSinks(t *testing.T) {
+	e := testOutboxEvent("a", 1)
+	e.Sinks = []string{"log"}
+	e.Attempts = 2
+	mockOutbox := new(mocks.OutboxRepo)
+	mockOutbox.On("Lease", mock.Anything, "test", time.Minute).Return(true, response.ApiError{})
+	mockOutbox.On("Heads", mock.Anything, testDispatchTime, 10).Return([]models.OutboxEvent{e}, response.ApiError{})
+	mockOutbox.On("MarkFailed", mock.Anything, e.ID, "nats: unavailable", testDispatchTime.Add(4*time.Second)).Return(response.ApiError{})
+	logSink := &testSink{name: "log"}
+	natsSink := &testSink{name: "nats", err: errors.New("unavailable")}
+
+	relayed, apiErr := newTestRelay(mockOutbox, logSink, natsSink).RelayOnce(context.Background())
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, 0, relayed)
+	assert.Empty(t, logSink.published)
+	mockOutbox.AssertExpectations(t)
+	mockOutbox.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRelayWaitsForLease(t *testing.T) {
+	mockOutbox := new(mocks.OutboxRepo)
+	mockOutbox.On("Lease", mock.Anything, "test", time.Minute).Return(false, response.ApiError{})
+
+	relayed, apiErr := newTestRelay(mockOutbox, &testSink{name: "log"}).RelayOnce(context.Background())
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, 0, relayed)
+	mockOutbox.AssertNotCalled(t, "Heads", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWebhookSinkQueuesDeliveries(t *testing.T) {
+	hook := models.Webhook{ID: primitive.NewObjectID(), Events: []string{models.EventUserCreated}, Active: true}
+	mockHooks := new(mocks.WebhookRepo)
+	mockDeliveries := new(mocks.DeliveryRepo)
+	mockHooks.On("Subscribed", mock.Anything, models.EventUserCreated).Return([]models.Webhook{hook}, response.ApiError{})
+	mockDeliveries.On("SaveMany", mock.Anything, mock.AnythingOfType("[]models.WebhookDelivery")).Return(response.ApiError{})
+	e := models.NewEvent(models.EventUserCreated, "user", nil)
+
+	err := NewWebhookSink(NewWebhook(mockHooks, mockDeliveries, webhooks.NewGuard(true), logging.Discard())).Publish(context.Background(), e)
+
+	assert.Nil(t, err)
+	deliveries := mockDeliveries.Calls[0].Arguments.Get(1).([]models.WebhookDelivery)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, e.ID, deliveries[0].EventID)
+	assert.Equal(t, hook.ID, deliveries[0].WebhookID)
+}
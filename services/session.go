@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+	"user-api/auth"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+)
+
+// sessionTouchInterval bounds how often Verify writes last_seen_at, one write
+// per authenticated request would double the load of VerifyToken.
+const sessionTouchInterval = time.Minute
+
+type SessionService interface {
+	Start(ctx context.Context, u models.User, client models.SessionClient) (models.Session, response.ApiError)
+	List(ctx context.Context, userID string) ([]models.Session, response.ApiError)
+	Revoke(ctx context.Context, userID string, id string) response.ApiError
+	Verify(ctx context.Context, familyID string) (models.Session, response.ApiError)
+	RevokeOthers(ctx context.Context, userID string, keepFamilyID string) response.ApiError
+}
+
+type sessionServiceImpl struct {
+	r     repositories.SessionRepo
+	users repositories.UserRepo
+	log   *slog.Logger
+	now   func() time.Time
+}
+
+func NewSession(r repositories.SessionRepo, users repositories.UserRepo, logger *slog.Logger) SessionService {
+	return sessionServiceImpl{
+		r:     r,
+		users: users,
+		log:   logger.With("component", "session_service"),
+		now:   time.Now,
+	}
+}
+
+// Start records the session of a successful login, its FamilyID has to be
+// embedded in the issued jwt.
+func (svc sessionServiceImpl) Start(ctx context.Context, u models.User, client models.SessionClient) (models.Session, response.ApiError) {
+	familyID, err := auth.GenerateSessionID()
+	if err != nil {
+		svc.log.ErrorContext(ctx, "error generating session id", "error", err)
+		return models.Session{}, response.InternalServerError
+	}
+
+	device := client.Device
+	if device == "" {
+		device = deviceName(client.UserAgent)
+	}
+
+	now := svc.now().UTC()
+	s := models.Session{
+		UserID:     u.ID,
+		FamilyID:   familyID,
+		Device:     device,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(auth.TokenTTL),
+	}
+
+	return svc.r.Save(ctx, s)
+}
+
+func (svc sessionServiceImpl) List(ctx context.Context, userID string) ([]models.Session, response.ApiError) {
+	return svc.r.ListActive(ctx, userID, svc.now().UTC())
+}
+
+func (svc sessionServiceImpl) Revoke(ctx context.Context, userID string, id string) response.ApiError {
+	apiErr := svc.r.Revoke(ctx, userID, id, svc.now().UTC())
+	if apiErr.Status == 0 {
+		svc.log.InfoContext(ctx, "session revoked", "session_id", id)
+	}
+	return apiErr
+}
+
+// RevokeOthers ends every session of userID but keepFamilyID, an empty
+// keepFamilyID ending them all, and the jwts issued before sessions existed.
+func (svc sessionServiceImpl) RevokeOthers(ctx context.Context, userID string, keepFamilyID string) response.ApiError {
+	now := svc.now().UTC()
+	if apiErr := svc.r.RevokeAllExcept(ctx, userID, keepFamilyID, now); apiErr.Status != 0 {
+		return apiErr
+	}
+	if apiErr := svc.users.RevokeTokens(ctx, userID, now); apiErr.Status != 0 {
+		return apiErr
+	}
+	svc.log.InfoContext(ctx, "other sessions revoked")
+	return response.ApiError{}
+}
+
+// Verify resolves the session of a jwt, rejecting revoked and expired ones.
+func (svc sessionServiceImpl) Verify(ctx context.Context, familyID string) (models.Session, response.ApiError) {
+	s, apiErr := svc.r.FindByFamily(ctx, familyID)
+	if apiErr.Status != 0 {
+		if apiErr.Status == response.ResourceNotFoundError.Status {
+			return s, response.InvalidTokenError
+		}
+		return s, apiErr
+	}
+
+	now := svc.now().UTC()
+	if !s.Active(now) {
+		return s, response.InvalidTokenError
+	}
+
+	if now.Sub(s.LastSeenAt) >= sessionTouchInterval {
+		if apiErr := svc.r.TouchLastSeen(ctx, s.ID, now); apiErr.Status == 0 {
+			s.LastSeenAt = now
+		}
+	}
+
+	return s, response.ApiError{}
+}
+
+// deviceName gives a "Browser on OS" label from a User-Agent, good enough for
+// a user to recognise their own devices.
+func deviceName(ua string) string {
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"okhttp/", "OkHttp"},
+		{"Go-http-client/", "Go http client"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-api/auth"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newSessionService(r *mocks.SessionRepo) sessionServiceImpl {
+	return sessionServiceImpl{r: r, log: logging.Discard(), now: func() time.Time { return fixedNow }}
+}
+
+func TestStartSession(t *testing.T) {
+	mockRepo := new(mocks.SessionRepo)
+	svc := newSessionService(mockRepo)
+	user := models.User{ID: primitive.NewObjectID()}
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("models.Session")).Return(func(_ context.Context, s models.Session) models.Session {
+		return s
+	}, response.ApiError{})
+
+	s, apiErr := svc.Start(context.Background(), user, models.SessionClient{
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 Chrome/104.0 Safari/537.36",
+		IP:        "10.0.0.1",
+	})
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.NotEmpty(t, s.FamilyID)
+	assert.Equal(t, user.ID, s.UserID)
+	assert.Equal(t, "Chrome on macOS", s.Device)
+	assert.Equal(t, fixedNow.Add(auth.TokenTTL), s.ExpiresAt)
+}
+
+func TestVerifySession(t *testing.T) {
+	revokedAt := fixedNow.Add(-time.Minute)
+	cases := []struct {
+		name     string
+		stored   models.Session
+		findErr  response.ApiError
+		expected string
+	}{
+		{"active", models.Session{LastSeenAt: fixedNow, ExpiresAt: fixedNow.Add(time.Hour)}, response.ApiError{}, ""},
+		{"revoked", models.Session{ExpiresAt: fixedNow.Add(time.Hour), RevokedAt: &revokedAt}, response.ApiError{}, response.InvalidTokenError.Code},
+		{"expired", models.Session{ExpiresAt: fixedNow.Add(-time.Hour)}, response.ApiError{}, response.InvalidTokenError.Code},
+		{"unknown", models.Session{}, response.ResourceNotFoundError, response.InvalidTokenError.Code},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.SessionRepo)
+			svc := newSessionService(mockRepo)
+			mockRepo.On("FindByFamily", mock.Anything, "family").Return(tc.stored, tc.findErr)
+
+			_, apiErr := svc.Verify(context.Background(), "family")
+
+			assert.Equal(t, tc.expected, apiErr.Code)
+			mockRepo.AssertNotCalled(t, "TouchLastSeen", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestVerifySessionTouchesLastSeen(t *testing.T) {
+	mockRepo := new(mocks.SessionRepo)
+	svc := newSessionService(mockRepo)
+	stored := models.Session{ID: primitive.NewObjectID(), LastSeenAt: fixedNow.Add(-time.Hour), ExpiresAt: fixedNow.Add(time.Hour)}
+	mockRepo.On("FindByFamily", mock.Anything, "family").Return(stored, response.ApiError{})
+	mockRepo.On("TouchLastSeen", mock.Anything, stored.ID, fixedNow).Return(response.ApiError{})
+
+	s, apiErr := svc.Verify(context.Background(), "family")
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, fixedNow, s.LastSeenAt)
+}
+
+func TestDeviceName(t *testing.T) {
+	assert.Equal(t, "Unknown device", deviceName(""))
+	assert.Equal(t, "Firefox on Linux", deviceName("Mozilla/5.0 (X11; Linux x86_64; rv:103.0) Gecko/20100101 Firefox/103.0"))
+	assert.Equal(t, "Safari on iOS", deviceName("Mozilla/5.0 (iPhone; CPU iPhone OS 15_6 like Mac OS X) AppleWebKit/605.1.15 Version/15.6 Mobile/15E148 Safari/604.1"))
+	assert.Equal(t, "curl", deviceName("curl/7.84.0"))
+}
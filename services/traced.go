@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"user-api/models"
+	"user-api/response"
+	"user-api/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type tracedUserService struct {
+	next UserService
+}
+
+// NewTracedUserService decorates a UserService opening a span around every
+// method so the time spent hashing can be told apart from repository calls.
+func NewTracedUserService(next UserService) UserService {
+	return tracedUserService{next: next}
+}
+
+func (svc tracedUserService) Register(ctx context.Context, u models.User) (models.User, response.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer span.End()
+
+	u, apiErr := svc.next.Register(ctx, u)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return u, apiErr
+}
+
+func (svc tracedUserService) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, response.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAll")
+	defer span.End()
+
+	u, apiErr := svc.next.GetAll(ctx, filter, limit, page)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return u, apiErr
+}
+
+func (svc tracedUserService) FindByEmail(ctx context.Context, email string) (models.User, response.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindByEmail")
+	defer span.End()
+
+	u, apiErr := svc.next.FindByEmail(ctx, email)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return u, apiErr
+}
+
+func (svc tracedUserService) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindById")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", id))
+
+	u, apiErr := svc.next.FindById(ctx, id)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return u, apiErr
+}
+
+func (svc tracedUserService) DeleteById(ctx context.Context, id string) response.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteById")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", id))
+
+	apiErr := svc.next.DeleteById(ctx, id)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (svc tracedUserService) UpdateById(ctx context.Context, id string, u models.User) response.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateById")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", id))
+
+	apiErr := svc.next.UpdateById(ctx, id, u)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (svc tracedUserService) Login(ctx context.Context, email string, password string, client models.SessionClient) (string, response.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+
+	jwt, apiErr := svc.next.Login(ctx, email, password, client)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return jwt, apiErr
+}
+
+func (svc tracedUserService) ChangePassword(ctx context.Context, u models.User, current string, password string, keepSessionID string) response.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", u.ID.Hex()))
+
+	apiErr := svc.next.ChangePassword(ctx, u, current, password, keepSessionID)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (svc tracedUserService) ResetPassword(ctx context.Context, id string, password string) response.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", id))
+
+	apiErr := svc.next.ResetPassword(ctx, id, password)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (svc tracedUserService) SetRole(ctx context.Context, id string, role string) response.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.SetRole")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", id))
+
+	apiErr := svc.next.SetRole(ctx, id, role)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (svc tracedUserService) SetAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.SetAttributes")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", id))
+
+	apiErr := svc.next.SetAttributes(ctx, id, attrs)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return apiErr
+}
+
+func (svc tracedUserService) IssueToken(ctx context.Context, u models.User, client models.SessionClient) (string, response.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.IssueToken")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", u.ID.Hex()))
+
+	jwt, apiErr := svc.next.IssueToken(ctx, u, client)
+	tracing.RecordApiError(span, apiErr.Code, apiErr.Status)
+	return jwt, apiErr
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"user-api/auth"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func withSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestTracedFindByIdSpans(t *testing.T) {
+	recorder := withSpanRecorder(t)
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindById", mock.Anything, "id").Return(models.User{Name: "test"}, response.ApiError{})
+	svc := NewTracedUserService(NewUser(repositories.NewTracedUserRepo(mockUserRepo), nil, nil, nil, auth.PasswordPolicy{}, models.AgePolicy{}, testHasher, nil, logging.Discard()))
+
+	_, apiErr := svc.FindById(context.Background(), "id")
+
+	assert.Equal(t, 0, apiErr.Status)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "UserRepo.FindById", spans[0].Name())
+	assert.Equal(t, "UserService.FindById", spans[1].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, spans[1].SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+}
+
+func TestTracedLoginRecordsPasswordSpan(t *testing.T) {
+	recorder := withSpanRecorder(t)
+	email := "test@test.com"
+	user := models.User{Password: "test"}
+	user.HashPassword(testHasher)
+	mockUserRepo := new(mocks.UserRepo)
+	mockUserRepo.On("FindByField", mock.Anything, email, "email").Return(user, response.ApiError{})
+	mockSessionRepo := new(mocks.SessionRepo)
+	mockSessionRepo.On("Save", mock.Anything, mock.AnythingOfType("models.Session")).Return(models.Session{}, response.ApiError{})
+	svc := NewTracedUserService(NewUser(mockUserRepo, NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), nil, nil, auth.PasswordPolicy{}, models.AgePolicy{}, testHasher, nil, logging.Discard()))
+
+	_, apiErr := svc.Login(context.Background(), email, "test", models.SessionClient{})
+
+	assert.Equal(t, 0, apiErr.Status)
+	names := make([]string, 0)
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+	assert.Equal(t, []string{"password.compare", "UserService.Login"}, names)
+}
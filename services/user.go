@@ -1,108 +1,404 @@
 package services
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/url"
+	"time"
 	"user-api/auth"
+	"user-api/logging"
+	"user-api/metrics"
 	"user-api/models"
 	"user-api/repositories"
 	"user-api/response"
+	"user-api/tracing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserService interface {
-	Register(models.User) (models.User, response.ApiError)
-	GetAll(uint64, uint64) ([]models.User, response.ApiError)
-	FindByEmail(email string) (models.User, response.ApiError)
-	FindById(id string) (models.User, response.ApiError)
-	DeleteById(id string) response.ApiError
-	UpdateById(id string, u models.User) response.ApiError
-	Login(email string, password string) (string, response.ApiError)
+	Register(ctx context.Context, u models.User) (models.User, response.ApiError)
+	GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, response.ApiError)
+	FindByEmail(ctx context.Context, email string) (models.User, response.ApiError)
+	FindById(ctx context.Context, id string) (models.User, response.ApiError)
+	DeleteById(ctx context.Context, id string) response.ApiError
+	UpdateById(ctx context.Context, id string, u models.User) response.ApiError
+	Login(ctx context.Context, email string, password string, client models.SessionClient) (string, response.ApiError)
+	ChangePassword(ctx context.Context, u models.User, current string, password string, keepSessionID string) response.ApiError
+	// ResetPassword replaces the password of a user without knowing the
+	// current one and ends every session, for operators only.
+	ResetPassword(ctx context.Context, id string, password string) response.ApiError
+	SetRole(ctx context.Context, id string, role string) response.ApiError
+	// IssueToken starts a session for u and returns a jwt bound to it,
+	// the credentials must have been checked by the caller.
+	IssueToken(ctx context.Context, u models.User, client models.SessionClient) (string, response.ApiError)
+	// SetAttributes replaces the custom attributes of a user, read only ones
+	// included, for admins only.
+	SetAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError
 }
 
 type userServiceImpl struct {
-	r repositories.UserRepo
+	r          repositories.UserRepo
+	sessions   SessionService
+	attributes AttributeService
+	avatars    AvatarService
+	policy     auth.PasswordPolicy
+	ages       models.AgePolicy
+	hasher     auth.PasswordHasher
+	outbox     *Outbox
+	log        *slog.Logger
 }
 
-func NewUser(r repositories.UserRepo) UserService {
+func NewUser(r repositories.UserRepo, sessions SessionService, attributes AttributeService, avatars AvatarService, policy auth.PasswordPolicy, ages models.AgePolicy, hasher auth.PasswordHasher, outbox *Outbox, logger *slog.Logger) UserService {
 	return userServiceImpl{
-		r: r,
+		r:          r,
+		sessions:   sessions,
+		attributes: attributes,
+		avatars:    avatars,
+		policy:     policy,
+		ages:       ages,
+		hasher:     hasher,
+		outbox:     outbox,
+		log:        logger.With("component", "user_service"),
 	}
 }
 
-func (svc userServiceImpl) Register(u models.User) (models.User, response.ApiError) {
+func (svc userServiceImpl) Register(ctx context.Context, u models.User) (models.User, response.ApiError) {
+
+	if apiErr := svc.checkPassword(u.Password, u); apiErr.Status != 0 {
+		return u, apiErr
+	}
+	if failed := svc.ages.Check(u, time.Now()); len(failed) != 0 {
+		return u, response.NewValidationError(url.Values{"birth_date": failed})
+	}
+	// registering users cannot set read only attributes
+	if apiErr := svc.attributes.ValidateChange(ctx, nil, u.Attributes); apiErr.Status != 0 {
+		return u, apiErr
+	}
 
-	_, apiErr := svc.FindByEmail(u.Email)
+	_, apiErr := svc.FindByEmail(ctx, u.Email)
 
 	if apiErr.Status != 0 {
 		if apiErr.Status != response.ResourceNotFoundError.Status {
-			log.Printf("[USER SERVICE] Unexepcted error ocurred: %s", apiErr.Error)
+			svc.log.ErrorContext(ctx, "unexpected error checking email", "code", apiErr.Code)
 			return u, response.InternalServerError
 		}
 	} else {
-		log.Printf("[USER SERVICE] Email %s already in use", u.Email)
+		svc.log.InfoContext(ctx, "email already in use", "email_fingerprint", logging.Fingerprint(u.Email))
 		return u, response.EmailAlreadyInUse
 	}
 
-	err := u.HashPassword()
-	if err != nil {
-		log.Printf("[USER SERVICE] Error hashing password: %s", err.Error())
+	if err := svc.hashPassword(ctx, &u); err != nil {
+		svc.log.ErrorContext(ctx, "error hashing password", "error", err)
 		return u, response.InternalServerError
 	}
 
-	return u, svc.r.Save(u)
+	// the id is set here so the event can carry it
+	u.ID = primitive.NewObjectID()
+	newAddresses(u.Addresses)
+	apiErr = svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.Save(ctx, u)
+	}, models.NewEvent(models.EventUserCreated, u.ID.Hex(), nil))
+	return u, apiErr
 }
 
-func (svc userServiceImpl) GetAll(limit uint64, page uint64) ([]models.User, response.ApiError) {
-	u, err := svc.r.GetAll(limit, page)
+// newAddresses gives the addresses of a new user their ids and makes the
+// first one asked for the only default, the first one when none is.
+func newAddresses(addresses []models.Address) {
+	chosen := 0
+	for i, a := range addresses {
+		if a.Default {
+			chosen = i
+			break
+		}
+	}
+	for i := range addresses {
+		addresses[i].ID = primitive.NewObjectID()
+		addresses[i].Default = i == chosen
+	}
+}
+
+func (svc userServiceImpl) GetAll(ctx context.Context, filter models.UserFilter, limit uint64, page uint64) ([]models.User, response.ApiError) {
+	u, err := svc.r.GetAll(ctx, filter, limit, page)
 	if err != nil {
-		log.Printf("Error getting users from repository: %v", err.Error())
+		svc.log.ErrorContext(ctx, "error getting users from repository", "error", err)
 		return u, response.InternalServerError
 	}
 
 	return u, response.ApiError{}
 }
 
-func (svc userServiceImpl) Login(email, password string) (string, response.ApiError) {
-	u, apiErr := svc.FindByEmail(email)
+// Login checks the credentials and starts a session for client, the returned
+// jwt is bound to it.
+func (svc userServiceImpl) Login(ctx context.Context, email, password string, client models.SessionClient) (string, response.ApiError) {
+	u, apiErr := svc.FindByEmail(ctx, email)
 
 	if apiErr.Status != 0 {
 		if apiErr.Status == response.ResourceNotFoundError.Status {
-			log.Printf("[USER SERVICE] User not found with email: %s", email)
+			svc.log.InfoContext(ctx, "user not found", "email_fingerprint", logging.Fingerprint(email))
+			metrics.LoginFailed(metrics.ReasonUserNotFound)
+		} else {
+			metrics.LoginFailed(metrics.ReasonInternalError)
 		}
 		return "", apiErr
 	}
+	if apiErr := withPasswordHash(ctx, svc.r, &u); apiErr.Status != 0 {
+		metrics.LoginFailed(metrics.ReasonInternalError)
+		return "", apiErr
+	}
 
-	err := u.CheckPassword(password)
-
-	if err != nil {
-		log.Printf("[USER SERVICE] Invalid password")
+	if err := svc.comparePassword(ctx, u, password); err != nil {
+		svc.log.InfoContext(ctx, "invalid password", "user", u, "error", err)
+		metrics.LoginFailed(metrics.ReasonInvalidPassword)
 		return "", response.InvalidCredentialsError
 	}
 
-	jwt, err := auth.GenerateJWT(email)
+	if svc.hasher.NeedsRehash(u.Password) {
+		svc.rehash(ctx, u, password)
+	}
+
+	var jwt string
+	apiErr = svc.outbox.Store(ctx, func(ctx context.Context) (apiErr response.ApiError) {
+		jwt, apiErr = svc.IssueToken(ctx, u, client)
+		return apiErr
+	}, models.NewEvent(models.EventUserLogin, u.ID.Hex(), nil))
+	if apiErr.Status != 0 {
+		metrics.LoginFailed(metrics.ReasonInternalError)
+		return "", apiErr
+	}
+
+	metrics.LoginSucceeded()
+	return jwt, response.ApiError{}
+}
 
+func (svc userServiceImpl) IssueToken(ctx context.Context, u models.User, client models.SessionClient) (string, response.ApiError) {
+	session, apiErr := svc.sessions.Start(ctx, u, client)
+	if apiErr.Status != 0 {
+		return "", apiErr
+	}
+
+	jwt, err := auth.GenerateJWT(u.ID.Hex(), session.FamilyID)
 	if err != nil {
-		log.Printf("[USER SERVICE] Error generating JWT: %s", err.Error())
+		svc.log.ErrorContext(ctx, "error generating jwt", "error", err)
 		return "", response.InternalServerError
 	}
 
 	return jwt, response.ApiError{}
 }
 
-func (svc userServiceImpl) FindByEmail(email string) (models.User, response.ApiError) {
-	return svc.r.FindByField(email, "email")
+func (svc userServiceImpl) FindByEmail(ctx context.Context, email string) (models.User, response.ApiError) {
+	return svc.r.FindByField(ctx, email, "email")
+}
+
+func (svc userServiceImpl) FindById(ctx context.Context, id string) (models.User, response.ApiError) {
+
+	return svc.r.FindById(ctx, id)
+}
+
+// DeleteById deletes the user id and the images of their avatar.
+func (svc userServiceImpl) DeleteById(ctx context.Context, id string) response.ApiError {
+	u, apiErr := svc.r.FindById(ctx, id)
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+
+	apiErr = svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.DeleteById(ctx, id)
+	}, models.NewEvent(models.EventUserDeleted, id, nil))
+	if apiErr.Status == 0 && u.Avatar != nil {
+		svc.avatars.Remove(ctx, *u.Avatar)
+	}
+	return apiErr
+}
+
+// UpdateById replaces the editable fields of the user id as changed by the
+// user, nil attributes being left as they are.
+func (svc userServiceImpl) UpdateById(ctx context.Context, id string, u models.User) response.ApiError {
+	// a nil birth date leaves the stored one, already checked
+	if u.BirthDate != nil {
+		if failed := svc.ages.Check(u, time.Now()); len(failed) != 0 {
+			return response.NewValidationError(url.Values{"birth_date": failed})
+		}
+	}
+	if u.Attributes != nil {
+		stored, apiErr := svc.r.FindById(ctx, id)
+		if apiErr.Status != 0 {
+			return apiErr
+		}
+		if apiErr := svc.attributes.ValidateChange(ctx, stored.Attributes, u.Attributes); apiErr.Status != 0 {
+			return apiErr
+		}
+	}
+
+	return svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.UpdateByID(ctx, id, u)
+	}, updatedEvent(id, updatedFields(u)...))
+}
+
+// updatedEvent is the user.updated event of the user id listing the changed
+// fields, not their values.
+func updatedEvent(id string, fields ...string) models.Event {
+	return models.NewEvent(models.EventUserUpdated, id, map[string]interface{}{"fields": fields})
+}
+
+// updatedFields lists the fields UpdateById changes for u.
+func updatedFields(u models.User) []string {
+	fields := []string{"name"}
+	if u.Address != "" {
+		fields = append(fields, "address", "addresses")
+	}
+	if u.Locale != "" {
+		fields = append(fields, "locale")
+	}
+	if u.BirthDate != nil {
+		fields = append(fields, "birth_date")
+	}
+	if u.Attributes != nil {
+		fields = append(fields, "attributes")
+	}
+	return fields
 }
 
-func (svc userServiceImpl) FindById(id string) (models.User, response.ApiError) {
+func (svc userServiceImpl) SetAttributes(ctx context.Context, id string, attrs map[string]interface{}) response.ApiError {
+	if apiErr := svc.attributes.Validate(ctx, attrs); apiErr.Status != 0 {
+		return apiErr
+	}
 
-	return svc.r.FindById(id)
+	return svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.UpdateAttributes(ctx, id, attrs)
+	}, updatedEvent(id, "attributes"))
 }
 
-func (svc userServiceImpl) DeleteById(id string) response.ApiError {
+// ChangePassword replaces the password of u once current is verified and
+// revokes every session but keepSessionID, the one making the change.
+func (svc userServiceImpl) ChangePassword(ctx context.Context, u models.User, current string, password string, keepSessionID string) response.ApiError {
+	if apiErr := withPasswordHash(ctx, svc.r, &u); apiErr.Status != 0 {
+		return apiErr
+	}
+	if err := svc.comparePassword(ctx, u, current); err != nil {
+		svc.log.InfoContext(ctx, "invalid current password", "user", u, "error", err)
+		return response.InvalidCredentialsError
+	}
+
+	if apiErr := svc.checkPassword(password, u); apiErr.Status != 0 {
+		return apiErr
+	}
+
+	u.Password = password
+	if err := svc.hashPassword(ctx, &u); err != nil {
+		svc.log.ErrorContext(ctx, "error hashing password", "error", err)
+		return response.InternalServerError
+	}
+
+	apiErr := svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.UpdatePassword(ctx, u.ID.Hex(), u.Password)
+	}, models.NewEvent(models.EventUserPasswordChanged, u.ID.Hex(), nil))
+	if apiErr.Status != 0 {
+		return apiErr
+	}
 
-	return svc.r.DeleteById(id)
+	svc.log.InfoContext(ctx, "password changed", "user", u)
+	return svc.sessions.RevokeOthers(ctx, u.ID.Hex(), keepSessionID)
 }
 
-func (svc userServiceImpl) UpdateById(id string, u models.User) response.ApiError {
+func (svc userServiceImpl) ResetPassword(ctx context.Context, id string, password string) response.ApiError {
+	u, apiErr := svc.r.FindById(ctx, id)
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+
+	if apiErr := svc.checkPassword(password, u); apiErr.Status != 0 {
+		return apiErr
+	}
+
+	u.Password = password
+	if err := svc.hashPassword(ctx, &u); err != nil {
+		svc.log.ErrorContext(ctx, "error hashing password", "error", err)
+		return response.InternalServerError
+	}
+
+	apiErr = svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.UpdatePassword(ctx, id, u.Password)
+	}, models.NewEvent(models.EventUserPasswordChanged, id, nil))
+	if apiErr.Status != 0 {
+		return apiErr
+	}
 
-	return svc.r.UpdateByID(id, u)
+	svc.log.InfoContext(ctx, "password reset", "user", u)
+	return svc.sessions.RevokeOthers(ctx, id, "")
+}
+
+func (svc userServiceImpl) SetRole(ctx context.Context, id string, role string) response.ApiError {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return response.NewValidationError(url.Values{"role": []string{"in:user,admin"}})
+	}
+
+	apiErr := svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		return svc.r.UpdateRole(ctx, id, role)
+	}, updatedEvent(id, "role"))
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+
+	svc.log.InfoContext(ctx, "role changed", "user_id", id, "role", role)
+	return response.ApiError{}
+}
+
+// checkPassword applies the password policy to a password chosen by u,
+// reporting the failed rules as field errors of the password field.
+func (svc userServiceImpl) checkPassword(password string, u models.User) response.ApiError {
+	failed := svc.policy.Check(password, u.Name, u.Email)
+	if len(failed) == 0 {
+		return response.ApiError{}
+	}
+	return response.NewValidationError(url.Values{"password": failed})
+}
+
+// withPasswordHash reads the password hash of u when it lacks one, as the
+// users of the cache do.
+func withPasswordHash(ctx context.Context, r repositories.UserRepo, u *models.User) response.ApiError {
+	if u.Password != "" {
+		return response.ApiError{}
+	}
+	hash, apiErr := r.PasswordHash(ctx, u.ID.Hex())
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+	u.Password = hash
+	return response.ApiError{}
+}
+
+func (svc userServiceImpl) hashPassword(ctx context.Context, u *models.User) error {
+	_, span := tracing.Start(ctx, "password.hash")
+	defer span.End()
+	start := time.Now()
+	err := u.HashPassword(svc.hasher)
+	metrics.ObservePasswordHash(metrics.OperationHash, start)
+	return err
+}
+
+func (svc userServiceImpl) comparePassword(ctx context.Context, u models.User, password string) error {
+	_, span := tracing.Start(ctx, "password.compare")
+	defer span.End()
+	start := time.Now()
+	err := u.CheckPassword(svc.hasher, password)
+	metrics.ObservePasswordHash(metrics.OperationCompare, start)
+	return err
+}
+
+// rehash upgrades the stored hash of u to the configured algorithm and
+// parameters while the plain text password is known. A failure only delays
+// the upgrade to the next login. It deliberately bypasses the outbox: the
+// password stays the same, so there is no change to publish.
+func (svc userServiceImpl) rehash(ctx context.Context, u models.User, password string) {
+	u.Password = password
+	if err := svc.hashPassword(ctx, &u); err != nil {
+		svc.log.ErrorContext(ctx, "error rehashing password", "error", err)
+		return
+	}
+	if apiErr := svc.r.UpdatePassword(ctx, u.ID.Hex(), u.Password); apiErr.Status != 0 {
+		svc.log.ErrorContext(ctx, "error storing rehashed password", "code", apiErr.Code)
+		return
+	}
+	svc.log.InfoContext(ctx, "password rehashed", "user", u)
 }
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+	"user-api/userio"
+)
+
+type UserExportService interface {
+	Export(ctx context.Context, filter models.UserFilter, w userio.UserWriter) (int, response.ApiError)
+}
+
+type userExportServiceImpl struct {
+	r   repositories.UserRepo
+	log *slog.Logger
+}
+
+func NewUserExport(r repositories.UserRepo, logger *slog.Logger) UserExportService {
+	return userExportServiceImpl{
+		r:   r,
+		log: logger.With("component", "user_export_service"),
+	}
+}
+
+// Export streams every user matching filter to w and closes it, returning
+// how many users were written. Users are never held in memory as a whole so
+// the export size is bound by the cursor batch only.
+func (svc userExportServiceImpl) Export(ctx context.Context, filter models.UserFilter, w userio.UserWriter) (int, response.ApiError) {
+	count := 0
+	err := svc.r.Each(ctx, filter, func(u models.User) error {
+		if err := w.Write(u); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		svc.log.ErrorContext(ctx, "error exporting users", "written", count, "error", err)
+		return count, response.InternalServerError
+	}
+
+	if err := w.Close(); err != nil {
+		svc.log.ErrorContext(ctx, "error closing export", "written", count, "error", err)
+		return count, response.InternalServerError
+	}
+
+	svc.log.InfoContext(ctx, "users exported", "count", count)
+	return count, response.ApiError{}
+}
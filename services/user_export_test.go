@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+	"user-api/userio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func eachUser(users ...models.User) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(2).(func(models.User) error)
+		for _, u := range users {
+			if err := fn(u); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestExportStreamsMatchingUsers(t *testing.T) {
+	filter := models.UserFilter{Role: models.RoleAdmin}
+	r := new(mocks.UserRepo)
+	r.On("Each", mock.Anything, filter, mock.Anything).
+		Run(eachUser(models.User{Email: "a@test.com"}, models.User{Email: "b@test.com"})).
+		Return(nil)
+	svc := userExportServiceImpl{r: r, log: logging.Discard()}
+
+	var buf bytes.Buffer
+	w, err := userio.NewUserWriter(userio.FormatCSV, &buf, []string{"email"})
+	assert.Nil(t, err)
+
+	count, apiErr := svc.Export(context.Background(), filter, w)
+
+	assert.Equal(t, response.ApiError{}, apiErr)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "email\na@test.com\nb@test.com\n", buf.String())
+}
+
+func TestExportCursorError(t *testing.T) {
+	r := new(mocks.UserRepo)
+	r.On("Each", mock.Anything, models.UserFilter{}, mock.Anything).Return(errors.New("cursor closed"))
+	svc := userExportServiceImpl{r: r, log: logging.Discard()}
+
+	w, err := userio.NewUserWriter(userio.FormatNDJSON, &bytes.Buffer{}, userio.ExportColumns)
+	assert.Nil(t, err)
+
+	_, apiErr := svc.Export(context.Background(), models.UserFilter{}, w)
+
+	assert.Equal(t, response.InternalServerError, apiErr)
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"user-api/auth"
+	"user-api/dto"
+	"user-api/mappers"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+	"user-api/userio"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const DefaultImportBatchSize = 500
+
+// MaxImportBatchSize bounds the batches, each one being saved in a single
+// transaction.
+const MaxImportBatchSize = 1000
+
+type ImportOptions struct {
+	// DryRun validates every row and checks uniqueness without saving.
+	DryRun bool
+	// BatchSize defaults to DefaultImportBatchSize and is clamped to
+	// MaxImportBatchSize.
+	BatchSize int
+}
+
+type UserImportService interface {
+	Import(ctx context.Context, rows userio.RowReader, opts ImportOptions) (dto.ImportReport, response.ApiError)
+}
+
+type userImportServiceImpl struct {
+	r          repositories.UserRepo
+	attributes AttributeService
+	policy     auth.PasswordPolicy
+	ages       models.AgePolicy
+	hasher     auth.PasswordHasher
+	outbox     *Outbox
+	log        *slog.Logger
+}
+
+func NewUserImport(r repositories.UserRepo, attributes AttributeService, policy auth.PasswordPolicy, ages models.AgePolicy, hasher auth.PasswordHasher, outbox *Outbox, logger *slog.Logger) UserImportService {
+	return userImportServiceImpl{
+		r:          r,
+		attributes: attributes,
+		policy:     policy,
+		ages:       ages,
+		hasher:     hasher,
+		outbox:     outbox,
+		log:        logger.With("component", "user_import_service"),
+	}
+}
+
+// importRow is a row waiting for its batch to be flushed, res is final once
+// its status is set.
+type importRow struct {
+	req dto.ImportUserReq
+	res dto.ImportRowRes
+}
+
+// Import streams rows through the registration validation, reporting a
+// result per row. Rows are checked for uniqueness and saved by batches, a
+// failing row never aborts the import, only reading errors do.
+func (svc userImportServiceImpl) Import(ctx context.Context, rows userio.RowReader, opts ImportOptions) (dto.ImportReport, response.ApiError) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultImportBatchSize
+	}
+	opts.BatchSize = min(opts.BatchSize, MaxImportBatchSize)
+
+	report := dto.ImportReport{DryRun: opts.DryRun, Rows: make([]dto.ImportRowRes, 0)}
+	seen := make(map[string]bool)
+	batch := make([]importRow, 0, opts.BatchSize)
+	pending := 0
+
+	for {
+		n, req, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		row := importRow{req: req, res: dto.ImportRowRes{Row: n, Email: req.Email}}
+		var rowErr *userio.RowError
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &rowErr):
+			row.res.Status = dto.ImportInvalid
+			row.res.Code = response.BadRequestError.Code
+		case errors.As(err, &tooLarge):
+			svc.log.InfoContext(ctx, "import too large", "row", n)
+			return report, response.PayloadTooLargeError
+		case err != nil:
+			svc.log.InfoContext(ctx, "error reading import", "row", n, "error", err)
+			return report, response.BadRequestError.WithDetail(err.Error())
+		default:
+			svc.validate(ctx, &row, seen)
+		}
+
+		batch = append(batch, row)
+		if row.res.Status == "" {
+			pending++
+		}
+		if pending == opts.BatchSize {
+			svc.flush(ctx, batch, opts.DryRun, &report)
+			batch, pending = batch[:0], 0
+		}
+	}
+	svc.flush(ctx, batch, opts.DryRun, &report)
+
+	svc.log.InfoContext(ctx, "users imported", "dry_run", opts.DryRun, "total", report.Total, "created", report.Created, "failed", report.Failed)
+	return report, response.ApiError{}
+}
+
+func (svc userImportServiceImpl) validate(ctx context.Context, row *importRow, seen map[string]bool) {
+	v := row.req.ValidateFields()
+	if row.req.PasswordHash == "" && row.req.Password != "" {
+		if failed := svc.policy.Check(row.req.Password, row.req.Name, row.req.Email); len(failed) != 0 {
+			v = merge(v, url.Values{"password": failed})
+		}
+	}
+	if _, invalid := v["birth_date"]; !invalid {
+		u := mappers.RegisterReqToUser(row.req.RegisterUserReq)
+		if failed := svc.ages.Check(u, time.Now()); len(failed) != 0 {
+			v = merge(v, url.Values{"birth_date": failed})
+		}
+	}
+	if len(v) != 0 {
+		row.res.Status = dto.ImportInvalid
+		row.res.Code = response.ValidationError.Code
+		row.res.Errors = response.NewValidationError(v).Errors
+		return
+	}
+	// imports are run by admins, read only attributes included
+	if apiErr := svc.attributes.Validate(ctx, row.req.Attributes); apiErr.Status != 0 {
+		row.res.Status = dto.ImportInvalid
+		row.res.Code = apiErr.Code
+		row.res.Errors = apiErr.Errors
+		return
+	}
+
+	key := strings.ToLower(row.req.Email)
+	if seen[key] {
+		row.res.Status = dto.ImportDuplicate
+		row.res.Code = response.EmailAlreadyInUse.Code
+		return
+	}
+	seen[key] = true
+}
+
+func merge(a, b url.Values) url.Values {
+	for k, v := range b {
+		a[k] = append(a[k], v...)
+	}
+	return a
+}
+
+// flush checks the rows of batch still pending against the stored emails,
+// then hashes and saves them unless dryRun.
+func (svc userImportServiceImpl) flush(ctx context.Context, batch []importRow, dryRun bool, report *dto.ImportReport) {
+	emails := make([]string, 0, len(batch))
+	for _, row := range batch {
+		if row.res.Status == "" {
+			emails = append(emails, row.req.Email)
+		}
+	}
+
+	existing, apiErr := svc.r.ExistingEmails(ctx, emails)
+	toSave := make([]int, 0, len(emails))
+	for i := range batch {
+		row := &batch[i]
+		switch {
+		case row.res.Status != "":
+		case apiErr.Status != 0:
+			row.res.Status, row.res.Code = dto.ImportFailed, apiErr.Code
+		case existing[row.req.Email]:
+			row.res.Status, row.res.Code = dto.ImportDuplicate, response.EmailAlreadyInUse.Code
+		case dryRun:
+			row.res.Status = dto.ImportValid
+		default:
+			toSave = append(toSave, i)
+		}
+	}
+
+	if len(toSave) != 0 {
+		svc.save(ctx, batch, toSave)
+	}
+
+	for _, row := range batch {
+		report.Add(row.res)
+	}
+}
+
+func (svc userImportServiceImpl) save(ctx context.Context, batch []importRow, toSave []int) {
+	users := make([]models.User, len(toSave))
+	hashErrs := make([]error, len(toSave))
+
+	// hashing dominates the import time, spread it over the cpus
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for j, i := range toSave {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(j int, req dto.ImportUserReq) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			users[j] = mappers.RegisterReqToUser(req.RegisterUserReq)
+			// the id is set here so the event can carry it
+			users[j].ID = primitive.NewObjectID()
+			newAddresses(users[j].Addresses)
+			if req.PasswordHash != "" {
+				users[j].Password = req.PasswordHash
+				return
+			}
+			hashErrs[j] = users[j].HashPassword(svc.hasher)
+		}(j, batch[i].req)
+	}
+	wg.Wait()
+
+	valid := make([]models.User, 0, len(users))
+	validIdx := make([]int, 0, len(users))
+	for j, i := range toSave {
+		if hashErrs[j] != nil {
+			svc.log.ErrorContext(ctx, "error hashing password", "row", batch[i].res.Row, "error", hashErrs[j])
+			batch[i].res.Status, batch[i].res.Code = dto.ImportFailed, response.InternalServerError.Code
+			continue
+		}
+		valid = append(valid, users[j])
+		validIdx = append(validIdx, i)
+	}
+
+	errs := svc.saveMany(ctx, valid)
+	for k, i := range validIdx {
+		switch {
+		case errs[k].Status == 0:
+			batch[i].res.Status = dto.ImportCreated
+		case errs[k].Code == response.EmailAlreadyInUse.Code:
+			batch[i].res.Status, batch[i].res.Code = dto.ImportDuplicate, errs[k].Code
+		default:
+			batch[i].res.Status, batch[i].res.Code = dto.ImportFailed, errs[k].Code
+		}
+	}
+}
+
+// saveMany saves users with their user.created events in one transaction.
+// A failed insert aborting the transaction, the users of a batch with a
+// failure are saved again one transaction each so every row gets its result.
+func (svc userImportServiceImpl) saveMany(ctx context.Context, users []models.User) []response.ApiError {
+	if svc.outbox == nil || len(users) == 0 {
+		return svc.r.SaveMany(ctx, users)
+	}
+
+	evts := make([]models.Event, len(users))
+	for i, u := range users {
+		evts[i] = models.NewEvent(models.EventUserCreated, u.ID.Hex(), nil)
+	}
+	var errs []response.ApiError
+	apiErr := svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+		errs = svc.r.SaveMany(ctx, users)
+		for _, e := range errs {
+			if e.Status != 0 {
+				return e
+			}
+		}
+		return response.ApiError{}
+	}, evts...)
+	if apiErr.Status == 0 {
+		return errs
+	}
+
+	errs = make([]response.ApiError, len(users))
+	for i := range users {
+		errs[i] = svc.outbox.Store(ctx, func(ctx context.Context) response.ApiError {
+			return svc.r.SaveMany(ctx, users[i:i+1])[0]
+		}, evts[i])
+	}
+	return errs
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"user-api/auth"
+	"user-api/dto"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+	"user-api/userio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const importCSV = `email,name,age,address,password,password_hash
+new@test.com,Ann Lee,30,street,c0rrect-Horse,
+taken@test.com,Bob Lee,30,street,c0rrect-Horse,
+new@test.com,Ann Again,30,street,c0rrect-Horse,
+bad-email,Alan,30,street,c0rrect-Horse,
+weak@test.com,Carl Lee,30,street,password,
+hashed@test.com,Dan Lee,40,street,,$2a$04$UjUIXgIDQYjljDGgdZlwxu5Ktr8yTNgnqT7Ht0WJMwYpVoTZfP9XO
+`
+
+func newImportService(r *mocks.UserRepo) userImportServiceImpl {
+	return userImportServiceImpl{
+		r:          r,
+		attributes: testAttributes(),
+		policy:     auth.PasswordPolicy{MinLength: 8, Breached: auth.DefaultBreachedList()},
+		hasher:     testHasher,
+		log:        logging.Discard(),
+	}
+}
+
+func importRows(t *testing.T, in string) userio.RowReader {
+	rows, err := userio.NewRowReader(userio.FormatCSV, strings.NewReader(in))
+	assert.Nil(t, err)
+	return rows
+}
+
+func statuses(report dto.ImportReport) []string {
+	s := make([]string, 0, len(report.Rows))
+	for _, r := range report.Rows {
+		s = append(s, r.Status)
+	}
+	return s
+}
+
+func TestImportUsers(t *testing.T) {
+	mockRepo := new(mocks.UserRepo)
+	svc := newImportService(mockRepo)
+	mockRepo.On("ExistingEmails", mock.Anything, []string{"new@test.com", "taken@test.com", "hashed@test.com"}).
+		Return(map[string]bool{"taken@test.com": true}, response.ApiError{})
+	var saved []models.User
+	mockRepo.On("SaveMany", mock.Anything, mock.AnythingOfType("[]models.User")).Run(func(args mock.Arguments) {
+		saved = args.Get(1).([]models.User)
+	}).Return([]response.ApiError{{}, {}})
+
+	report, apiErr := svc.Import(context.Background(), importRows(t, importCSV), ImportOptions{})
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, []string{dto.ImportCreated, dto.ImportDuplicate, dto.ImportDuplicate, dto.ImportInvalid, dto.ImportInvalid, dto.ImportCreated}, statuses(report))
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 4, report.Failed)
+	assert.Equal(t, "EMAIL", report.Rows[3].Errors[0].Code)
+	assert.Equal(t, "BREACHED", report.Rows[4].Errors[0].Code)
+
+	assert.Len(t, saved, 2)
+	assert.Nil(t, saved[0].CheckPassword(testHasher, "c0rrect-Horse"))
+	assert.Equal(t, models.RoleUser, saved[0].Role)
+	assert.Equal(t, "$2a$04$UjUIXgIDQYjljDGgdZlwxu5Ktr8yTNgnqT7Ht0WJMwYpVoTZfP9XO", saved[1].Password)
+}
+
+func TestImportUsersDryRun(t *testing.T) {
+	mockRepo := new(mocks.UserRepo)
+	svc := newImportService(mockRepo)
+	mockRepo.On("ExistingEmails", mock.Anything, mock.Anything).Return(map[string]bool{}, response.ApiError{})
+
+	report, apiErr := svc.Import(context.Background(), importRows(t, importCSV), ImportOptions{DryRun: true})
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 3, report.Valid)
+	mockRepo.AssertNotCalled(t, "SaveMany", mock.Anything, mock.Anything)
+}
+
+func TestImportUsersBatches(t *testing.T) {
+	mockRepo := new(mocks.UserRepo)
+	svc := newImportService(mockRepo)
+	mockRepo.On("ExistingEmails", mock.Anything, mock.Anything).Return(map[string]bool{}, response.ApiError{})
+	mockRepo.On("SaveMany", mock.Anything, mock.Anything).Return([]response.ApiError{{}})
+
+	in := "email,name,age,address,password_hash\n"
+	for _, e := range []string{"a", "b", "c"} {
+		in += e + "@test.com,Name,30,street,$2a$04$UjUIXgIDQYjljDGgdZlwxu5Ktr8yTNgnqT7Ht0WJMwYpVoTZfP9XO\n"
+	}
+
+	report, _ := svc.Import(context.Background(), importRows(t, in), ImportOptions{BatchSize: 1})
+
+	assert.Equal(t, 3, report.Created)
+	assert.Equal(t, []int{1, 2, 3}, []int{report.Rows[0].Row, report.Rows[1].Row, report.Rows[2].Row})
+	mockRepo.AssertNumberOfCalls(t, "SaveMany", 3)
+}
+
+func TestImportClampsBatchSize(t *testing.T) {
+	mockRepo := new(mocks.UserRepo)
+	svc := newImportService(mockRepo)
+	mockRepo.On("ExistingEmails", mock.Anything, mock.Anything).Return(map[string]bool{}, response.ApiError{})
+	mockRepo.On("SaveMany", mock.Anything, mock.Anything).Return(func(_ context.Context, users []models.User) []response.ApiError {
+		return make([]response.ApiError, len(users))
+	})
+
+	var in strings.Builder
+	in.WriteString("email,name,age,address,password_hash\n")
+	for i := 0; i <= MaxImportBatchSize; i++ {
+		fmt.Fprintf(&in, "u%d@test.com,Name,30,street,$2a$04$UjUIXgIDQYjljDGgdZlwxu5Ktr8yTNgnqT7Ht0WJMwYpVoTZfP9XO\n", i)
+	}
+
+	report, _ := svc.Import(context.Background(), importRows(t, in.String()), ImportOptions{BatchSize: 10 * MaxImportBatchSize})
+
+	assert.Equal(t, MaxImportBatchSize+1, report.Created)
+	mockRepo.AssertNumberOfCalls(t, "SaveMany", 2)
+}
+
+func TestImportTooLarge(t *testing.T) {
+	mockRepo := new(mocks.UserRepo)
+	svc := newImportService(mockRepo)
+	mockRepo.On("ExistingEmails", mock.Anything, mock.Anything).Return(map[string]bool{}, response.ApiError{})
+	in := "email,name,age,address,password_hash\n" + strings.Repeat("a@test.com,Name,30,street,\n", 1000)
+	rows, err := userio.NewRowReader(userio.FormatCSV, http.MaxBytesReader(nil, io.NopCloser(strings.NewReader(in)), 8<<10))
+	assert.Nil(t, err)
+
+	_, apiErr := svc.Import(context.Background(), rows, ImportOptions{DryRun: true})
+
+	assert.Equal(t, response.PayloadTooLargeError, apiErr)
+}
+
+const importHashedCSV = `email,name,age,address,password_hash
+a@test.com,Ann Lee,30,street,$2a$04$UjUIXgIDQYjljDGgdZlwxu5Ktr8yTNgnqT7Ht0WJMwYpVoTZfP9XO
+b@test.com,Bob Lee,30,street,$2a$04$UjUIXgIDQYjljDGgdZlwxu5Ktr8yTNgnqT7Ht0WJMwYpVoTZfP9XO
+`
+
+func TestImportStoresUserCreatedEvents(t *testing.T) {
+	mockRepo := new(mocks.UserRepo)
+	mockOutbox := new(mocks.OutboxRepo)
+	svc := newImportService(mockRepo)
+	svc.outbox = NewOutbox(newTestTransactor(), mockOutbox)
+	mockRepo.On("ExistingEmails", mock.Anything, mock.Anything).Return(map[string]bool{}, response.ApiError{})
+	var saved []models.User
+	mockRepo.On("SaveMany", mock.Anything, mock.AnythingOfType("[]models.User")).Run(func(args mock.Arguments) {
+		saved = args.Get(1).([]models.User)
+	}).Return([]response.ApiError{{}, {}})
+	mockOutbox.On("Append", mock.Anything, mock.Anything, mock.Anything).Return(response.ApiError{})
+
+	report, _ := svc.Import(context.Background(), importRows(t, importHashedCSV), ImportOptions{})
+
+	assert.Equal(t, 2, report.Created)
+	events := mockOutbox.Calls[0].Arguments
+	assert.Len(t, events, 3)
+	for i, u := range saved {
+		e := events.Get(i + 1).(models.Event)
+		assert.Equal(t, models.EventUserCreated, e.Type)
+		assert.Equal(t, u.ID.Hex(), e.UserID)
+	}
+}
+
+func TestImportSavesUsersAloneAfterFailedBatch(t *testing.T) {
+	mockRepo := new(mocks.UserRepo)
+	mockOutbox := new(mocks.OutboxRepo)
+	svc := newImportService(mockRepo)
+	svc.outbox = NewOutbox(newTestTransactor(), mockOutbox)
+	mockRepo.On("ExistingEmails", mock.Anything, mock.Anything).Return(map[string]bool{}, response.ApiError{})
+	// b@test.com was registered meanwhile, aborting the batch transaction
+	mockRepo.On("SaveMany", mock.Anything, mock.MatchedBy(func(users []models.User) bool { return len(users) == 2 })).
+		Return([]response.ApiError{{}, response.EmailAlreadyInUse}).Once()
+	mockRepo.On("SaveMany", mock.Anything, mock.MatchedBy(func(users []models.User) bool { return users[0].Email == "a@test.com" })).
+		Return([]response.ApiError{{}}).Once()
+	mockRepo.On("SaveMany", mock.Anything, mock.MatchedBy(func(users []models.User) bool { return users[0].Email == "b@test.com" })).
+		Return([]response.ApiError{response.EmailAlreadyInUse}).Once()
+	mockOutbox.On("Append", mock.Anything, mock.Anything).Return(response.ApiError{})
+
+	report, _ := svc.Import(context.Background(), importRows(t, importHashedCSV), ImportOptions{})
+
+	assert.Equal(t, []string{dto.ImportCreated, dto.ImportDuplicate}, statuses(report))
+	mockOutbox.AssertNumberOfCalls(t, "Append", 1)
+	mockRepo.AssertExpectations(t)
+}
@@ -1,58 +1,86 @@
 package services
 
 import (
+	"context"
 	"testing"
+	"time"
+	"user-api/auth"
+	"user-api/logging"
 	mocks "user-api/mocks/repositories"
 	"user-api/models"
 	"user-api/response"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// testHasher keeps the tests fast, the service never hashes at this cost.
+var testHasher = auth.NewPasswordHasher(auth.BcryptHasher{Cost: bcrypt.MinCost})
+
+var testBirthDate = time.Date(2000, time.May, 17, 0, 0, 0, 0, time.UTC)
+
 func TestRegisterAlreadyExists(t *testing.T) {
-	userToBeRegister := models.NewUser("test", 20, "test@test.com", "pass", "add")
+	userToBeRegister := models.NewUser("test", &testBirthDate, "test@test.com", "pass", "add")
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
-	mockUserRepo.On("FindByField", userToBeRegister.Email, "email").Return(*userToBeRegister, response.ApiError{})
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, userToBeRegister.Email, "email").Return(*userToBeRegister, response.ApiError{})
 
-	_, apiErr := svc.Register(*userToBeRegister)
+	_, apiErr := svc.Register(context.Background(), *userToBeRegister)
 
 	assert.Equal(t, response.EmailAlreadyInUse.Code, apiErr.Code)
 }
 
 func TestRegisterInternalError(t *testing.T) {
-	userToBeRegister := models.NewUser("test", 20, "test@test.com", "pass", "add")
+	userToBeRegister := models.NewUser("test", &testBirthDate, "test@test.com", "pass", "add")
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
-	mockUserRepo.On("FindByField", userToBeRegister.Email, "email").Return(*userToBeRegister, response.InternalServerError)
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, userToBeRegister.Email, "email").Return(*userToBeRegister, response.InternalServerError)
 
-	_, apiErr := svc.Register(*userToBeRegister)
+	_, apiErr := svc.Register(context.Background(), *userToBeRegister)
 
 	assert.Equal(t, response.InternalServerError.Code, apiErr.Code)
 }
 
 func TestRegisterSuccess(t *testing.T) {
-	userToBeRegister := models.NewUser("test", 20, "test@test.com", "pass", "add")
+	userToBeRegister := models.NewUser("test", &testBirthDate, "test@test.com", "pass", "add")
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
-	mockUserRepo.On("FindByField", userToBeRegister.Email, "email").Return(*userToBeRegister, response.ResourceNotFoundError)
-	mockUserRepo.On("Save", mock.AnythingOfType("models.User")).Return(response.ApiError{})
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, userToBeRegister.Email, "email").Return(*userToBeRegister, response.ResourceNotFoundError)
+	mockUserRepo.On("Save", mock.Anything, mock.AnythingOfType("models.User")).Return(response.ApiError{})
 
-	user, apiErr := svc.Register(*userToBeRegister)
+	user, apiErr := svc.Register(context.Background(), *userToBeRegister)
 
-	assert.Nil(t, user.CheckPassword("pass"))
+	assert.Nil(t, user.CheckPassword(testHasher, "pass"))
 	assert.Equal(t, "", apiErr.Code)
 }
 
+func TestRegisterMakesOneAddressDefault(t *testing.T) {
+	userToBeRegister := models.NewUser("test", &testBirthDate, "test@test.com", "pass", "")
+	userToBeRegister.Addresses = []models.Address{{Label: models.AddressHome}, {Label: models.AddressBilling, Default: true}, {Label: models.AddressShipping, Default: true}}
+	mockUserRepo := new(mocks.UserRepo)
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, userToBeRegister.Email, "email").Return(models.User{}, response.ResourceNotFoundError)
+	mockUserRepo.On("Save", mock.Anything, mock.AnythingOfType("models.User")).Return(response.ApiError{})
+
+	user, apiErr := svc.Register(context.Background(), *userToBeRegister)
+
+	assert.Equal(t, "", apiErr.Code)
+	assert.Equal(t, []bool{false, true, false}, []bool{user.Addresses[0].Default, user.Addresses[1].Default, user.Addresses[2].Default})
+	for _, a := range user.Addresses {
+		assert.False(t, a.ID.IsZero())
+	}
+}
+
 func TestLoginUserNotFound(t *testing.T) {
 	email := "test@test.com"
 	password := "test"
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
-	mockUserRepo.On("FindByField", email, "email").Return(models.User{}, response.ResourceNotFoundError)
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, email, "email").Return(models.User{}, response.ResourceNotFoundError)
 
-	_, err := svc.Login(email, password)
+	_, err := svc.Login(context.Background(), email, password, models.SessionClient{})
 
 	assert.Equal(t, response.ResourceNotFoundError.Code, err.Code)
 }
@@ -61,12 +89,12 @@ func TestLoginInvalidCredentials(t *testing.T) {
 	email := "test@test.com"
 	password := "test"
 	user := models.User{Password: "tes"}
-	user.HashPassword()
+	user.HashPassword(testHasher)
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
-	mockUserRepo.On("FindByField", email, "email").Return(user, response.ApiError{})
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, email, "email").Return(user, response.ApiError{})
 
-	_, err := svc.Login(email, password)
+	_, err := svc.Login(context.Background(), email, password, models.SessionClient{})
 
 	assert.Equal(t, response.InvalidCredentialsError.Code, err.Code)
 }
@@ -75,25 +103,47 @@ func TestLoginSuccess(t *testing.T) {
 	email := "test@test.com"
 	password := "test"
 	user := models.User{Password: "test"}
-	user.HashPassword()
+	user.HashPassword(testHasher)
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
-	mockUserRepo.On("FindByField", email, "email").Return(user, response.ApiError{})
+	mockSessionRepo := new(mocks.SessionRepo)
+	svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, sessions: NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, email, "email").Return(user, response.ApiError{})
+	mockSessionRepo.On("Save", mock.Anything, mock.AnythingOfType("models.Session")).Return(models.Session{FamilyID: "family"}, response.ApiError{})
 
-	jwt, err := svc.Login(email, password)
+	jwt, err := svc.Login(context.Background(), email, password, models.SessionClient{})
 
 	assert.Equal(t, 0, err.Status)
-	assert.NotEmpty(t, jwt)
+	claims, vErr := auth.ValidateToken(jwt)
+	assert.Nil(t, vErr)
+	assert.Equal(t, "family", claims.SessionID)
+}
+
+func TestLoginReadsPasswordHashOfCachedUser(t *testing.T) {
+	email := "test@test.com"
+	hashed := models.User{Password: "test"}
+	hashed.HashPassword(testHasher)
+	user := models.User{ID: primitive.NewObjectID(), Email: email}
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessionRepo := new(mocks.SessionRepo)
+	svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, sessions: NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, email, "email").Return(user, response.ApiError{})
+	mockUserRepo.On("PasswordHash", mock.Anything, user.ID.Hex()).Return(hashed.Password, response.ApiError{})
+	mockSessionRepo.On("Save", mock.Anything, mock.AnythingOfType("models.Session")).Return(models.Session{}, response.ApiError{})
+
+	_, err := svc.Login(context.Background(), email, "test", models.SessionClient{})
+
+	assert.Equal(t, 0, err.Status)
+	mockUserRepo.AssertExpectations(t)
 }
 
 func TestShouldCallFindById(t *testing.T) {
 	id := "id"
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
 	user := models.User{Name: "test"}
-	mockUserRepo.On("FindById", id).Return(user, response.ApiError{})
+	mockUserRepo.On("FindById", mock.Anything, id).Return(user, response.ApiError{})
 
-	u, _ := svc.FindById(id)
+	u, _ := svc.FindById(context.Background(), id)
 
 	assert.Equal(t, user.Email, u.Email)
 }
@@ -101,11 +151,12 @@ func TestShouldCallFindById(t *testing.T) {
 func TestShouldCallDeleteById(t *testing.T) {
 	id := "id"
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
 	err := response.ApiError{Code: "CODE"}
-	mockUserRepo.On("DeleteById", id).Return(err)
+	mockUserRepo.On("FindById", mock.Anything, id).Return(models.User{}, response.ApiError{})
+	mockUserRepo.On("DeleteById", mock.Anything, id).Return(err)
 
-	apiErr := svc.DeleteById(id)
+	apiErr := svc.DeleteById(context.Background(), id)
 
 	assert.Equal(t, err.Code, apiErr.Code)
 }
@@ -113,12 +164,192 @@ func TestShouldCallDeleteById(t *testing.T) {
 func TestShouldCallUpdateById(t *testing.T) {
 	id := "id"
 	mockUserRepo := new(mocks.UserRepo)
-	svc := userServiceImpl{r: mockUserRepo}
+	svc := userServiceImpl{r: mockUserRepo, attributes: testAttributes(), hasher: testHasher, log: logging.Discard()}
 	user := models.User{Name: "test"}
 	err := response.ApiError{Code: "CODE"}
-	mockUserRepo.On("UpdateByID", id, user).Return(err)
+	mockUserRepo.On("UpdateByID", mock.Anything, id, user).Return(err)
 
-	apiErr := svc.UpdateById(id, user)
+	apiErr := svc.UpdateById(context.Background(), id, user)
 
 	assert.Equal(t, err.Code, apiErr.Code)
 }
+
+func TestUpdateByIdAgePolicy(t *testing.T) {
+	tooOld := time.Now().AddDate(-130, 0, 0)
+	future := time.Now().AddDate(0, 0, 1)
+	cases := map[string]struct {
+		birthDate time.Time
+		code      string
+	}{
+		"too old": {tooOld, "MAX_AGE"},
+		"future":  {future, "BEFORE_TODAY"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepo)
+			svc := userServiceImpl{r: mockUserRepo, ages: models.AgePolicy{Max: 120}, log: logging.Discard()}
+
+			apiErr := svc.UpdateById(context.Background(), "id", models.User{Name: "test", BirthDate: &c.birthDate})
+
+			assert.Equal(t, response.ValidationError.Code, apiErr.Code)
+			assert.Equal(t, "birth_date", apiErr.Errors[0].Field)
+			assert.Equal(t, c.code, apiErr.Errors[0].Code)
+			mockUserRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestChangePasswordInvalidCurrent(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID(), Password: "current"}
+	user.HashPassword(testHasher)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessionRepo := new(mocks.SessionRepo)
+	svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, sessions: NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), log: logging.Discard()}
+
+	apiErr := svc.ChangePassword(context.Background(), user, "wrong", "newpass", "family")
+
+	assert.Equal(t, response.InvalidCredentialsError.Code, apiErr.Code)
+	mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	mockSessionRepo.AssertNotCalled(t, "RevokeAllExcept", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID(), Password: "current"}
+	user.HashPassword(testHasher)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessionRepo := new(mocks.SessionRepo)
+	svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, sessions: NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), log: logging.Discard()}
+	var stored string
+	mockUserRepo.On("UpdatePassword", mock.Anything, user.ID.Hex(), mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		stored = args.String(2)
+	}).Return(response.ApiError{})
+	mockSessionRepo.On("RevokeAllExcept", mock.Anything, user.ID.Hex(), "family", mock.AnythingOfType("time.Time")).Return(response.ApiError{})
+	mockUserRepo.On("RevokeTokens", mock.Anything, user.ID.Hex(), mock.AnythingOfType("time.Time")).Return(response.ApiError{})
+
+	apiErr := svc.ChangePassword(context.Background(), user, "current", "newpass", "family")
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Nil(t, (&models.User{Password: stored}).CheckPassword(testHasher, "newpass"))
+	mockSessionRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestResetPasswordRevokesEverySession(t *testing.T) {
+	user := models.User{ID: primitive.NewObjectID(), Password: "forgotten"}
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessionRepo := new(mocks.SessionRepo)
+	svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, sessions: NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), log: logging.Discard()}
+	mockUserRepo.On("FindById", mock.Anything, user.ID.Hex()).Return(user, response.ApiError{})
+	mockUserRepo.On("UpdatePassword", mock.Anything, user.ID.Hex(), mock.AnythingOfType("string")).Return(response.ApiError{})
+	mockSessionRepo.On("RevokeAllExcept", mock.Anything, user.ID.Hex(), "", mock.AnythingOfType("time.Time")).Return(response.ApiError{})
+	mockUserRepo.On("RevokeTokens", mock.Anything, user.ID.Hex(), mock.AnythingOfType("time.Time")).Return(response.ApiError{})
+
+	apiErr := svc.ResetPassword(context.Background(), user.ID.Hex(), "newpass")
+
+	assert.Equal(t, 0, apiErr.Status)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestSetRoleRejectsUnknownRole(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepo)
+	svc := userServiceImpl{r: mockUserRepo, log: logging.Discard()}
+
+	apiErr := svc.SetRole(context.Background(), primitive.NewObjectID().Hex(), "root")
+
+	assert.Equal(t, response.ValidationError.Code, apiErr.Code)
+	mockUserRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRegisterPasswordPolicy(t *testing.T) {
+	userToBeRegister := models.NewUser("test", &testBirthDate, "test@test.com", "test1234", "add")
+	mockUserRepo := new(mocks.UserRepo)
+	svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, policy: auth.PasswordPolicy{MinLength: 10, RejectPersonal: true}, log: logging.Discard()}
+
+	_, apiErr := svc.Register(context.Background(), *userToBeRegister)
+
+	assert.Equal(t, response.ValidationError.Code, apiErr.Code)
+	codes := make([]string, 0)
+	for _, e := range apiErr.Errors {
+		assert.Equal(t, "password", e.Field)
+		codes = append(codes, e.Code)
+	}
+	assert.Equal(t, []string{"MIN_LENGTH", "PERSONAL_INFO"}, codes)
+	mockUserRepo.AssertNotCalled(t, "FindByField", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginRehashesOutdatedHash(t *testing.T) {
+	email := "test@test.com"
+	user := models.User{ID: primitive.NewObjectID(), Password: "test"}
+	user.HashPassword(testHasher)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessionRepo := new(mocks.SessionRepo)
+	argon := auth.Argon2idHasher{Memory: 1024, Iterations: 1}
+	svc := userServiceImpl{r: mockUserRepo, sessions: NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), hasher: auth.NewPasswordHasher(argon), log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, email, "email").Return(user, response.ApiError{})
+	mockSessionRepo.On("Save", mock.Anything, mock.AnythingOfType("models.Session")).Return(models.Session{}, response.ApiError{})
+	var stored string
+	mockUserRepo.On("UpdatePassword", mock.Anything, user.ID.Hex(), mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		stored = args.String(2)
+	}).Return(response.ApiError{})
+
+	_, apiErr := svc.Login(context.Background(), email, "test", models.SessionClient{})
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.False(t, argon.NeedsRehash(stored))
+	assert.Nil(t, argon.Verify(stored, "test"))
+}
+
+func TestLoginKeepsCurrentHash(t *testing.T) {
+	email := "test@test.com"
+	user := models.User{ID: primitive.NewObjectID(), Password: "test"}
+	user.HashPassword(testHasher)
+	mockUserRepo := new(mocks.UserRepo)
+	mockSessionRepo := new(mocks.SessionRepo)
+	svc := userServiceImpl{r: mockUserRepo, sessions: NewSession(mockSessionRepo, mockUserRepo, logging.Discard()), hasher: testHasher, log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, email, "email").Return(user, response.ApiError{})
+	mockSessionRepo.On("Save", mock.Anything, mock.AnythingOfType("models.Session")).Return(models.Session{}, response.ApiError{})
+
+	_, apiErr := svc.Login(context.Background(), email, "test", models.SessionClient{})
+
+	assert.Equal(t, 0, apiErr.Status)
+	mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRegisterAgePolicy(t *testing.T) {
+	tooYoung := time.Now().AddDate(-12, 0, 0)
+	future := time.Now().AddDate(0, 0, 1)
+	cases := map[string]struct {
+		birthDate *time.Time
+		code      string
+	}{
+		"too young": {&tooYoung, "MIN_AGE"},
+		"future":    {&future, "BEFORE_TODAY"},
+		"unknown":   {nil, "REQUIRED"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepo)
+			svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, ages: models.AgePolicy{Min: 13, Max: 120}, log: logging.Discard()}
+
+			_, apiErr := svc.Register(context.Background(), *models.NewUser("test", c.birthDate, "test@test.com", "pass", "add"))
+
+			assert.Equal(t, response.ValidationError.Code, apiErr.Code)
+			assert.Equal(t, "birth_date", apiErr.Errors[0].Field)
+			assert.Equal(t, c.code, apiErr.Errors[0].Code)
+			mockUserRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRegisterLegacyAgeWithinPolicy(t *testing.T) {
+	u := models.NewUser("test", nil, "test@test.com", "pass", "add")
+	u.Age = 30
+	mockUserRepo := new(mocks.UserRepo)
+	svc := userServiceImpl{r: mockUserRepo, hasher: testHasher, ages: models.AgePolicy{Min: 18}, attributes: testAttributes(), log: logging.Discard()}
+	mockUserRepo.On("FindByField", mock.Anything, u.Email, "email").Return(models.User{}, response.ResourceNotFoundError)
+	mockUserRepo.On("Save", mock.Anything, mock.AnythingOfType("models.User")).Return(response.ApiError{})
+
+	_, apiErr := svc.Register(context.Background(), *u)
+
+	assert.Equal(t, 0, apiErr.Status)
+}
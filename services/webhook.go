@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"time"
+	"user-api/events"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+	"user-api/webhooks"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookService interface {
+	// Publish queues a delivery of e to every active webhook subscribed to
+	// its type, for the outbox relay.
+	Publish(ctx context.Context, e models.Event) response.ApiError
+	// Create registers w with a generated secret, returned once in the
+	// created webhook.
+	Create(ctx context.Context, w models.Webhook) (models.Webhook, response.ApiError)
+	List(ctx context.Context) ([]models.Webhook, response.ApiError)
+	Get(ctx context.Context, id string) (models.Webhook, response.ApiError)
+	// Update replaces the url, events and active flag of the webhook id.
+	Update(ctx context.Context, id string, w models.Webhook) (models.Webhook, response.ApiError)
+	// Delete removes the webhook id with its queued and logged deliveries.
+	Delete(ctx context.Context, id string) response.ApiError
+	Deliveries(ctx context.Context, webhookID string, status string, limit uint64, page uint64) ([]models.WebhookDelivery, response.ApiError)
+	// Redeliver queues a new delivery of the event of deliveryID, whatever
+	// the outcome of the original one.
+	Redeliver(ctx context.Context, webhookID string, deliveryID string) (models.WebhookDelivery, response.ApiError)
+}
+
+type webhookServiceImpl struct {
+	r          repositories.WebhookRepo
+	deliveries repositories.DeliveryRepo
+	guard      webhooks.Guard
+	log        *slog.Logger
+	now        func() time.Time
+}
+
+// NewWebhook returns the WebhookService registering the urls guard allows.
+func NewWebhook(r repositories.WebhookRepo, deliveries repositories.DeliveryRepo, guard webhooks.Guard, logger *slog.Logger) WebhookService {
+	return webhookServiceImpl{
+		r:          r,
+		deliveries: deliveries,
+		guard:      guard,
+		log:        logger.With("component", "webhook_service"),
+		now:        time.Now,
+	}
+}
+
+func (svc webhookServiceImpl) Create(ctx context.Context, w models.Webhook) (models.Webhook, response.ApiError) {
+	if apiErr := svc.checkURL(ctx, w.URL); apiErr.Status != 0 {
+		return w, apiErr
+	}
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		svc.log.ErrorContext(ctx, "error generating webhook secret", "error", err)
+		return w, response.InternalServerError
+	}
+
+	now := svc.now().UTC()
+	w.ID = primitive.NilObjectID
+	w.Secret = secret
+	w.CreatedAt = now
+	w.UpdatedAt = now
+
+	w, apiErr := svc.r.Save(ctx, w)
+	if apiErr.Status != 0 {
+		return w, apiErr
+	}
+
+	svc.log.InfoContext(ctx, "webhook created", "webhook_id", w.ID.Hex(), "events", w.Events)
+	return w, response.ApiError{}
+}
+
+func (svc webhookServiceImpl) List(ctx context.Context) ([]models.Webhook, response.ApiError) {
+	return svc.r.List(ctx)
+}
+
+func (svc webhookServiceImpl) Get(ctx context.Context, id string) (models.Webhook, response.ApiError) {
+	return svc.r.FindById(ctx, id)
+}
+
+func (svc webhookServiceImpl) Update(ctx context.Context, id string, w models.Webhook) (models.Webhook, response.ApiError) {
+	stored, apiErr := svc.r.FindById(ctx, id)
+	if apiErr.Status != 0 {
+		return stored, apiErr
+	}
+	if apiErr := svc.checkURL(ctx, w.URL); apiErr.Status != 0 {
+		return stored, apiErr
+	}
+
+	stored.URL = w.URL
+	stored.Events = w.Events
+	stored.Active = w.Active
+	stored.UpdatedAt = svc.now().UTC()
+	if apiErr := svc.r.Update(ctx, stored); apiErr.Status != 0 {
+		return stored, apiErr
+	}
+
+	svc.log.InfoContext(ctx, "webhook updated", "webhook_id", id, "events", stored.Events, "active", stored.Active)
+	return stored, response.ApiError{}
+}
+
+// checkURL rejects the urls whose host resolves to an internal address, the
+// sender checking the address again when it dials.
+func (svc webhookServiceImpl) checkURL(ctx context.Context, rawURL string) response.ApiError {
+	if err := svc.guard.CheckURL(ctx, rawURL); err != nil {
+		svc.log.InfoContext(ctx, "webhook url rejected", "error", err)
+		return response.NewValidationError(url.Values{"url": []string{"public_host"}})
+	}
+	return response.ApiError{}
+}
+
+func (svc webhookServiceImpl) Delete(ctx context.Context, id string) response.ApiError {
+	w, apiErr := svc.r.FindById(ctx, id)
+	if apiErr.Status != 0 {
+		return apiErr
+	}
+
+	if apiErr := svc.r.Delete(ctx, id); apiErr.Status != 0 {
+		return apiErr
+	}
+	// a failure leaves deliveries the dispatcher marks dead
+	if apiErr := svc.deliveries.DeleteByWebhook(ctx, w.ID); apiErr.Status != 0 {
+		svc.log.WarnContext(ctx, "error deleting deliveries of deleted webhook", "webhook_id", id, "code", apiErr.Code)
+	}
+
+	svc.log.InfoContext(ctx, "webhook deleted", "webhook_id", id)
+	return response.ApiError{}
+}
+
+func (svc webhookServiceImpl) Deliveries(ctx context.Context, webhookID string, status string, limit uint64, page uint64) ([]models.WebhookDelivery, response.ApiError) {
+	w, apiErr := svc.r.FindById(ctx, webhookID)
+	if apiErr.Status != 0 {
+		return nil, apiErr
+	}
+
+	return svc.deliveries.List(ctx, w.ID, status, limit, page)
+}
+
+func (svc webhookServiceImpl) Redeliver(ctx context.Context, webhookID string, deliveryID string) (models.WebhookDelivery, response.ApiError) {
+	w, apiErr := svc.r.FindById(ctx, webhookID)
+	if apiErr.Status != 0 {
+		return models.WebhookDelivery{}, apiErr
+	}
+	original, apiErr := svc.deliveries.FindById(ctx, w.ID, deliveryID)
+	if apiErr.Status != 0 {
+		return original, apiErr
+	}
+
+	now := svc.now().UTC()
+	d := models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     w.ID,
+		EventID:       original.EventID,
+		Event:         original.Event,
+		UserID:        original.UserID,
+		Seq:           original.Seq,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		RedeliveryOf:  &original.ID,
+	}
+	if apiErr := svc.deliveries.SaveMany(ctx, []models.WebhookDelivery{d}); apiErr.Status != 0 {
+		return d, apiErr
+	}
+
+	svc.log.InfoContext(ctx, "webhook delivery queued again", "webhook_id", webhookID, "delivery_id", deliveryID)
+	return d, response.ApiError{}
+}
+
+// Publish queues the deliveries of e, the dispatcher sending them.
+func (svc webhookServiceImpl) Publish(ctx context.Context, e models.Event) response.ApiError {
+	hooks, apiErr := svc.r.Subscribed(ctx, e.Type)
+	if apiErr.Status != 0 || len(hooks) == 0 {
+		return apiErr
+	}
+
+	payload, err := events.Encode(e)
+	if err != nil {
+		svc.log.ErrorContext(ctx, "error encoding event", "event_id", e.ID.Hex(), "error", err)
+		return response.InternalServerError
+	}
+
+	now := svc.now().UTC()
+	deliveries := make([]models.WebhookDelivery, len(hooks))
+	for i, w := range hooks {
+		deliveries[i] = models.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     w.ID,
+			EventID:       e.ID,
+			Event:         e.Type,
+			UserID:        e.UserID,
+			Seq:           e.Seq,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+	}
+	return svc.deliveries.SaveMany(ctx, deliveries)
+}
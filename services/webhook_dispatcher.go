@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	"user-api/metrics"
+	"user-api/models"
+	"user-api/repositories"
+	"user-api/response"
+	"user-api/webhooks"
+)
+
+// WebhookOptions tunes the sending of the queued deliveries.
+type WebhookOptions struct {
+	// Workers is the number of deliveries sent concurrently.
+	Workers int
+	// PollInterval is how long an idle worker waits before looking for due
+	// deliveries again.
+	PollInterval time.Duration
+	// MaxAttempts is the number of failed attempts after which a delivery
+	// is dead.
+	MaxAttempts int
+	// RetryBase and RetryMax bound the exponential backoff between the
+	// attempts.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Lease is how long a claimed delivery is left to its worker before
+	// another one takes it over, longer than the request timeout.
+	Lease time.Duration
+}
+
+// WebhookDispatcher sends the queued deliveries to the webhooks, retrying
+// the failed ones with exponential backoff until they run out of attempts.
+type WebhookDispatcher struct {
+	deliveries repositories.DeliveryRepo
+	hooks      repositories.WebhookRepo
+	sender     webhooks.Sender
+	opts       WebhookOptions
+	log        *slog.Logger
+	now        func() time.Time
+}
+
+func NewWebhookDispatcher(deliveries repositories.DeliveryRepo, hooks repositories.WebhookRepo, sender webhooks.Sender, opts WebhookOptions, logger *slog.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		deliveries: deliveries,
+		hooks:      hooks,
+		sender:     sender,
+		opts:       opts,
+		log:        logger.With("component", "webhook_dispatcher"),
+		now:        time.Now,
+	}
+}
+
+// Run sends the due deliveries with the configured number of workers until
+// ctx is done, returning once they stopped.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *WebhookDispatcher) work(ctx context.Context) {
+	for {
+		sent, _ := d.DeliverNext(ctx)
+		if sent {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.opts.PollInterval):
+		}
+	}
+}
+
+// DeliverNext makes one attempt of the next due delivery, sent is false
+// when none is due.
+func (d *WebhookDispatcher) DeliverNext(ctx context.Context) (sent bool, apiErr response.ApiError) {
+	now := d.now()
+	delivery, found, apiErr := d.deliveries.Claim(ctx, now, d.opts.Lease)
+	if apiErr.Status != 0 || !found {
+		return false, apiErr
+	}
+
+	w, apiErr := d.hooks.FindById(ctx, delivery.WebhookID.Hex())
+	if apiErr.Status != 0 && apiErr.Status != response.ResourceNotFoundError.Status {
+		// left in flight, retried once the lease expires
+		return true, apiErr
+	}
+	if apiErr.Status != 0 || !w.Active {
+		attempt := models.Attempt{At: now.UTC(), Error: "webhook deleted or inactive"}
+		metrics.WebhookAttempted(metrics.WebhookDead)
+		return true, d.deliveries.Record(ctx, delivery.ID, attempt, models.DeliveryDead, delivery.NextAttemptAt)
+	}
+
+	attempt := d.sender.Send(ctx, w, delivery)
+	if webhooks.Succeeded(attempt) {
+		metrics.WebhookAttempted(metrics.WebhookDelivered)
+		return true, d.deliveries.Record(ctx, delivery.ID, attempt, models.DeliveryDelivered, delivery.NextAttemptAt)
+	}
+
+	failed := len(delivery.Attempts) + 1
+	if failed >= d.opts.MaxAttempts {
+		d.log.WarnContext(ctx, "webhook delivery dead", "webhook_id", w.ID.Hex(), "delivery_id", delivery.ID.Hex(), "attempts", failed, "error", attempt.Error)
+		metrics.WebhookAttempted(metrics.WebhookDead)
+		return true, d.deliveries.Record(ctx, delivery.ID, attempt, models.DeliveryDead, delivery.NextAttemptAt)
+	}
+
+	next := now.Add(webhooks.Backoff(failed, d.opts.RetryBase, d.opts.RetryMax)).UTC()
+	d.log.InfoContext(ctx, "webhook delivery failed", "webhook_id", w.ID.Hex(), "delivery_id", delivery.ID.Hex(), "attempts", failed, "next_attempt_at", next, "error", attempt.Error)
+	metrics.WebhookAttempted(metrics.WebhookRetried)
+	return true, d.deliveries.Record(ctx, delivery.ID, attempt, models.DeliveryPending, next)
+}
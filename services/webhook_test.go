@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-api/dto"
+	"user-api/logging"
+	mocks "user-api/mocks/repositories"
+	"user-api/models"
+	"user-api/response"
+	"user-api/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testWebhookOptions = WebhookOptions{
+	Workers:      1,
+	PollInterval: time.Millisecond,
+	MaxAttempts:  3,
+	RetryBase:    30 * time.Second,
+	RetryMax:     time.Hour,
+	Lease:        time.Minute,
+}
+
+var testDispatchTime = time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+// newTestDispatcher returns a dispatcher claiming d for hook at
+// testDispatchTime.
+func newTestDispatcher(hook models.Webhook, d models.WebhookDelivery) (*WebhookDispatcher, *mocks.DeliveryRepo) {
+	mockDeliveries := new(mocks.DeliveryRepo)
+	mockHooks := new(mocks.WebhookRepo)
+	mockDeliveries.On("Claim", mock.Anything, testDispatchTime, time.Minute).Return(d, true, response.ApiError{})
+	mockDeliveries.On("Record", mock.Anything, d.ID, mock.Anything, mock.Anything, mock.Anything).Return(response.ApiError{})
+	mockHooks.On("FindById", mock.Anything, hook.ID.Hex()).Return(hook, response.ApiError{})
+
+	dispatcher := NewWebhookDispatcher(mockDeliveries, mockHooks, webhooks.NewSender(time.Second, webhooks.NewGuard(true)), testWebhookOptions, logging.Discard())
+	dispatcher.now = func() time.Time { return testDispatchTime }
+	return dispatcher, mockDeliveries
+}
+
+func testDelivery(hook models.Webhook, attempts int) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:        primitive.NewObjectID(),
+		WebhookID: hook.ID,
+		EventID:   primitive.NewObjectID(),
+		Event:     models.EventUserCreated,
+		Payload:   `{"type":"user.created"}`,
+		Status:    models.DeliveryInFlight,
+		Attempts:  make([]models.Attempt, attempts),
+	}
+}
+
+func TestPublishQueuesSubscribedWebhooks(t *testing.T) {
+	hooks := []models.Webhook{{ID: primitive.NewObjectID()}, {ID: primitive.NewObjectID()}}
+	mockHooks := new(mocks.WebhookRepo)
+	mockDeliveries := new(mocks.DeliveryRepo)
+	mockHooks.On("Subscribed", mock.Anything, models.EventUserUpdated).Return(hooks, response.ApiError{})
+	mockDeliveries.On("SaveMany", mock.Anything, mock.Anything).Return(response.ApiError{})
+	svc := NewWebhook(mockHooks, mockDeliveries, webhooks.NewGuard(true), logging.Discard())
+	e := models.NewEvent(models.EventUserUpdated, "6530d1b2e4b0a1c2d3e4f5a6", map[string]interface{}{"fields": []string{"role"}})
+	e.Seq = 4
+
+	apiErr := svc.Publish(context.Background(), e)
+
+	assert.Equal(t, 0, apiErr.Status)
+	queued := mockDeliveries.Calls[0].Arguments.Get(1).([]models.WebhookDelivery)
+	assert.Len(t, queued, 2)
+	for i, d := range queued {
+		assert.Equal(t, hooks[i].ID, d.WebhookID)
+		assert.Equal(t, e.ID, d.EventID)
+		assert.Equal(t, e.UserID, d.UserID)
+		assert.Equal(t, int64(4), d.Seq)
+		assert.Equal(t, models.DeliveryPending, d.Status)
+	}
+	payload := dto.EventPayload{}
+	assert.Nil(t, json.Unmarshal([]byte(queued[0].Payload), &payload))
+	assert.Equal(t, e.ID.Hex(), payload.ID)
+	assert.Equal(t, models.EventUserUpdated, payload.Type)
+	assert.Equal(t, "6530d1b2e4b0a1c2d3e4f5a6", payload.UserID)
+}
+
+func TestCreateRejectsInternalURL(t *testing.T) {
+	mockHooks := new(mocks.WebhookRepo)
+	svc := NewWebhook(mockHooks, new(mocks.DeliveryRepo), webhooks.NewGuard(false), logging.Discard())
+
+	_, apiErr := svc.Create(context.Background(), models.Webhook{URL: "http://169.254.169.254/latest/meta-data", Events: []string{models.EventUserCreated}})
+
+	assert.Equal(t, response.ValidationError.Code, apiErr.Code)
+	assert.Equal(t, "url", apiErr.Errors[0].Field)
+	assert.Equal(t, "PUBLIC_HOST", apiErr.Errors[0].Code)
+	mockHooks.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestPublishWithoutSubscribers(t *testing.T) {
+	mockHooks := new(mocks.WebhookRepo)
+	mockDeliveries := new(mocks.DeliveryRepo)
+	mockHooks.On("Subscribed", mock.Anything, models.EventUserLogin).Return([]models.Webhook{}, response.ApiError{})
+	svc := NewWebhook(mockHooks, mockDeliveries, webhooks.NewGuard(true), logging.Discard())
+
+	apiErr := svc.Publish(context.Background(), models.NewEvent(models.EventUserLogin, "id", nil))
+
+	assert.Equal(t, 0, apiErr.Status)
+	mockDeliveries.AssertNotCalled(t, "SaveMany", mock.Anything, mock.Anything)
+}
+
+func TestDispatcherDeliversSignedPayload(t *testing.T) {
+	var body []byte
+	var header http.Header
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer receiver.Close()
+	hook := models.Webhook{ID: primitive.NewObjectID(), URL: receiver.URL, Secret: "whsec_test", Active: true}
+	d := testDelivery(hook, 0)
+	dispatcher, mockDeliveries := newTestDispatcher(hook, d)
+
+	sent, apiErr := dispatcher.DeliverNext(context.Background())
+
+	assert.True(t, sent)
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, d.Payload, string(body))
+	assert.Equal(t, models.EventUserCreated, header.Get(webhooks.EventHeader))
+	assert.Nil(t, webhooks.Verify("whsec_test", header.Get(webhooks.TimestampHeader), header.Get(webhooks.SignatureHeader), body, 5*time.Minute, time.Now()))
+	record := mockDeliveries.Calls[1].Arguments
+	assert.Equal(t, http.StatusOK, record.Get(2).(models.Attempt).StatusCode)
+	assert.Equal(t, models.DeliveryDelivered, record.Get(3))
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+	hook := models.Webhook{ID: primitive.NewObjectID(), URL: receiver.URL, Secret: "whsec_test", Active: true}
+	d := testDelivery(hook, 1)
+	dispatcher, mockDeliveries := newTestDispatcher(hook, d)
+
+	_, apiErr := dispatcher.DeliverNext(context.Background())
+
+	assert.Equal(t, 0, apiErr.Status)
+	record := mockDeliveries.Calls[1].Arguments
+	assert.Equal(t, http.StatusServiceUnavailable, record.Get(2).(models.Attempt).StatusCode)
+	assert.Equal(t, models.DeliveryPending, record.Get(3))
+	// second failure, twice the base delay
+	assert.Equal(t, testDispatchTime.Add(time.Minute), record.Get(4))
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	hook := models.Webhook{ID: primitive.NewObjectID(), URL: receiver.URL, Secret: "whsec_test", Active: true}
+	d := testDelivery(hook, testWebhookOptions.MaxAttempts-1)
+	dispatcher, mockDeliveries := newTestDispatcher(hook, d)
+
+	_, apiErr := dispatcher.DeliverNext(context.Background())
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, models.DeliveryDead, mockDeliveries.Calls[1].Arguments.Get(3))
+}
+
+func TestDispatcherInactiveWebhook(t *testing.T) {
+	hook := models.Webhook{ID: primitive.NewObjectID(), URL: "http://127.0.0.1:1", Active: false}
+	d := testDelivery(hook, 0)
+	dispatcher, mockDeliveries := newTestDispatcher(hook, d)
+
+	_, apiErr := dispatcher.DeliverNext(context.Background())
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.Equal(t, models.DeliveryDead, mockDeliveries.Calls[1].Arguments.Get(3))
+}
+
+func TestDispatcherIdle(t *testing.T) {
+	mockDeliveries := new(mocks.DeliveryRepo)
+	mockDeliveries.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(models.WebhookDelivery{}, false, response.ApiError{})
+	dispatcher := NewWebhookDispatcher(mockDeliveries, new(mocks.WebhookRepo), webhooks.NewSender(time.Second, webhooks.NewGuard(true)), testWebhookOptions, logging.Discard())
+
+	sent, apiErr := dispatcher.DeliverNext(context.Background())
+
+	assert.False(t, sent)
+	assert.Equal(t, 0, apiErr.Status)
+}
+
+func TestRedeliver(t *testing.T) {
+	hook := models.Webhook{ID: primitive.NewObjectID()}
+	original := testDelivery(hook, testWebhookOptions.MaxAttempts)
+	original.Status = models.DeliveryDead
+	original.UserID = "6530d1b2e4b0a1c2d3e4f5a6"
+	original.Seq = 2
+	mockHooks := new(mocks.WebhookRepo)
+	mockDeliveries := new(mocks.DeliveryRepo)
+	mockHooks.On("FindById", mock.Anything, hook.ID.Hex()).Return(hook, response.ApiError{})
+	mockDeliveries.On("FindById", mock.Anything, hook.ID, original.ID.Hex()).Return(original, response.ApiError{})
+	mockDeliveries.On("SaveMany", mock.Anything, mock.Anything).Return(response.ApiError{})
+	svc := NewWebhook(mockHooks, mockDeliveries, webhooks.NewGuard(true), logging.Discard())
+
+	d, apiErr := svc.Redeliver(context.Background(), hook.ID.Hex(), original.ID.Hex())
+
+	assert.Equal(t, 0, apiErr.Status)
+	assert.NotEqual(t, original.ID, d.ID)
+	assert.Equal(t, original.EventID, d.EventID)
+	assert.Equal(t, original.Payload, d.Payload)
+	assert.Equal(t, original.UserID, d.UserID)
+	assert.Equal(t, original.Seq, d.Seq)
+	assert.Equal(t, models.DeliveryPending, d.Status)
+	assert.Empty(t, d.Attempts)
+	assert.Equal(t, original.ID, *d.RedeliveryOf)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// BlobStore keeps binary objects, such as avatar images, under slash
+// separated keys and gives the public url they are served from.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete removes the object of key, a missing one is not an error.
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// checkKey rejects keys which could escape the root of a store.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalPutServeDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(dir, "http://localhost:8082/blobs/")
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	assert.Nil(t, s.Put(ctx, "avatars/u1/v1/64.png", strings.NewReader("image"), 5, "image/png"))
+	assert.Equal(t, "http://localhost:8082/blobs/avatars/u1/v1/64.png", s.URL("avatars/u1/v1/64.png"))
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatars/u1/v1/64.png", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image", rec.Body.String())
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+
+	assert.Nil(t, s.Delete(ctx, "avatars/u1/v1/64.png"))
+	assert.Nil(t, s.Delete(ctx, "avatars/u1/v1/64.png"))
+	_, err = os.Stat(filepath.Join(dir, "avatars"))
+	assert.True(t, os.IsNotExist(err), "empty directories are removed")
+	assert.Nil(t, s.Check(ctx))
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir(), "/blobs")
+	assert.Nil(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b"} {
+		assert.Error(t, s.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain"), key)
+	}
+}
+
+func TestLocalHandlerHidesDirectories(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir(), "/blobs")
+	assert.Nil(t, err)
+	assert.Nil(t, s.Put(context.Background(), "avatars/u1/64.png", strings.NewReader("x"), 1, "image/png"))
+
+	for _, path := range []string{"/", "/avatars/", "/avatars/u1", "/missing.png"} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestS3PutDelete runs against the MinIO of docker-compose.yml when
+// S3_TEST_ENDPOINT is set, e.g. localhost:9000.
+func TestS3PutDelete(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set")
+	}
+	s, err := NewS3Store(S3Config{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    "user-api-test",
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+	})
+	assert.Nil(t, err)
+	ctx := context.Background()
+	if ok, _ := s.client.BucketExists(ctx, s.bucket); !ok {
+		assert.Nil(t, s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{}))
+	}
+	assert.Nil(t, s.Check(ctx))
+
+	key := "avatars/u1/v1/64.png"
+	assert.Nil(t, s.Put(ctx, key, strings.NewReader("image"), 5, "image/png"))
+	assert.Equal(t, "http://"+endpoint+"/user-api-test/"+key, s.URL(key))
+
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	assert.Nil(t, err)
+	body, err := io.ReadAll(obj)
+	assert.Nil(t, err)
+	assert.Equal(t, "image", string(body))
+	info, err := obj.Stat()
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", info.ContentType)
+
+	assert.Nil(t, s.Delete(ctx, key))
+	assert.Nil(t, s.Delete(ctx, key))
+	_, err = s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	assert.Error(t, err)
+}
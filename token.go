@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"user-api/auth"
+	"user-api/config"
+	"user-api/models"
+
+	"github.com/urfave/cli/v2"
+)
+
+func tokenCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "token",
+		Usage: "Issue and inspect jwts",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "issue",
+				Usage:     "Start a session for a user and print its jwt",
+				ArgsUsage: "ID|EMAIL",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "device", Value: "user-api cli", Usage: "device name of the session"},
+				},
+				Action: issueToken,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Decode a jwt, telling whether it is valid and its session active",
+				ArgsUsage: "TOKEN (- for stdin)",
+				Action:    inspectToken,
+			},
+		},
+	}
+}
+
+func issueToken(c *cli.Context) error {
+	return withApp(c, func(ctx context.Context, a *app) error {
+		u, err := findUser(ctx, a, c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		client := models.SessionClient{Device: c.String("device"), UserAgent: "user-api cli"}
+		jwt, apiErr := a.userSvc.IssueToken(ctx, u, client)
+		if apiErr.Status != 0 {
+			return commandError("issuing token", apiErr)
+		}
+
+		fmt.Println(jwt)
+		return nil
+	})
+}
+
+type tokenInspection struct {
+	Valid     bool           `json:"valid"`
+	Error     string         `json:"error,omitempty"`
+	Session   string         `json:"session,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Claims    *auth.JWTClaim `json:"claims"`
+}
+
+func inspectToken(c *cli.Context) error {
+	token := c.Args().First()
+	if token == "-" {
+		b, err := readAllStdin()
+		if err != nil {
+			return err
+		}
+		token = b
+	}
+	if token == "" {
+		return cli.Exit("missing TOKEN argument", 2)
+	}
+	if t, ok := auth.BearerToken(token); ok {
+		token = t
+	}
+
+	configureJWT(config.Load())
+	claims, err := auth.InspectToken(token)
+	if claims == nil {
+		return fmt.Errorf("decoding token: %w", err)
+	}
+
+	res := tokenInspection{Valid: err == nil, Claims: claims, ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC()}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	if claims.SessionID == "" {
+		return writeJSON(os.Stdout, res)
+	}
+
+	return withApp(c, func(ctx context.Context, a *app) error {
+		res.Session = "active"
+		if _, apiErr := a.sessionSvc.Verify(ctx, claims.SessionID); apiErr.Status != 0 {
+			res.Session = "inactive"
+			res.Valid = false
+		}
+		return writeJSON(os.Stdout, res)
+	})
+}
+
+func readAllStdin() (string, error) {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
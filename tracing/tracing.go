@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	instrumentationName = "user-api"
+)
+
+type Config struct {
+	Exporter     string
+	OTLPEndpoint string
+	ServiceName  string
+	// SampleRatio is the fraction of new traces sampled, 0 sampling none
+	// and 1 all of them.
+	SampleRatio float64
+}
+
+// Init installs the global tracer provider and the W3C trace-context
+// propagator. The returned function flushes pending spans and must be called
+// on shutdown.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing sample ratio %v is not between 0 and 1", cfg.SampleRatio)
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tp := NewProvider(cfg, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// NewProvider builds a tracer provider with the service resource and sampler
+// from cfg. Tests pass a tracetest.SpanRecorder through sdktrace.WithSpanProcessor.
+func NewProvider(cfg Config, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	name := cfg.ServiceName
+	if name == "" {
+		name = instrumentationName
+	}
+
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(name))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}, opts...)
+
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Tracer returns the api tracer from the global provider, resolved on every
+// call so a provider installed after start up is honoured.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start opens a child span of the one carried in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// RecordApiError marks the span as failed when the call returned an error.
+func RecordApiError(span trace.Span, code string, status int) {
+	if status == 0 {
+		return
+	}
+	span.SetAttributes(semconv.ErrorTypeKey.String(code))
+	if status >= 500 {
+		span.SetStatus(codes.Error, code)
+	}
+}
+
+// RecordError marks the span as failed when err is not nil.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProviderWithZeroRatioSamplesNothing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := NewProvider(Config{SampleRatio: 0}, sdktrace.WithSpanProcessor(recorder))
+
+	_, span := tp.Tracer(instrumentationName).Start(context.Background(), "root")
+	span.End()
+
+	assert.False(t, span.SpanContext().IsSampled())
+	assert.Empty(t, recorder.Ended())
+}
+
+func TestInitRejectsRatioOutOfRange(t *testing.T) {
+	for _, ratio := range []float64{-0.1, 1.5} {
+		_, err := Init(context.Background(), Config{Exporter: ExporterStdout, SampleRatio: ratio})
+
+		assert.ErrorContains(t, err, "sample ratio")
+	}
+}
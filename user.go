@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"user-api/dto"
+	"user-api/mappers"
+	"user-api/models"
+	"user-api/response"
+
+	"github.com/urfave/cli/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// cliUser is a user as printed by the admin commands, which unlike the api
+// show the role.
+type cliUser struct {
+	dto.UserResponse
+	Address string `json:"address,omitempty"`
+	Role    string `json:"role"`
+}
+
+func toCLIUser(u models.User) cliUser {
+	role := u.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	return cliUser{UserResponse: mappers.UserToRes(u), Address: u.Address, Role: role}
+}
+
+func userCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "user",
+		Usage: "Manage users",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "Create a user, the password policy applies",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "email", Required: true},
+					&cli.StringFlag{Name: "name", Required: true},
+					&cli.StringFlag{Name: "birth-date", Required: true, Usage: "YYYY-MM-DD"},
+					&cli.StringFlag{Name: "address", Required: true},
+					&cli.StringFlag{Name: "locale"},
+					&cli.StringFlag{Name: "role", Value: models.RoleUser, Usage: "user or admin"},
+					&cli.StringFlag{Name: "password", Usage: "generated and printed on stderr when omitted"},
+				},
+				Action: createUser,
+			},
+			{
+				Name:  "list",
+				Usage: "List users as json",
+				Flags: []cli.Flag{
+					&cli.Uint64Flag{Name: "limit", Value: 50},
+					&cli.Uint64Flag{Name: "page", Value: 1},
+					&cli.StringFlag{Name: "email"},
+					&cli.StringFlag{Name: "locale"},
+					&cli.StringFlag{Name: "role"},
+				},
+				Action: listUsers,
+			},
+			{
+				Name:      "get",
+				Usage:     "Print a user as json",
+				ArgsUsage: "ID|EMAIL",
+				Action:    getUser,
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a user",
+				ArgsUsage: "ID|EMAIL",
+				Action:    deleteUser,
+			},
+			{
+				Name:      "set-role",
+				Usage:     "Change the role of a user",
+				ArgsUsage: "ID|EMAIL user|admin",
+				Action:    setRole,
+			},
+			{
+				Name:      "reset-password",
+				Usage:     "Replace the password of a user and end all their sessions",
+				ArgsUsage: "ID|EMAIL",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "password", Usage: "generated and printed on stderr when omitted"},
+				},
+				Action: resetPassword,
+			},
+		},
+	}
+}
+
+// findUser resolves the ID|EMAIL argument of the user commands.
+func findUser(ctx context.Context, a *app, ref string) (models.User, error) {
+	if ref == "" {
+		return models.User{}, cli.Exit("missing ID|EMAIL argument", 2)
+	}
+
+	var u models.User
+	var apiErr response.ApiError
+	if primitive.IsValidObjectID(ref) {
+		u, apiErr = a.userSvc.FindById(ctx, ref)
+	} else {
+		u, apiErr = a.userSvc.FindByEmail(ctx, ref)
+	}
+	if apiErr.Status != 0 {
+		return u, commandError("finding user "+ref, apiErr)
+	}
+	return u, nil
+}
+
+// passwordFlag returns the --password flag or a random password, printed on
+// stderr so it can be handed to the user.
+func passwordFlag(c *cli.Context) (string, error) {
+	if pw := c.String("password"); pw != "" {
+		return pw, nil
+	}
+
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	pw := base64.RawURLEncoding.EncodeToString(b)
+	fmt.Fprintf(os.Stderr, "generated password: %s\n", pw)
+	return pw, nil
+}
+
+func createUser(c *cli.Context) error {
+	password, err := passwordFlag(c)
+	if err != nil {
+		return err
+	}
+
+	role := c.String("role")
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return cli.Exit("role must be user or admin", 2)
+	}
+
+	// the same checks as the register endpoint
+	req := dto.RegisterUserReq{
+		Name:      c.String("name"),
+		Email:     c.String("email"),
+		BirthDate: c.String("birth-date"),
+		Password:  password,
+		Address:   c.String("address"),
+		Locale:    c.String("locale"),
+	}
+	if v := req.ValidateFields(); len(v) != 0 {
+		return cli.Exit(commandError("creating user", response.NewValidationError(v)), 2)
+	}
+
+	u := mappers.RegisterReqToUser(req)
+	u.Role = role
+
+	return withApp(c, func(ctx context.Context, a *app) error {
+		if _, apiErr := a.userSvc.Register(ctx, u); apiErr.Status != 0 {
+			return commandError("creating user", apiErr)
+		}
+
+		stored, err := findUser(ctx, a, u.Email)
+		if err != nil {
+			return err
+		}
+		return writeJSON(os.Stdout, toCLIUser(stored))
+	})
+}
+
+func listUsers(c *cli.Context) error {
+	filter := models.UserFilter{Email: c.String("email"), Locale: c.String("locale"), Role: c.String("role")}
+
+	return withApp(c, func(ctx context.Context, a *app) error {
+		users, apiErr := a.userSvc.GetAll(ctx, filter, c.Uint64("limit"), c.Uint64("page"))
+		if apiErr.Status != 0 {
+			return commandError("listing users", apiErr)
+		}
+
+		res := make([]cliUser, 0, len(users))
+		for _, u := range users {
+			res = append(res, toCLIUser(u))
+		}
+		return writeJSON(os.Stdout, res)
+	})
+}
+
+func getUser(c *cli.Context) error {
+	return withApp(c, func(ctx context.Context, a *app) error {
+		u, err := findUser(ctx, a, c.Args().First())
+		if err != nil {
+			return err
+		}
+		return writeJSON(os.Stdout, toCLIUser(u))
+	})
+}
+
+func deleteUser(c *cli.Context) error {
+	return withApp(c, func(ctx context.Context, a *app) error {
+		u, err := findUser(ctx, a, c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		if apiErr := a.userSvc.DeleteById(ctx, u.ID.Hex()); apiErr.Status != 0 {
+			return commandError("deleting user", apiErr)
+		}
+		if apiErr := a.sessionSvc.RevokeOthers(ctx, u.ID.Hex(), ""); apiErr.Status != 0 {
+			return commandError("revoking sessions", apiErr)
+		}
+
+		fmt.Fprintf(os.Stderr, "user %s deleted\n", u.ID.Hex())
+		return nil
+	})
+}
+
+func setRole(c *cli.Context) error {
+	role := c.Args().Get(1)
+	if role == "" {
+		return cli.Exit("missing role argument", 2)
+	}
+
+	return withApp(c, func(ctx context.Context, a *app) error {
+		u, err := findUser(ctx, a, c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		if apiErr := a.userSvc.SetRole(ctx, u.ID.Hex(), role); apiErr.Status != 0 {
+			return commandError("setting role", apiErr)
+		}
+
+		u.Role = role
+		return writeJSON(os.Stdout, toCLIUser(u))
+	})
+}
+
+func resetPassword(c *cli.Context) error {
+	return withApp(c, func(ctx context.Context, a *app) error {
+		u, err := findUser(ctx, a, c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		password, err := passwordFlag(c)
+		if err != nil {
+			return err
+		}
+
+		if apiErr := a.userSvc.ResetPassword(ctx, u.ID.Hex(), password); apiErr.Status != 0 {
+			return commandError("resetting password", apiErr)
+		}
+
+		fmt.Fprintf(os.Stderr, "password of %s reset, every session ended\n", u.ID.Hex())
+		return nil
+	})
+}
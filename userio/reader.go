@@ -0,0 +1,165 @@
+// Package userio reads and writes users in the bulk file formats of the
+// import and export endpoints.
+package userio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"user-api/dto"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxLineSize bounds a single NDJSON record.
+const maxLineSize = 1 << 20
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// RowError is returned by RowReader.Next for a record that cannot be decoded,
+// reading can go on with the next one.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// RowReader streams the rows of an import file, Next returns io.EOF once
+// every row was read.
+type RowReader interface {
+	Next() (row int, req dto.ImportUserReq, err error)
+}
+
+// NewRowReader reads CSV, whose header names the columns after the json
+// fields of dto.ImportUserReq, or NDJSON, one dto.ImportUserReq per line.
+func NewRowReader(format string, r io.Reader) (RowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &ndjsonReader{sc: sc}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// FormatFromContentType maps the media types of the supported formats.
+func FormatFromContentType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return FormatNDJSON
+	default:
+		return ""
+	}
+}
+
+type ndjsonReader struct {
+	sc  *bufio.Scanner
+	row int
+}
+
+func (r *ndjsonReader) Next() (int, dto.ImportUserReq, error) {
+	req := dto.ImportUserReq{}
+	for r.sc.Scan() {
+		line := strings.TrimSpace(r.sc.Text())
+		if line == "" {
+			continue
+		}
+		r.row++
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return r.row, req, &RowError{Row: r.row, Err: err}
+		}
+		return r.row, req, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return r.row, req, err
+	}
+	return r.row, req, io.EOF
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	row     int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, errors.New("csv header has no email column")
+	}
+
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+func (r *csvReader) Next() (int, dto.ImportUserReq, error) {
+	req := dto.ImportUserReq{}
+	record, err := r.r.Read()
+	if err == io.EOF {
+		return r.row, req, io.EOF
+	}
+	r.row++
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return r.row, req, &RowError{Row: r.row, Err: err}
+		}
+		return r.row, req, err
+	}
+
+	get := func(column string) string {
+		if i, ok := r.columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	req.Name = get("name")
+	req.Email = get("email")
+	req.Password = get("password")
+	req.PasswordHash = get("password_hash")
+	req.Address = get("address")
+	req.Locale = get("locale")
+	req.BirthDate = get("birth_date")
+	if age := get("age"); age != "" {
+		v, err := strconv.ParseUint(age, 10, 8)
+		if err != nil {
+			return r.row, req, &RowError{Row: r.row, Err: fmt.Errorf("invalid age %q", age)}
+		}
+		req.Age = uint8(v)
+	}
+
+	return r.row, req, nil
+}
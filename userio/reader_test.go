@@ -0,0 +1,78 @@
+package userio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, r RowReader) (rows []int, emails []string, rowErrs []int) {
+	for {
+		n, req, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			rowErrs = append(rowErrs, rowErr.Row)
+			continue
+		}
+		assert.Nil(t, err)
+		rows = append(rows, n)
+		emails = append(emails, req.Email)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	in := "Email,name,age,password_hash,unknown\n" +
+		"a@test.com,Ann,30,$2a$04$hash,x\n" +
+		"b@test.com,Bob,old,,\n" +
+		"c@test.com,\"Carl, Jr\",40\n"
+
+	r, err := NewRowReader(FormatCSV, strings.NewReader(in))
+	assert.Nil(t, err)
+
+	_, first, err := r.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "Ann", first.Name)
+	assert.Equal(t, uint8(30), first.Age)
+	assert.Equal(t, "$2a$04$hash", first.PasswordHash)
+
+	rows, emails, rowErrs := readAll(t, r)
+	assert.Equal(t, []int{3}, rows)
+	assert.Equal(t, []string{"c@test.com"}, emails)
+	assert.Equal(t, []int{2}, rowErrs)
+}
+
+func TestCSVReaderRequiresEmailColumn(t *testing.T) {
+	_, err := NewRowReader(FormatCSV, strings.NewReader("name,age\nAnn,30\n"))
+
+	assert.NotNil(t, err)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	in := `{"email":"a@test.com","name":"Ann","age":30}
+
+{"email":
+{"email":"c@test.com","password_hash":"$2a$04$hash"}
+`
+	r, err := NewRowReader(FormatNDJSON, strings.NewReader(in))
+	assert.Nil(t, err)
+
+	rows, emails, rowErrs := readAll(t, r)
+	assert.Equal(t, []int{1, 3}, rows)
+	assert.Equal(t, []string{"a@test.com", "c@test.com"}, emails)
+	assert.Equal(t, []int{2}, rowErrs)
+}
+
+func TestFormats(t *testing.T) {
+	_, err := NewRowReader("xml", strings.NewReader(""))
+
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	assert.Equal(t, FormatCSV, FormatFromContentType("text/csv; charset=utf-8"))
+	assert.Equal(t, FormatNDJSON, FormatFromContentType("application/x-ndjson"))
+	assert.Equal(t, "", FormatFromContentType("application/json"))
+}
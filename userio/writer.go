@@ -0,0 +1,258 @@
+package userio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"user-api/dto"
+	"user-api/mappers"
+	"user-api/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const FormatParquet = "parquet"
+
+// parquetRowGroupRows is the number of rows buffered before a row group is
+// flushed to the export, bounding its memory.
+const parquetRowGroupRows = 10000
+
+// ExportColumns are the columns an export may select, in their default
+// order. The password hash is never exported, address is the deprecated
+// free-form address and addresses the structured ones as a JSON array.
+var ExportColumns = []string{"id", "name", "email", "birth_date", "age", "address", "addresses", "locale", "role"}
+
+// ParseColumns reads a comma separated column selection, an empty selection
+// is every export column.
+func ParseColumns(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return ExportColumns, nil
+	}
+
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, col := range strings.Split(s, ",") {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if !isExportColumn(col) {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+		if seen[col] {
+			continue
+		}
+		seen[col] = true
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+func isExportColumn(col string) bool {
+	for _, c := range ExportColumns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType is the media type of an export in format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// UserWriter streams users to an export file, Close flushes what is buffered
+// and must be called once every user was written.
+type UserWriter interface {
+	Write(u models.User) error
+	Close() error
+}
+
+// NewUserWriter writes the selected columns of every user as CSV with a
+// header row, NDJSON or Parquet.
+func NewUserWriter(format string, w io.Writer, columns []string) (UserWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{w: bw, columns: columns}, nil
+	case FormatParquet:
+		return newParquetWriter(w, columns, parquetRowGroupRows), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// value is the exported value of col, missing optional values are nil.
+func value(u models.User, col string) interface{} {
+	switch col {
+	case "id":
+		return u.ID.Hex()
+	case "name":
+		return u.Name
+	case "email":
+		return u.Email
+	case "birth_date":
+		if u.BirthDate == nil {
+			return nil
+		}
+		return u.BirthDate.Format(dto.DateLayout)
+	case "age":
+		age, ok := u.AgeOn(time.Now())
+		if !ok {
+			return nil
+		}
+		return int32(age)
+	case "address":
+		return u.Address
+	case "addresses":
+		b, _ := json.Marshal(mappers.AddressesToRes(u.Addresses))
+		return json.RawMessage(b)
+	case "locale":
+		return u.Locale
+	case "role":
+		if u.Role == "" {
+			return models.RoleUser
+		}
+		return u.Role
+	default:
+		return nil
+	}
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw, columns: columns, record: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) Write(u models.User) error {
+	for i, col := range c.columns {
+		switch v := value(u, col).(type) {
+		case nil:
+			c.record[i] = ""
+		case string:
+			c.record[i] = escapeFormula(v)
+		case json.RawMessage:
+			c.record[i] = string(v)
+		default:
+			c.record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+// escapeFormula prefixes with a quote the cells spreadsheets would evaluate
+// as formulas, so user data cannot run in the spreadsheet of an admin.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+// Write encodes the record by hand so keys keep the column order.
+func (n *ndjsonWriter) Write(u models.User) error {
+	n.w.WriteByte('{')
+	for i, col := range n.columns {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(col)
+		val, err := json.Marshal(value(u, col))
+		if err != nil {
+			return err
+		}
+		n.w.Write(key)
+		n.w.WriteByte(':')
+		n.w.Write(val)
+	}
+	n.w.WriteByte('}')
+	return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
+
+type parquetWriter struct {
+	w         *parquet.Writer
+	columns   []string
+	groupRows int
+	buffered  int
+}
+
+// newParquetWriter flushes a row group every groupRows rows, as the writer
+// keeps the rows of the current group in memory.
+func newParquetWriter(w io.Writer, columns []string, groupRows int) *parquetWriter {
+	group := parquet.Group{}
+	for _, col := range columns {
+		switch col {
+		case "age":
+			group[col] = parquet.Optional(parquet.Int(32))
+			continue
+		case "birth_date":
+			group[col] = parquet.Optional(parquet.String())
+			continue
+		}
+		group[col] = parquet.String()
+	}
+	schema := parquet.NewSchema("user", group)
+	pw := parquet.NewWriter(w, schema, parquet.MaxRowsPerRowGroup(int64(groupRows)))
+	return &parquetWriter{w: pw, columns: columns, groupRows: groupRows}
+}
+
+func (p *parquetWriter) Write(u models.User) error {
+	row := make(map[string]any, len(p.columns))
+	for _, col := range p.columns {
+		v := value(u, col)
+		if raw, ok := v.(json.RawMessage); ok {
+			v = string(raw)
+		}
+		row[col] = v
+	}
+	if err := p.w.Write(row); err != nil {
+		return err
+	}
+
+	p.buffered++
+	if p.buffered < p.groupRows {
+		return nil
+	}
+	p.buffered = 0
+	return p.w.Flush()
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,135 @@
+package userio
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+	"user-api/models"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var exportUsers = []models.User{
+	{ID: primitive.NewObjectID(), Name: "Ann, Lee", Email: "a@test.com", Age: 30, Password: "$2a$04$hash", Role: models.RoleAdmin},
+	{ID: primitive.NewObjectID(), Name: "Bob", Email: "b@test.com", Password: "$2a$04$hash"},
+}
+
+func writeAll(t *testing.T, format string, columns []string) []byte {
+	var buf bytes.Buffer
+	w, err := NewUserWriter(format, &buf, columns)
+	assert.Nil(t, err)
+	for _, u := range exportUsers {
+		assert.Nil(t, w.Write(u))
+	}
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("")
+	assert.Nil(t, err)
+	assert.Equal(t, ExportColumns, columns)
+
+	columns, err = ParseColumns(" Email,name,email ")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"email", "name"}, columns)
+
+	_, err = ParseColumns("email,password")
+	assert.NotNil(t, err)
+}
+
+func TestCSVWriter(t *testing.T) {
+	out := writeAll(t, FormatCSV, []string{"email", "name", "age", "role"})
+
+	assert.Equal(t, "email,name,age,role\n"+
+		"a@test.com,\"Ann, Lee\",30,admin\n"+
+		"b@test.com,Bob,,user\n", string(out))
+}
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewUserWriter(FormatCSV, &buf, []string{"name", "address"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, w.Write(models.User{Name: "=HYPERLINK(\"http://evil\")", Address: "@SUM(A1)"}))
+	assert.Nil(t, w.Write(models.User{Name: "+1", Address: "-2 Main St"}))
+	assert.Nil(t, w.Write(models.User{Name: "Ann = Lee", Address: "1 Main St"}))
+	assert.Nil(t, w.Close())
+
+	assert.Equal(t, "name,address\n"+
+		"\"'=HYPERLINK(\"\"http://evil\"\")\",'@SUM(A1)\n"+
+		"'+1,'-2 Main St\n"+
+		"Ann = Lee,1 Main St\n", buf.String())
+}
+
+func TestBirthDateColumn(t *testing.T) {
+	birthDate := time.Date(1990, time.May, 17, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	w, err := NewUserWriter(FormatCSV, &buf, []string{"email", "birth_date", "age"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, w.Write(models.User{Email: "a@test.com", BirthDate: &birthDate}))
+	assert.Nil(t, w.Close())
+
+	age := models.AgeOn(birthDate, time.Now())
+	assert.Equal(t, fmt.Sprintf("email,birth_date,age\na@test.com,1990-05-17,%d\n", age), buf.String())
+}
+
+func TestNDJSONWriterKeepsColumnOrder(t *testing.T) {
+	out := writeAll(t, FormatNDJSON, []string{"name", "age", "email"})
+
+	assert.Equal(t, `{"name":"Ann, Lee","age":30,"email":"a@test.com"}`+"\n"+
+		`{"name":"Bob","age":null,"email":"b@test.com"}`+"\n", string(out))
+	assert.NotContains(t, string(writeAll(t, FormatNDJSON, ExportColumns)), "password")
+}
+
+func TestAddressesColumn(t *testing.T) {
+	u := models.User{Address: "1 Main St", Addresses: []models.Address{{Label: models.AddressHome, Line1: "1 Main St", City: "Springfield", Country: "US", Default: true}}}
+	var buf bytes.Buffer
+	w, err := NewUserWriter(FormatNDJSON, &buf, []string{"address", "addresses"})
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(u))
+	assert.Nil(t, w.Write(models.User{}))
+	assert.Nil(t, w.Close())
+
+	assert.Equal(t, `{"address":"1 Main St","addresses":[{"id":"000000000000000000000000","label":"home","line1":"1 Main St","city":"Springfield","country":"US","default":true}]}`+"\n"+
+		`{"address":"","addresses":[]}`+"\n", buf.String())
+}
+
+func TestParquetWriter(t *testing.T) {
+	out := writeAll(t, FormatParquet, ExportColumns)
+
+	f, err := parquet.OpenFile(bytes.NewReader(out), int64(len(out)))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(exportUsers)), f.NumRows())
+
+	names := make([]string, 0)
+	for _, field := range f.Schema().Fields() {
+		names = append(names, field.Name())
+	}
+	assert.ElementsMatch(t, ExportColumns, names)
+}
+
+func TestParquetWriterFlushesRowGroups(t *testing.T) {
+	var buf bytes.Buffer
+	w := newParquetWriter(&buf, []string{"id", "email"}, 2)
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, w.Write(models.User{ID: primitive.NewObjectID(), Email: fmt.Sprintf("%d@test.com", i)}))
+	}
+	assert.Nil(t, w.Close())
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), f.NumRows())
+	assert.Len(t, f.RowGroups(), 3)
+}
+
+func TestUnsupportedExportFormat(t *testing.T) {
+	_, err := NewUserWriter("xlsx", &bytes.Buffer{}, ExportColumns)
+
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,77 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which some clouds use
+// for their metadata services.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Guard keeps the webhooks from reaching the internal network, such as
+// the cloud metadata service at 169.254.169.254, unless private addresses
+// are allowed, e.g. in development.
+type Guard struct {
+	AllowPrivate bool
+	lookup       func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+func NewGuard(allowPrivate bool) Guard {
+	return Guard{AllowPrivate: allowPrivate, lookup: net.DefaultResolver.LookupIPAddr}
+}
+
+// Allowed reports whether webhooks may reach ip.
+func (g Guard) Allowed(ip net.IP) bool {
+	if g.AllowPrivate {
+		return true
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// CheckURL resolves the host of rawURL, failing with ErrForbiddenAddress
+// when one of its addresses is not allowed.
+func (g Guard) CheckURL(ctx context.Context, rawURL string) error {
+	if g.AllowPrivate {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	addrs, err := g.lookup(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", u.Hostname(), err)
+	}
+	for _, a := range addrs {
+		if !g.Allowed(a.IP) {
+			return fmt.Errorf("%s resolves to %s: %w", u.Hostname(), a.IP, ErrForbiddenAddress)
+		}
+	}
+	return nil
+}
+
+// DialContext dials the address resolved for the connection itself, so a
+// host resolving to another address after its registration is refused too.
+func (g Guard) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := net.Dialer{
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !g.Allowed(ip) {
+				return fmt.Errorf("dialing %s: %w", host, ErrForbiddenAddress)
+			}
+			return nil
+		},
+	}
+	return dialer.DialContext(ctx, network, address)
+}
@@ -0,0 +1,95 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"user-api/models"
+)
+
+const userAgent = "user-api-webhooks"
+
+// Sender posts the deliveries to the webhook endpoints.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender returns a Sender giving up on a request after timeout, redirects
+// are not followed so a delivery only reaches the registered url, and only
+// the addresses guard allows are dialed, without going through a proxy.
+func NewSender(timeout time.Duration, guard Guard) Sender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = guard.DialContext
+	return Sender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send makes one attempt of d to w, successful when the endpoint answers
+// with a 2xx status.
+func (s Sender) Send(ctx context.Context, w models.Webhook, d models.WebhookDelivery) (attempt models.Attempt) {
+	start := s.now()
+	attempt.At = start.UTC()
+	defer func() { attempt.Duration = s.now().Sub(start) }()
+
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(IDHeader, d.ID.Hex())
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(TimestampHeader, fmt.Sprint(timestamp))
+	req.Header.Set(SignatureHeader, Sign(w.Secret, timestamp, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+	// drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4<<10))
+
+	attempt.StatusCode = res.StatusCode
+	if !Succeeded(attempt) {
+		attempt.Error = fmt.Sprintf("unexpected status %d", res.StatusCode)
+	}
+	return attempt
+}
+
+func Succeeded(a models.Attempt) bool {
+	return a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// Backoff returns the delay before the attempt following the attempts-th
+// failed one, doubling from base up to max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
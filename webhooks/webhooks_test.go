@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testGuard lets the senders reach the httptest servers on loopback.
+var testGuard = NewGuard(true)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1760864400, 0)
+	body := []byte(`{"type":"user.created"}`)
+	sig := Sign("whsec_test", now.Unix(), body)
+
+	assert.Nil(t, Verify("whsec_test", "1760864400", sig, body, 5*time.Minute, now))
+	assert.Equal(t, ErrInvalidSignature, Verify("whsec_other", "1760864400", sig, body, 5*time.Minute, now))
+	assert.Equal(t, ErrInvalidSignature, Verify("whsec_test", "1760864400", sig, []byte(`{}`), 5*time.Minute, now))
+	assert.Equal(t, ErrInvalidSignature, Verify("whsec_test", "1760864401", sig, body, 5*time.Minute, now))
+	assert.Equal(t, ErrTimestampExpired, Verify("whsec_test", "1760864400", sig, body, 5*time.Minute, now.Add(time.Hour)))
+}
+
+func TestSendSignsDelivery(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	hook := models.Webhook{URL: receiver.URL, Secret: "whsec_test"}
+	d := models.WebhookDelivery{ID: primitive.NewObjectID(), Event: models.EventUserCreated, Payload: `{"type":"user.created"}`}
+
+	attempt := NewSender(time.Second, testGuard).Send(context.Background(), hook, d)
+
+	assert.True(t, Succeeded(attempt))
+	assert.Equal(t, "", attempt.Error)
+	r := <-received
+	assert.Equal(t, d.ID.Hex(), r.Header.Get(IDHeader))
+	assert.Equal(t, models.EventUserCreated, r.Header.Get(EventHeader))
+	assert.Nil(t, Verify("whsec_test", r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body, time.Minute, time.Now()))
+}
+
+func TestSendFailures(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	}))
+	hook := models.Webhook{URL: receiver.URL, Secret: "whsec_test"}
+
+	attempt := NewSender(time.Second, testGuard).Send(context.Background(), hook, models.WebhookDelivery{})
+	assert.False(t, Succeeded(attempt))
+	assert.Equal(t, http.StatusFound, attempt.StatusCode)
+
+	receiver.Close()
+	attempt = NewSender(time.Second, testGuard).Send(context.Background(), hook, models.WebhookDelivery{})
+	assert.False(t, Succeeded(attempt))
+	assert.NotEqual(t, "", attempt.Error)
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	hook := models.Webhook{URL: receiver.URL, Secret: "whsec_test"}
+
+	attempt := NewSender(time.Second, NewGuard(false)).Send(context.Background(), hook, models.WebhookDelivery{})
+
+	assert.False(t, Succeeded(attempt))
+	assert.Contains(t, attempt.Error, ErrForbiddenAddress.Error())
+}
+
+func TestGuardCheckURL(t *testing.T) {
+	guard := NewGuard(false)
+	guard.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		hosts := map[string]string{"hooks.example.com": "93.184.216.34", "internal.example.com": "10.0.0.7"}
+		if ip, ok := hosts[host]; ok {
+			return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+		}
+		return net.DefaultResolver.LookupIPAddr(ctx, host)
+	}
+
+	assert.Nil(t, guard.CheckURL(context.Background(), "https://hooks.example.com/users"))
+	for _, u := range []string{"https://internal.example.com/users", "http://169.254.169.254/latest/meta-data", "http://127.0.0.1:8080", "http://[::1]/", "http://100.100.100.200/"} {
+		assert.ErrorIs(t, guard.CheckURL(context.Background(), u), ErrForbiddenAddress, u)
+	}
+	assert.Nil(t, NewGuard(true).CheckURL(context.Background(), "http://127.0.0.1:8080"))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1, 30*time.Second, time.Hour))
+	assert.Equal(t, 60*time.Second, Backoff(2, 30*time.Second, time.Hour))
+	assert.Equal(t, 4*time.Minute, Backoff(4, 30*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, Backoff(20, 30*time.Second, time.Hour))
+}